@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/buildinfo"
+	"github.com/dwellir/grpc-code-examples/go/internal/decimal"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+	"github.com/dwellir/grpc-code-examples/go/internal/orderbook"
+	"github.com/dwellir/grpc-code-examples/go/internal/output"
+)
+
+// snapshotFile is an orderbook snapshot loaded from a file written by
+// get_orderbook_snapshot.go -out
+type snapshotFile struct {
+	path    string
+	time    json.RawMessage
+	ladders *orderbook.Ladders
+}
+
+func main() {
+	beforePath := flag.String("before", "", "earlier snapshot JSON file, e.g. written by get_orderbook_snapshot.go -out")
+	afterPath := flag.String("after", "", "later snapshot JSON file to compare against -before")
+	showChanges := flag.Int("changes", 0, "also print up to this many changed levels, 0 prints only the summary")
+	outFile := flag.String("out-file", "", "write the comparison to this file instead of stdout")
+	precision := flag.Int("precision", decimal.Auto, "decimals shown for prices and sizes, -1 shows as many as needed (up to 8)")
+	logLevel := flag.String("log-level", "info", "minimum level of log records on stderr: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "log record format on stderr: text or json")
+	showVersion := flag.Bool("version", false, "print the version, commit and build date and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(buildinfo.String())
+		return
+	}
+
+	if err := logging.Setup(*logLevel, *logFormat); err != nil {
+		logging.Fatal("invalid logging configuration", "err", err)
+	}
+	if *beforePath == "" || *afterPath == "" {
+		logging.Fatal("-before and -after are required")
+	}
+	if *showChanges < 0 {
+		logging.Fatal("-changes must not be negative", "changes", *showChanges)
+	}
+	if *precision < decimal.Auto {
+		logging.Fatal("-precision must be -1 or more", "precision", *precision)
+	}
+
+	before, err := loadSnapshot(*beforePath)
+	if err != nil {
+		logging.Fatal("failed to load snapshot", "path", *beforePath, "err", err)
+	}
+	after, err := loadSnapshot(*afterPath)
+	if err != nil {
+		logging.Fatal("failed to load snapshot", "path", *afterPath, "err", err)
+	}
+
+	out, err := output.Open(*outFile)
+	if err != nil {
+		logging.Fatal("failed to open output file", "path", *outFile, "err", err)
+	}
+	defer out.Close()
+
+	fmt.Fprintln(out, "🚀 Hyperliquid Go gRPC Client - Compare OrderBook Snapshots")
+	fmt.Fprintln(out, "============================================================")
+	fmt.Fprintf(out, "📂 Before: %s (time %s)\n", before.path, snapshotTime(before))
+	fmt.Fprintf(out, "📂 After:  %s (time %s)\n\n", after.path, snapshotTime(after))
+
+	printSpreadChange(out, before.ladders, after.ladders, *precision)
+	printDepthChange(out, "📗 Bids", before.ladders.Bids, after.ladders.Bids, *precision)
+	printDepthChange(out, "📕 Asks", before.ladders.Asks, after.ladders.Asks, *precision)
+
+	changes := orderbook.Diff(before.ladders, after.ladders)
+	fmt.Fprintln(out, "\n🔄 Level changes:")
+	for _, side := range []string{"bid", "ask"} {
+		counts := make(map[string]int)
+		for _, c := range changes {
+			if c.Side == side {
+				counts[c.Kind]++
+			}
+		}
+		fmt.Fprintf(out, "  • %s: %d added, %d removed, %d resized\n", side, counts[orderbook.Added], counts[orderbook.Removed], counts[orderbook.Resized])
+	}
+
+	if *showChanges > 0 && len(changes) > 0 {
+		fmt.Fprintln(out)
+		printChangedLevels(out, changes, *showChanges, *precision)
+	}
+}
+
+// loadSnapshot reads a snapshot file and parses its levels into ladders
+func loadSnapshot(path string) (*snapshotFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot struct {
+		Time   json.RawMessage `json:"time"`
+		Levels json.RawMessage `json:"levels"`
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	ladders, err := orderbook.ParseLevels(snapshot.Levels)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshotFile{path: path, time: snapshot.Time, ladders: ladders}, nil
+}
+
+// snapshotTime returns the snapshot's time as written, or "unknown"
+func snapshotTime(s *snapshotFile) string {
+	if len(s.time) == 0 {
+		return "unknown"
+	}
+	return string(s.time)
+}
+
+// printSpreadChange prints the spread of both snapshots and how it moved
+func printSpreadChange(w io.Writer, before, after *orderbook.Ladders, precision int) {
+	old, hasOld := before.Spread()
+	cur, hasCur := after.Spread()
+	switch {
+	case hasOld && hasCur:
+		fmt.Fprintf(w, "↔️  Spread: %s → %s (%s)\n", decimal.Format(old, precision), decimal.Format(cur, precision), formatDelta(old, cur, precision))
+	case hasCur:
+		fmt.Fprintf(w, "↔️  Spread: none → %s\n", decimal.Format(cur, precision))
+	case hasOld:
+		fmt.Fprintf(w, "↔️  Spread: %s → none\n", decimal.Format(old, precision))
+	default:
+		fmt.Fprintln(w, "↔️  Spread: none in either snapshot")
+	}
+}
+
+// printDepthChange prints the level count and total size of one side in both
+// snapshots
+func printDepthChange(w io.Writer, label string, before, after []orderbook.Level, precision int) {
+	oldSize, curSize := orderbook.TotalSize(before), orderbook.TotalSize(after)
+	fmt.Fprintf(w, "%s: %d → %d levels (%+d), total size %s → %s (%s)\n", label,
+		len(before), len(after), len(after)-len(before),
+		decimal.Format(oldSize, precision), decimal.Format(curSize, precision), formatDelta(oldSize, curSize, precision))
+}
+
+// printChangedLevels prints up to limit changed levels: + added, - removed
+// and ~ resized
+func printChangedLevels(w io.Writer, changes []orderbook.Change, limit, precision int) {
+	for _, c := range changes[:min(limit, len(changes))] {
+		icon := "📗"
+		if c.Side == "ask" {
+			icon = "📕"
+		}
+		switch c.Kind {
+		case orderbook.Added:
+			fmt.Fprintf(w, "  %s %s + %s (size %s)\n", icon, c.Side, decimal.Format(c.Price, precision), decimal.Format(c.NewSize, precision))
+		case orderbook.Removed:
+			fmt.Fprintf(w, "  %s %s - %s (was %s)\n", icon, c.Side, decimal.Format(c.Price, precision), decimal.Format(c.OldSize, precision))
+		case orderbook.Resized:
+			fmt.Fprintf(w, "  %s %s ~ %s %s → %s\n", icon, c.Side, decimal.Format(c.Price, precision), decimal.Format(c.OldSize, precision), decimal.Format(c.NewSize, precision))
+		}
+	}
+	if len(changes) > limit {
+		fmt.Fprintf(w, "  ... and %d more\n", len(changes)-limit)
+	}
+}
+
+// formatDelta formats cur - old with an explicit sign
+func formatDelta(old, cur *big.Rat, precision int) string {
+	delta := new(big.Rat).Sub(cur, old)
+	if delta.Sign() > 0 {
+		return "+" + decimal.Format(delta, precision)
+	}
+	return decimal.Format(delta, precision)
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/buildinfo"
+	"github.com/dwellir/grpc-code-examples/go/internal/candle"
+	"github.com/dwellir/grpc-code-examples/go/internal/client"
+	"github.com/dwellir/grpc-code-examples/go/internal/color"
+	"github.com/dwellir/grpc-code-examples/go/internal/config"
+	"github.com/dwellir/grpc-code-examples/go/internal/decimal"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+	"github.com/dwellir/grpc-code-examples/go/internal/model"
+	"github.com/dwellir/grpc-code-examples/go/internal/output"
+	"github.com/dwellir/grpc-code-examples/go/internal/shutdown"
+)
+
+// candleJSON is a candle as written with -output json. Prices and volume are
+// decimal strings so no digits are lost.
+type candleJSON struct {
+	Symbol   string `json:"symbol"`
+	Start    int64  `json:"start"`
+	Interval string `json:"interval"`
+	Open     string `json:"open"`
+	High     string `json:"high"`
+	Low      string `json:"low"`
+	Close    string `json:"close"`
+	Volume   string `json:"volume"`
+	Fills    int    `json:"fills"`
+	// Complete is false for the candles still open when the stream ended
+	Complete bool `json:"complete"`
+}
+
+func main() {
+	cfg := config.Register(flag.CommandLine)
+	interval := flag.Duration("interval", time.Minute, "candle interval, e.g. 1m, 5m or 1h; candles start on multiples of it counted from the Unix epoch")
+	symbols := flag.String("symbols", "", "comma-separated symbols to build candles for (case-insensitive), empty builds all")
+	outputFormat := flag.String("output", "text", "output format: text (one line per candle) or json (one candle object per line)")
+	outFile := flag.String("out-file", "", "write the candles to this file instead of stdout")
+	precision := flag.Int("precision", decimal.Auto, "decimals shown for prices and volume with -output text, -1 shows as many as needed (up to 8)")
+	keepaliveTime := flag.Duration("keepalive-time", 30*time.Second, "interval between keepalive pings on an idle connection, 0 disables keepalive")
+	keepaliveTimeout := flag.Duration("keepalive-timeout", 10*time.Second, "how long to wait for a keepalive ping to be acknowledged")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 8*time.Second, "after Ctrl+C or SIGTERM, force exit if the summary isn't printed within this long, 0 waits indefinitely")
+	idleTimeout := flag.Duration("idle-timeout", 60*time.Second, "restart the stream when no message arrives for this long, 0 disables")
+	breakerThreshold := flag.Int("breaker-threshold", 5, "open the circuit breaker after this many consecutive reconnect failures within -breaker-window, 0 disables it")
+	breakerWindow := flag.Duration("breaker-window", 5*time.Minute, "time window in which -breaker-threshold failures open the circuit breaker")
+	breakerCooldown := flag.Duration("breaker-cooldown", 5*time.Minute, "how long an open circuit breaker waits before trying again; with a single endpoint the example exits instead")
+	maxMsgSize := config.ByteSize(client.DefaultMaxMessageSize)
+	flag.Var(&maxMsgSize, "max-msg-size", "maximum message size to receive, e.g. 256MB or 1GB")
+	showVersion := flag.Bool("version", false, "print the version, commit and build date and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(buildinfo.String())
+		return
+	}
+
+	if err := cfg.LoadFile(); err != nil {
+		logging.Fatal("invalid -config file", "err", err)
+	}
+	if err := cfg.SetupLogging(); err != nil {
+		logging.Fatal("invalid logging configuration", "err", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	if warning := cfg.SecurityWarning(); warning != "" {
+		slog.Warn(warning)
+	}
+	// Block times have millisecond resolution
+	if *interval < time.Second || *interval%time.Millisecond != 0 {
+		logging.Fatal("-interval must be at least 1s and a whole number of milliseconds", "interval", *interval)
+	}
+	if *outputFormat != "text" && *outputFormat != "json" {
+		logging.Fatal("unknown -output (expected text or json)", "output", *outputFormat)
+	}
+	if *precision < decimal.Auto {
+		logging.Fatal("-precision must be -1 or more", "precision", *precision)
+	}
+
+	// -dry-run stops here, before any file is written or stream opened
+	if cfg.DryRun {
+		color.Setup(os.Stdout, false)
+		os.Exit(cfg.RunDryRun(os.Stdout))
+	}
+
+	out, err := output.Open(*outFile)
+	if err != nil {
+		logging.Fatal("failed to open output file", "path", *outFile, "err", err)
+	}
+	defer out.Close()
+
+	// In json mode the output carries only candles, so banners and summaries are dropped
+	var info io.Writer = out
+	if *outputFormat != "text" {
+		info = io.Discard
+	}
+
+	// API key is optional - some endpoints are public and don't require authentication
+	if cfg.APIKey == "" {
+		slog.Info("no API key provided, connecting to a public endpoint")
+	}
+
+	filter := parseSymbols(*symbols)
+
+	fmt.Fprintln(info, "🚀 Hyperliquid Go gRPC Client - Fills to OHLCV Candles")
+	fmt.Fprintln(info, "======================================================")
+	fmt.Fprintf(info, "📡 Endpoints: %s\n", strings.Join(cfg.Endpoints(), ", "))
+	fmt.Fprintf(info, "🔒 Transport: %s\n", cfg.TransportDescription())
+	fmt.Fprintf(info, "⏱️  Start: %s\n", cfg.StartDescription())
+	fmt.Fprintf(info, "🎬 Mode: %s\n", cfg.StreamDescription())
+	fmt.Fprintf(info, "🕯️  Interval: %s\n", intervalLabel(*interval))
+	if filter != nil {
+		fmt.Fprintf(info, "🔎 Symbols: %s\n", *symbols)
+	}
+	fmt.Fprintf(info, "⚙️  Config precedence: %s\n\n", config.Precedence)
+
+	slog.Info("connecting to gRPC server", "endpoints", cfg.Endpoints())
+	// Keepalive pings detect connections silently dropped by intermediaries
+	connectOpts := append(cfg.ConnectOptions(),
+		client.WithKeepalive(*keepaliveTime, *keepaliveTimeout),
+		client.WithMaxMessageSize(int(maxMsgSize)),
+	)
+
+	// Endpoints are tried in priority order, and each reconnect fails over to the next one
+	failover := client.NewFailover(cfg.Endpoints(), func(endpoint string) (*grpc.ClientConn, error) {
+		return client.Connect(endpoint, cfg.APIKey, connectOpts...)
+	})
+	ctx := context.Background()
+
+	// The client connects lazily, so wait until an endpoint is actually ready
+	conn, err := failover.Connect(ctx, cfg.ConnectTimeout)
+	if err != nil {
+		logging.Exit(client.ExitConnectionFailure, "failed to connect", "err", err)
+	}
+	defer conn.Close()
+
+	slog.Info("connected", "endpoint", failover.Active())
+
+	// Without another endpoint to fail over to, an open breaker ends the run
+	breaker := client.WithCircuitBreaker(client.Breaker{
+		Threshold:    *breakerThreshold,
+		Window:       *breakerWindow,
+		Cooldown:     *breakerCooldown,
+		ExitWhenOpen: len(cfg.Endpoints()) == 1,
+	})
+
+	// First Ctrl+C (or SIGTERM) drains the stream, a second one or an overrun
+	// of -shutdown-timeout forces an immediate exit
+	ctx, stop := shutdown.Listen(ctx, *shutdownTimeout)
+	defer stop()
+
+	// Create request - 0 means latest, otherwise replay from the start time (see -from)
+	request := &pb.Timestamp{Timestamp: cfg.RequestTimestamp()}
+
+	fmt.Fprintln(info, "📥 Building candles from block fills...")
+	fmt.Fprint(info, "Press Ctrl+C to stop streaming (twice to force quit)\n\n")
+
+	write := func(c *candle.Candle, complete bool) {
+		if err := writeCandle(out, *outputFormat, c, complete, *precision); err != nil {
+			slog.Error("failed to write candle", "symbol", c.Symbol, "err", err)
+		}
+	}
+
+	candles := candle.NewAggregator(*interval)
+	blockFillsCount, candleCount, lateFills := 0, 0, 0
+	err = client.StreamWithReconnect(ctx, conn, failover.Redial, pb.HyperLiquidL1GatewayClient.StreamBlockFills, request, func(response *pb.BlockFills) {
+		blockFillsCount++
+
+		blockFills, err := model.DecodeBlockFills(response.Data)
+		if err != nil {
+			slog.Error("failed to decode block fills", "block", blockFillsCount, "err", err)
+			return
+		}
+		// Candles roll over on the fills' time, not on the local clock
+		if blockFills.Time == 0 {
+			slog.Warn("skipping block fills without a time", "block", blockFillsCount, "height", blockFills.Height)
+			return
+		}
+		fillTime := time.UnixMilli(blockFills.Time).UTC()
+
+		// Quiet symbols complete once the feed moves past their interval
+		for _, c := range candles.Advance(fillTime) {
+			write(c, true)
+			candleCount++
+		}
+		for _, fill := range blockFills.Fills {
+			if !filter.Matches(fill.Symbol) {
+				continue
+			}
+			completed, err := candles.Add(fill.Symbol, fill.Price, fill.Size, fillTime)
+			if errors.Is(err, candle.ErrLate) {
+				// Replayed after a reconnect, and its candle was already written
+				lateFills++
+				continue
+			}
+			if err != nil {
+				slog.Warn("skipping fill", "height", blockFills.Height, "err", err)
+				continue
+			}
+			if completed != nil {
+				write(completed, true)
+				candleCount++
+			}
+		}
+	}, client.WithIdleTimeout(*idleTimeout), breaker)
+	exitCode := client.ExitOK
+	if err != nil {
+		message, auth := client.ClassifyError(err)
+		if auth {
+			logging.Exit(client.ExitAuthFailure, message, "err", err)
+		}
+		slog.Error("stream ended with an error", "err", err)
+		exitCode = client.ExitStreamError
+	}
+
+	// The candles still open are written too, marked as incomplete
+	open := candles.Flush()
+	for _, c := range open {
+		write(c, false)
+	}
+
+	fmt.Fprintf(info, "\n📊 Total block fills received: %d\n", blockFillsCount)
+	fmt.Fprintf(info, "🕯️  Completed candles: %d (%d incomplete at exit)\n", candleCount, len(open))
+	if lateFills > 0 {
+		fmt.Fprintf(info, "⏪ Late fills skipped: %d\n", lateFills)
+	}
+
+	// The summary is printed and all output written, so skipping deferred
+	// cleanup is safe
+	if exitCode != client.ExitOK {
+		out.Close()
+		os.Exit(exitCode)
+	}
+}
+
+// writeCandle writes c as a text line or a JSON object
+func writeCandle(w io.Writer, format string, c *candle.Candle, complete bool, precision int) error {
+	if format == "json" {
+		data, err := json.Marshal(candleJSON{
+			Symbol:   c.Symbol,
+			Start:    c.Start.UnixMilli(),
+			Interval: intervalLabel(c.Interval),
+			Open:     decimal.Format(c.Open, decimal.Auto),
+			High:     decimal.Format(c.High, decimal.Auto),
+			Low:      decimal.Format(c.Low, decimal.Auto),
+			Close:    decimal.Format(c.Close, decimal.Auto),
+			Volume:   decimal.Format(c.Volume, decimal.Auto),
+			Fills:    c.Fills,
+			Complete: complete,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	suffix := ""
+	if !complete {
+		suffix = " (incomplete)"
+	}
+	_, err := fmt.Fprintf(w, "🕯️  %s %s %s  O %s  H %s  L %s  C %s  V %s  (%d fills)%s\n",
+		c.Start.Format("2006-01-02 15:04:05"), c.Symbol, intervalLabel(c.Interval),
+		decimal.Format(c.Open, precision), decimal.Format(c.High, precision), decimal.Format(c.Low, precision),
+		decimal.Format(c.Close, precision), decimal.Format(c.Volume, precision), c.Fills, suffix)
+	return err
+}
+
+// intervalLabel formats d without zero trailing units, e.g. 1m instead of
+// time.Duration's 1m0s
+func intervalLabel(d time.Duration) string {
+	label := d.String()
+	if strings.HasSuffix(label, "m0s") {
+		label = strings.TrimSuffix(label, "0s")
+	}
+	if strings.HasSuffix(label, "h0m") {
+		label = strings.TrimSuffix(label, "0m")
+	}
+	return label
+}
+
+// symbolSet is a set of upper-cased symbols. A nil set matches every symbol.
+type symbolSet map[string]bool
+
+// parseSymbols parses a comma-separated symbol list
+func parseSymbols(list string) symbolSet {
+	var set symbolSet
+	for _, symbol := range strings.Split(list, ",") {
+		symbol = strings.TrimSpace(symbol)
+		if symbol == "" {
+			continue
+		}
+		if set == nil {
+			set = make(symbolSet)
+		}
+		set[strings.ToUpper(symbol)] = true
+	}
+	return set
+}
+
+// Matches reports whether symbol is in the set
+func (s symbolSet) Matches(symbol string) bool {
+	return s == nil || s[strings.ToUpper(symbol)]
+}
@@ -1,18 +1,47 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"math"
+	"math/big"
 	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
 
 	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
-	"github.com/joho/godotenv"
+	"github.com/dwellir/grpc-code-examples/go/internal/buildinfo"
+	"github.com/dwellir/grpc-code-examples/go/internal/client"
+	"github.com/dwellir/grpc-code-examples/go/internal/color"
+	"github.com/dwellir/grpc-code-examples/go/internal/config"
+	"github.com/dwellir/grpc-code-examples/go/internal/decimal"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+	"github.com/dwellir/grpc-code-examples/go/internal/orderbook"
+	"github.com/dwellir/grpc-code-examples/go/internal/output"
+	"github.com/dwellir/grpc-code-examples/go/internal/shutdown"
+	"github.com/dwellir/grpc-code-examples/go/internal/util"
+)
+
+// Bounds for the HTTP/2 tuning flags: gRPC ignores windows smaller than the
+// HTTP/2 default of 64KB, and buffers beyond 1GB only waste memory
+const (
+	minWindowSize = 64 << 10
+	maxBufferSize = 1 << 30
 )
 
 // OrderBookSnapshot represents the structure of an orderbook snapshot
@@ -22,149 +51,639 @@ type OrderBookSnapshot struct {
 }
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not found")
+	cfg := config.Register(flag.CommandLine)
+	compress := flag.Bool("compress", false, "request gzip compression for the snapshot call")
+	timeout := flag.Duration("timeout", 60*time.Second, "deadline for each snapshot attempt")
+	maxRetries := flag.Int("max-retries", 3, "retries for transient failures (UNAVAILABLE, RESOURCE_EXHAUSTED, ABORTED, INTERNAL, UNKNOWN, and CANCELLED or DEADLINE_EXCEEDED sent by the server)")
+	outFile := flag.String("out-file", "", "write the human-readable output to this file instead of stdout")
+	outPath := flag.String("out", "", "write the full snapshot JSON to this file")
+	pretty := flag.Bool("pretty", false, "indent the JSON written with -out")
+	levelsCSV := flag.String("levels-csv", "", "write every bid and ask level to this CSV file")
+	precision := flag.Int("precision", decimal.Auto, "decimals shown for prices and sizes, -1 shows as many as needed (up to 8)")
+	streamParse := flag.Bool("stream-parse", false, "summarise the snapshot while reading it token by token instead of decoding all levels, for snapshots too large to hold decoded; not with -levels-csv or -poll")
+	poll := flag.Duration("poll", 0, "after the first snapshot, fetch one every interval and print only the levels that changed, 0 fetches once")
+	pollCount := flag.Int("poll-count", 0, "with -poll, stop after this many polls, 0 polls until stopped")
+	// Large message support works with dedicated endpoints that don't have the 64MB limit
+	maxMsgSize := config.ByteSize(1 << 30) // 1GB
+	flag.Var(&maxMsgSize, "max-msg-size", "maximum snapshot size to receive, e.g. 256MB or 2GB")
+	// The HTTP/2 windows let the whole snapshot arrive without waiting for
+	// flow-control updates; smaller values save memory on constrained hosts
+	initialWindow := config.ByteSize(1 << 30)
+	flag.Var(&initialWindow, "initial-window", "HTTP/2 per-stream flow-control window, from 64KB up to but not including 2GB")
+	connWindow := config.ByteSize(1 << 30)
+	flag.Var(&connWindow, "conn-window", "HTTP/2 per-connection flow-control window, from 64KB up to but not including 2GB")
+	readBuffer := config.ByteSize(64 << 20)
+	flag.Var(&readBuffer, "read-buffer", "size of the connection's read buffer, up to 1GB")
+	writeBuffer := config.ByteSize(64 << 20)
+	flag.Var(&writeBuffer, "write-buffer", "size of the connection's write buffer, up to 1GB")
+	watchConn := flag.Bool("watch-conn", false, "log every connection state transition (IDLE, CONNECTING, READY, TRANSIENT_FAILURE, ...)")
+	showVersion := flag.Bool("version", false, "print the version, commit and build date and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(buildinfo.String())
+		return
 	}
 
-	endpoint := os.Getenv("HYPERLIQUID_ENDPOINT")
-	apiKey := os.Getenv("API_KEY")
+	if err := cfg.LoadFile(); err != nil {
+		logging.Fatal("invalid -config file", "err", err)
+	}
+	if err := cfg.SetupLogging(); err != nil {
+		logging.Fatal("invalid logging configuration", "err", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	if warning := cfg.SecurityWarning(); warning != "" {
+		slog.Warn(warning)
+	}
+	if *precision < decimal.Auto {
+		logging.Fatal("-precision must be -1 or more", "precision", *precision)
+	}
+	for _, window := range []struct {
+		name string
+		size config.ByteSize
+	}{{"-initial-window", initialWindow}, {"-conn-window", connWindow}} {
+		// gRPC ignores windows below the HTTP/2 default, and the window is an int32
+		if window.size < minWindowSize || window.size > math.MaxInt32 {
+			logging.Fatal(window.name+" must be at least 64KB and less than 2GB", "size", window.size.String())
+		}
+	}
+	for _, buffer := range []struct {
+		name string
+		size config.ByteSize
+	}{{"-read-buffer", readBuffer}, {"-write-buffer", writeBuffer}} {
+		if buffer.size > maxBufferSize {
+			logging.Fatal(buffer.name+" must not exceed 1GB", "size", buffer.size.String())
+		}
+	}
+
+	// A fixed snapshot time would return the same book on every poll
+	if *poll > 0 && cfg.StartMode() != config.FromLatest {
+		logging.Fatal("-poll needs the latest snapshot, use -from latest", "from", cfg.StartDescription())
+	}
+	// Both need every level, which -stream-parse doesn't keep
+	if *streamParse && (*levelsCSV != "" || *poll > 0) {
+		logging.Fatal("-stream-parse cannot be combined with -levels-csv or -poll")
+	}
+	if *pollCount < 0 {
+		logging.Fatal("-poll-count must not be negative", "poll-count", *pollCount)
+	}
+	if *pollCount > 0 && *poll == 0 {
+		logging.Fatal("-poll-count needs -poll")
+	}
 
-	if endpoint == "" {
-		log.Fatal("Error: HYPERLIQUID_ENDPOINT environment variable is required.\n" +
-			"Please create a .env file from .env.example and set your endpoint.")
+	// -dry-run stops here, before any file is written or stream opened
+	if cfg.DryRun {
+		color.Setup(os.Stdout, false)
+		os.Exit(cfg.RunDryRun(os.Stdout))
+	}
+
+	out, err := output.Open(*outFile)
+	if err != nil {
+		logging.Fatal("failed to open output file", "path", *outFile, "err", err)
 	}
+	defer out.Close()
 
 	// API key is optional - some endpoints are public and don't require authentication
-	if apiKey == "" {
-		fmt.Println("ℹ️  No API key provided - connecting to public endpoint")
+	if cfg.APIKey == "" {
+		slog.Info("no API key provided, connecting to a public endpoint")
 	}
 
-	fmt.Println("🚀 Hyperliquid Go gRPC Client - Get OrderBook Snapshot")
-	fmt.Println("=======================================================")
-	fmt.Printf("📡 Endpoint: %s\n\n", endpoint)
+	fmt.Fprintln(out, "🚀 Hyperliquid Go gRPC Client - Get OrderBook Snapshot")
+	fmt.Fprintln(out, "=======================================================")
+	fmt.Fprintf(out, "📡 Endpoints: %s\n", strings.Join(cfg.Endpoints(), ", "))
+	fmt.Fprintf(out, "🔒 Transport: %s\n", cfg.TransportDescription())
+	fmt.Fprintf(out, "⏱️  Snapshot time: %s\n", cfg.StartDescription())
+	fmt.Fprintf(out, "⚙️  Config precedence: %s\n", config.Precedence)
+	fmt.Fprintf(out, "🪟 HTTP/2: stream window %s, connection window %s, read buffer %s, write buffer %s\n\n",
+		initialWindow.String(), connWindow.String(), readBuffer.String(), writeBuffer.String())
 
-	// Create TLS credentials
-	creds := credentials.NewTLS(nil)
+	maxSize := int(maxMsgSize)
 
-	// Set up connection options with large message support
-	// This works with dedicated endpoints that don't have the 64MB limit
-	maxSize := 1024 * 1024 * 1024 // 1GB
-	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(creds),
-		grpc.WithDefaultCallOptions(
-			grpc.MaxCallRecvMsgSize(maxSize),
-			grpc.MaxCallSendMsgSize(maxSize),
-		),
+	// Records how many bytes the response took on the wire
+	wireSizes := &payloadSizes{}
+
+	slog.Info("connecting to gRPC server", "endpoints", cfg.Endpoints())
+	connectOpts := append(cfg.ConnectOptions(),
+		client.WithMaxMessageSize(maxSize),
 		// Increase HTTP/2 settings for large messages
-		grpc.WithInitialWindowSize(1 << 30),          // 1GB
-		grpc.WithInitialConnWindowSize(1 << 30),      // 1GB
-		grpc.WithReadBufferSize(1024 * 1024 * 64),    // 64MB
-		grpc.WithWriteBufferSize(1024 * 1024 * 64),   // 64MB
+		client.WithDialOptions(
+			grpc.WithInitialWindowSize(int32(initialWindow)),
+			grpc.WithInitialConnWindowSize(int32(connWindow)),
+			grpc.WithReadBufferSize(int(readBuffer)),
+			grpc.WithWriteBufferSize(int(writeBuffer)),
+			grpc.WithStatsHandler(wireSizes),
+		),
+	)
+	if *watchConn {
+		// Every connection, including ones made on reconnect, is watched until closed
+		watchCtx, stopWatching := context.WithCancel(context.Background())
+		defer stopWatching()
+		connectOpts = append(connectOpts, client.WithStateLogging(watchCtx))
 	}
+	// The first endpoint in priority order that becomes ready is used
+	failover := client.NewFailover(cfg.Endpoints(), func(endpoint string) (*grpc.ClientConn, error) {
+		return client.Connect(endpoint, cfg.APIKey, connectOpts...)
+	})
+	ctx := context.Background()
 
-	fmt.Println("🔌 Connecting to gRPC server...")
-	conn, err := grpc.NewClient(endpoint, opts...)
+	// The client connects lazily, so wait until an endpoint is actually ready
+	conn, err := failover.Connect(ctx, cfg.ConnectTimeout)
 	if err != nil {
-		log.Fatalf("Failed to create client: %v", err)
+		logging.Exit(client.ExitConnectionFailure, "failed to connect", "err", err)
 	}
 	defer conn.Close()
 
-	client := pb.NewHyperLiquidL1GatewayClient(conn)
-	fmt.Println("✅ Connected successfully!\n")
+	gateway := client.NewGatewayClient(conn)
+	slog.Info("connected", "endpoint", failover.Active())
 
-	// Create context
-	ctx := context.Background()
+	// Create request - 0 means current snapshot, otherwise the snapshot at the given time
+	request := &pb.Timestamp{Timestamp: cfg.RequestTimestamp()}
 
-	// Add metadata (API key) only if provided
-	if apiKey != "" {
-		ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", apiKey)
-	}
-
-	// Create request - 0 means current snapshot
-	request := &pb.Timestamp{Timestamp: 0}
-
-	fmt.Println("📥 Requesting OrderBook snapshot...")
-	fmt.Println("   (This may take a moment for large orderbooks...)\n")
+	fmt.Fprintln(out, "📥 Requesting OrderBook snapshot...")
+	fmt.Fprint(out, "   (This may take a moment for large orderbooks...)\n\n")
 
-	// Make the gRPC call
-	response, err := client.GetOrderBookSnapshot(
-		ctx,
-		request,
+	// Capture response headers to see which encoding the server used
+	var header metadata.MD
+	callOpts := []grpc.CallOption{
 		grpc.MaxCallRecvMsgSize(maxSize),
-	)
+		grpc.Header(&header),
+	}
+	if *compress {
+		// Importing the gzip package also advertises gzip for responses
+		callOpts = append(callOpts, grpc.UseCompressor(gzip.Name))
+		fmt.Fprintln(out, "🗜️  Requesting gzip compression")
+	}
+
+	// Make the gRPC call, retrying transient failures
+	response, err := getSnapshotWithRetry(ctx, gateway, request, *timeout, *maxRetries, callOpts...)
+	var failed client.StreamError
+	if errors.As(err, &failed) && failed.Code == codes.DeadlineExceeded {
+		if failed.Local {
+			logging.Exit(client.ExitStreamError, "timed out waiting for the orderbook snapshot; large snapshots can take a while, retry with a longer -timeout",
+				"timeout", *timeout)
+		}
+		// A longer -timeout can't help when the server gave up first
+		logging.Exit(client.ExitStreamError, "the server gave up on the orderbook snapshot before -timeout; try again later or with -max-retries",
+			"err", failed.Err)
+	}
+	if message, auth := client.ClassifyError(err); auth {
+		logging.Exit(client.ExitAuthFailure, message, "err", err)
+	}
 	if err != nil {
-		log.Fatalf("Failed to get orderbook snapshot: %v\n\n"+
-			"Note: Some endpoints have message size limits (typically 64MB).\n"+
-			"This method works with dedicated endpoints that support larger messages.\n", err)
+		// Some endpoints have message size limits (typically 64MB)
+		logging.Exit(client.ExitStreamError, "failed to get orderbook snapshot; this method needs a dedicated endpoint that supports large messages",
+			"err", err)
 	}
 
-	fmt.Println("✅ Received OrderBook snapshot!\n")
+	fmt.Fprint(out, "✅ Received OrderBook snapshot!\n\n")
+
+	if *compress {
+		reportCompression(out, header, wireSizes, len(response.Data))
+	}
 
 	// Process the snapshot
-	processOrderBookSnapshot(response.Data)
+	var ladders *orderbook.Ladders
+	if *streamParse {
+		scanOrderBookSnapshot(out, response.Data, *precision)
+	} else {
+		ladders = processOrderBookSnapshot(out, response.Data, *precision)
+	}
+
+	if *outPath != "" {
+		written, err := writeSnapshot(*outPath, response.Data, *pretty)
+		if err != nil {
+			logging.Fatal("failed to write snapshot", "path", *outPath, "err", err)
+		}
+		fmt.Fprintf(out, "💾 Snapshot written to %s (%d bytes)\n", *outPath, written)
+	}
+
+	if *levelsCSV != "" {
+		if ladders == nil {
+			logging.Fatal("levels are not [bids, asks] ladders, nothing to write", "path", *levelsCSV)
+		}
+		rows, err := writeLevelsCSV(*levelsCSV, ladders)
+		if err != nil {
+			logging.Fatal("failed to write levels CSV", "path", *levelsCSV, "err", err)
+		}
+		fmt.Fprintf(out, "💾 Levels written to %s (%d rows)\n", *levelsCSV, rows)
+	}
+
+	if *poll > 0 {
+		// Ctrl+C stops polling after the current snapshot, a second one quits
+		ctx, stop := shutdown.Listen(ctx, 0)
+		defer stop()
+
+		if *pollCount > 0 {
+			fmt.Fprintf(out, "\n🔄 Polling every %v, %d times, printing changed levels (Ctrl+C to stop)\n", *poll, *pollCount)
+		} else {
+			fmt.Fprintf(out, "\n🔄 Polling every %v, printing changed levels (Ctrl+C to stop)\n", *poll)
+		}
+		// Every poll is a call on the connection made above, never a new dial
+		polled := pollSnapshots(ctx, out, ladders, *poll, *pollCount, *precision, func(ctx context.Context) (*pb.OrderBookSnapshot, error) {
+			return getSnapshotWithRetry(ctx, gateway, request, *timeout, *maxRetries, callOpts...)
+		})
+		fmt.Fprintf(out, "\n📊 Snapshots polled: %d\n", polled)
+	}
 }
 
-func processOrderBookSnapshot(data []byte) {
-	// Parse as generic map first to see what keys are available
-	var rawData map[string]interface{}
-	if err := json.Unmarshal(data, &rawData); err != nil {
-		log.Printf("❌ Failed to parse JSON: %v", err)
-		if len(data) > 200 {
-			log.Printf("Raw data (first 200 bytes): %s", data[:200])
+// maxPrintedChanges caps the changed levels printed per poll
+const maxPrintedChanges = 50
+
+// pollSnapshots fetches a snapshot every interval until ctx is cancelled, or
+// until count polls were made when count is positive, and prints the levels
+// that changed against the previous one. Failed polls count too, so a
+// failing endpoint doesn't extend the run. prev is the book of the first
+// snapshot, nil if its levels couldn't be parsed. It returns the number of
+// snapshots fetched. Prices and sizes are shown with precision decimals (see
+// decimal.Format).
+func pollSnapshots(ctx context.Context, w io.Writer, prev *orderbook.Ladders, interval time.Duration, count, precision int, fetch func(context.Context) (*pb.OrderBookSnapshot, error)) int {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	polled := 0
+	for polls := 0; count == 0 || polls < count; polls++ {
+		select {
+		case <-ctx.Done():
+			return polled
+		case <-ticker.C:
+		}
+
+		response, err := fetch(ctx)
+		if ctx.Err() != nil {
+			return polled
+		}
+		if message, auth := client.ClassifyError(err); auth {
+			logging.Exit(client.ExitAuthFailure, message, "err", err)
+		}
+		if err != nil {
+			slog.Error("failed to poll orderbook snapshot, retrying at the next interval", "err", err)
+			continue
+		}
+		polled++
+
+		next, err := orderbook.ParseSnapshot(response.Data)
+		if err != nil {
+			slog.Error("unexpected snapshot levels, skipping it", "err", err)
+			continue
+		}
+		// Without a parsed previous book this snapshot becomes the baseline
+		if prev == nil {
+			fmt.Fprintf(w, "\n📸 Baseline: %d bids, %d asks\n", len(next.Bids), len(next.Asks))
 		} else {
-			log.Printf("Raw data: %s", data)
+			printLevelChanges(w, orderbook.Diff(prev, next), precision)
+		}
+		prev = next
+	}
+	return polled
+}
+
+// printLevelChanges prints one line per changed level: + added, - removed
+// and ~ resized
+func printLevelChanges(w io.Writer, changes []orderbook.Change, precision int) {
+	if len(changes) == 0 {
+		fmt.Fprintf(w, "\n🔄 %s: no changes\n", time.Now().UTC().Format("15:04:05"))
+		return
+	}
+
+	fmt.Fprintf(w, "\n🔄 %s: %d changed levels\n", time.Now().UTC().Format("15:04:05"), len(changes))
+	for _, c := range changes[:min(maxPrintedChanges, len(changes))] {
+		icon := "📗"
+		if c.Side == "ask" {
+			icon = "📕"
+		}
+		switch c.Kind {
+		case orderbook.Added:
+			fmt.Fprintf(w, "  %s %s + %s (size %s)\n", icon, c.Side, decimal.Format(c.Price, precision), decimal.Format(c.NewSize, precision))
+		case orderbook.Removed:
+			fmt.Fprintf(w, "  %s %s - %s (was %s)\n", icon, c.Side, decimal.Format(c.Price, precision), decimal.Format(c.OldSize, precision))
+		case orderbook.Resized:
+			fmt.Fprintf(w, "  %s %s ~ %s %s → %s\n", icon, c.Side, decimal.Format(c.Price, precision), decimal.Format(c.OldSize, precision), decimal.Format(c.NewSize, precision))
+		}
+	}
+	if len(changes) > maxPrintedChanges {
+		fmt.Fprintf(w, "  ... and %d more\n", len(changes)-maxPrintedChanges)
+	}
+}
+
+// levelsCSVHeader lists the columns written by writeLevelsCSV
+var levelsCSVHeader = []string{"side", "level", "price", "size", "orders"}
+
+// writeLevelsCSV writes one row per level, bids first, to path and returns
+// the number of rows written. Level numbers start at 1 on each side and
+// orders is left empty when the snapshot doesn't carry a count.
+func writeLevelsCSV(path string, ladders *orderbook.Ladders) (rows int, err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	w := csv.NewWriter(file)
+	if err := w.Write(levelsCSVHeader); err != nil {
+		return 0, err
+	}
+	for _, side := range []struct {
+		name   string
+		levels []orderbook.Level
+	}{{"bid", ladders.Bids}, {"ask", ladders.Asks}} {
+		for i, level := range side.levels {
+			orders := ""
+			if level.Orders > 0 {
+				orders = strconv.Itoa(level.Orders)
+			}
+			row := []string{side.name, strconv.Itoa(i + 1), decimal.Format(level.Price, decimal.Auto), decimal.Format(level.Size, decimal.Auto), orders}
+			if err := w.Write(row); err != nil {
+				return rows, err
+			}
+			rows++
 		}
+	}
+
+	w.Flush()
+	return rows, w.Error()
+}
+
+// writeSnapshot writes the raw snapshot, or an indented copy when pretty is
+// set, to path and returns the number of bytes written
+func writeSnapshot(path string, data []byte, pretty bool) (int, error) {
+	if pretty {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, data, "", "  "); err != nil {
+			return 0, fmt.Errorf("indent JSON: %w", err)
+		}
+		buf.WriteByte('\n')
+		data = buf.Bytes()
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// getSnapshotWithRetry requests the snapshot, bounding each attempt by
+// timeout so a stalled server cannot hang the process. Attempts failing with
+// a retryable status, including a cancellation or deadline coming from the
+// server, are retried up to maxRetries times with exponential backoff; any
+// other error, such as the local timeout expiring, is returned immediately.
+// Failed calls are returned as a client.StreamError (see
+// client.ClassifyCall).
+func getSnapshotWithRetry(ctx context.Context, gateway pb.HyperLiquidL1GatewayClient, request *pb.Timestamp, timeout time.Duration, maxRetries int, opts ...grpc.CallOption) (*pb.OrderBookSnapshot, error) {
+	backoff := time.Second
+
+	for attempt := 1; ; attempt++ {
+		slog.Info("requesting snapshot", "attempt", attempt, "of", maxRetries+1)
+
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		response, err := gateway.GetOrderBookSnapshot(callCtx, request, opts...)
+		// Classified before cancel, which would make every failure look local
+		classified := client.ClassifyCall(callCtx, err)
+		cancel()
+		if err == nil {
+			slog.Info("snapshot received", "attempt", attempt)
+			return response, nil
+		}
+
+		if !classified.Retryable {
+			slog.Error("snapshot attempt failed, not retryable", "attempt", attempt, "code", classified.Code, "local", classified.Local)
+			return nil, classified
+		}
+		if attempt > maxRetries {
+			slog.Error("giving up on snapshot", "attempts", attempt, "code", classified.Code)
+			return nil, classified
+		}
+
+		slog.Warn("snapshot attempt failed, retrying", "attempt", attempt, "code", classified.Code, "message", classified.Message, "in", backoff)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+	}
+}
+
+// reportCompression prints the response encoding and compares the size on the
+// wire with the decoded payload size.
+func reportCompression(w io.Writer, header metadata.MD, sizes *payloadSizes, decoded int) {
+	encoding := "identity"
+	if values := header.Get("grpc-encoding"); len(values) > 0 {
+		encoding = values[0]
+	}
+	fmt.Fprintf(w, "🗜️  Response encoding: %s\n", encoding)
+
+	compressed := sizes.compressed.Load()
+	if compressed == 0 {
+		fmt.Fprint(w, "📦 Wire size: not measured\n\n")
 		return
 	}
+	fmt.Fprintf(w, "📦 Wire size: %d bytes (%.2f MB), decoded: %d bytes (%.2f MB), ratio: %.1f%%\n\n",
+		compressed, float64(compressed)/(1024*1024), decoded, float64(decoded)/(1024*1024),
+		float64(compressed)/float64(max(decoded, 1))*100)
+}
 
-	fmt.Println("📊 ORDERBOOK SNAPSHOT")
-	fmt.Println("=====================")
+// payloadSizes is a gRPC stats handler recording the compressed size of the
+// last received message
+type payloadSizes struct {
+	compressed atomic.Int64
+}
+
+func (p *payloadSizes) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (p *payloadSizes) HandleRPC(_ context.Context, s stats.RPCStats) {
+	if in, ok := s.(*stats.InPayload); ok {
+		p.compressed.Store(int64(in.CompressedLength))
+	}
+}
+
+func (p *payloadSizes) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (p *payloadSizes) HandleConn(context.Context, stats.ConnStats) {}
+
+// processOrderBookSnapshot prints the snapshot, with precision decimals for
+// prices and sizes, and returns its parsed ladders, or nil when the levels
+// aren't [bids, asks] ladders
+func processOrderBookSnapshot(w io.Writer, data []byte, precision int) *orderbook.Ladders {
+	// Decode only the top level so the levels can be parsed into typed ladders
+	var rawData map[string]json.RawMessage
+	if err := json.Unmarshal(data, &rawData); err != nil {
+		slog.Error("failed to parse snapshot", "err", err, "raw", string(util.ClampBytes(data, 200)))
+		return nil
+	}
+
+	fmt.Fprintln(w, "📊 ORDERBOOK SNAPSHOT")
+	fmt.Fprintln(w, "=====================")
 
 	// Display available keys
-	fmt.Print("📋 Available data: ")
+	fmt.Fprint(w, "📋 Available data: ")
 	keys := make([]string, 0, len(rawData))
 	for k := range rawData {
 		keys = append(keys, k)
 	}
-	fmt.Printf("%v\n\n", keys)
+	fmt.Fprintf(w, "%v\n\n", keys)
 
 	// Display timestamp if available
 	if timeVal, ok := rawData["time"]; ok {
-		fmt.Printf("⏰ Timestamp: %v\n", timeVal)
+		fmt.Fprintf(w, "⏰ Timestamp: %s\n", timeVal)
 	}
 
-	// Display levels info if available
+	// Display bids and asks, or the raw levels if they aren't [bids, asks] ladders
+	var ladders *orderbook.Ladders
 	if levelsVal, ok := rawData["levels"]; ok {
-		if levels, ok := levelsVal.([]interface{}); ok {
-			fmt.Printf("📈 Total levels: %d\n", len(levels))
-
-			if len(levels) > 0 {
-				fmt.Println("\nSample levels (first 3):")
-				for i := 0; i < min(3, len(levels)); i++ {
-					levelJSON, _ := json.Marshal(levels[i])
-					levelStr := string(levelJSON)
-					if len(levelStr) > 100 {
-						levelStr = levelStr[:100] + "..."
-					}
-					fmt.Printf("  • Level %d: %s\n", i+1, levelStr)
-				}
-
-				if len(levels) > 3 {
-					fmt.Printf("  ... and %d more levels\n", len(levels)-3)
-				}
-			}
+		var err error
+		if ladders, err = orderbook.ParseLevels(levelsVal); err == nil {
+			printLadders(w, ladders, precision)
+		} else {
+			slog.Warn("unexpected levels shape, showing raw levels", "err", err)
+			printRawLevels(w, levelsVal)
 		}
 	}
 
 	// Display data size info
 	dataSizeMB := float64(len(data)) / (1024 * 1024)
-	fmt.Printf("\n📦 Response size: %d bytes (%.2f MB)\n", len(data), dataSizeMB)
+	fmt.Fprintf(w, "\n📦 Response size: %d bytes (%.2f MB)\n", len(data), dataSizeMB)
+	return ladders
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
+// scanSample is the number of levels per side scanOrderBookSnapshot shows
+const scanSample = 3
+
+// scanOrderBookSnapshot prints the same summary as processOrderBookSnapshot,
+// with precision decimals for prices and sizes, from a token-by-token scan
+// that never holds more than one level decoded
+func scanOrderBookSnapshot(w io.Writer, data []byte, precision int) {
+	summary, err := orderbook.Scan(bytes.NewReader(data), scanSample)
+	if err != nil {
+		slog.Error("failed to scan snapshot", "err", err, "raw", string(util.ClampBytes(data, 200)))
+		return
+	}
+
+	fmt.Fprintln(w, "📊 ORDERBOOK SNAPSHOT (stream-parsed)")
+	fmt.Fprintln(w, "=====================================")
+	fmt.Fprintf(w, "📋 Available data: %v\n\n", summary.Keys)
+	if summary.Time != nil {
+		fmt.Fprintf(w, "⏰ Timestamp: %s\n", summary.Time)
+	}
+
+	if summary.HasLevels {
+		fmt.Fprintf(w, "📗 Bids: %d levels, total size %s\n", summary.Bids.Levels, decimal.Format(summary.Bids.TotalSize, precision))
+		fmt.Fprintf(w, "📕 Asks: %d levels, total size %s\n", summary.Asks.Levels, decimal.Format(summary.Asks.TotalSize, precision))
+
+		fmt.Fprintln(w, "\n🔝 Top of book:")
+		printScannedBest(w, "Best bid", summary.Bids, precision)
+		printScannedBest(w, "Best ask", summary.Asks, precision)
+		if spread, ok := summary.Spread(); ok {
+			fmt.Fprintf(w, "  • Spread: %s\n", decimal.Format(spread, precision))
+		}
+
+		fmt.Fprintf(w, "\nSample levels (first %d per side):\n", scanSample)
+		for _, level := range summary.Bids.Sample {
+			fmt.Fprintf(w, "  • bid %s x %s (%d orders)\n", decimal.Format(level.Price, precision), decimal.Format(level.Size, precision), level.Orders)
+		}
+		for _, level := range summary.Asks.Sample {
+			fmt.Fprintf(w, "  • ask %s x %s (%d orders)\n", decimal.Format(level.Price, precision), decimal.Format(level.Size, precision), level.Orders)
+		}
+	}
+
+	dataSizeMB := float64(len(data)) / (1024 * 1024)
+	fmt.Fprintf(w, "\n📦 Response size: %d bytes (%.2f MB)\n", len(data), dataSizeMB)
+}
+
+// printScannedBest prints the best level of a scanned side
+func printScannedBest(w io.Writer, label string, side orderbook.SideSummary, precision int) {
+	if side.Levels == 0 {
+		fmt.Fprintf(w, "  • %s: none\n", label)
+		return
+	}
+	fmt.Fprintf(w, "  • %s: %s (size %s, %d orders)\n", label, decimal.Format(side.Best.Price, precision), decimal.Format(side.Best.Size, precision), side.Best.Orders)
+}
+
+// printLadders prints the top of book and the depth on each side
+func printLadders(w io.Writer, ladders *orderbook.Ladders, precision int) {
+	fmt.Fprintf(w, "📗 Bids: %d levels, total size %s\n", len(ladders.Bids), decimal.Format(orderbook.TotalSize(ladders.Bids), precision))
+	fmt.Fprintf(w, "📕 Asks: %d levels, total size %s\n", len(ladders.Asks), decimal.Format(orderbook.TotalSize(ladders.Asks), precision))
+
+	fmt.Fprintln(w, "\n🔝 Top of book:")
+	if bid, ok := ladders.BestBid(); ok {
+		fmt.Fprintf(w, "  • Best bid: %s (size %s, %d orders)\n", decimal.Format(bid.Price, precision), decimal.Format(bid.Size, precision), bid.Orders)
+	} else {
+		fmt.Fprintln(w, "  • Best bid: none")
+	}
+	if ask, ok := ladders.BestAsk(); ok {
+		fmt.Fprintf(w, "  • Best ask: %s (size %s, %d orders)\n", decimal.Format(ask.Price, precision), decimal.Format(ask.Size, precision), ask.Orders)
+	} else {
+		fmt.Fprintln(w, "  • Best ask: none")
+	}
+	if spread, ok := ladders.Spread(); ok {
+		fmt.Fprintf(w, "  • Spread: %s\n", decimal.Format(spread, precision))
+	}
+	if mid, ok := ladders.Mid(); ok {
+		fmt.Fprintf(w, "  • Mid-price: %s\n", decimal.Format(mid, precision))
+	}
+	if microprice, ok := ladders.Microprice(); ok {
+		fmt.Fprintf(w, "  • Microprice: %s\n", decimal.Format(microprice, precision))
+	}
+	printImbalance(w, ladders)
+}
+
+// imbalanceDepths are the numbers of levels per side the size imbalance is
+// printed for
+var imbalanceDepths = []int{1, 5, 10, 20}
+
+// printImbalance prints the share of bid size in the top levels of the book
+// at each of imbalanceDepths, skipping depths past the deeper side
+func printImbalance(w io.Writer, ladders *orderbook.Ladders) {
+	deepest := max(len(ladders.Bids), len(ladders.Asks))
+	header := false
+	for i, depth := range imbalanceDepths {
+		if i > 0 && depth > deepest {
+			break
+		}
+		imbalance, ok := ladders.Imbalance(depth)
+		if !ok {
+			continue
+		}
+		if !header {
+			fmt.Fprintln(w, "\n⚖️  Size imbalance (bid share of the size, 50% is balanced):")
+			header = true
+		}
+		percent := new(big.Rat).Mul(imbalance, big.NewRat(100, 1))
+		fmt.Fprintf(w, "  • Top %d: %s%%\n", depth, decimal.Format(percent, 1))
+	}
+}
+
+// printRawLevels shows the first few levels as raw JSON
+func printRawLevels(w io.Writer, levelsVal json.RawMessage) {
+	var levels []interface{}
+	if err := json.Unmarshal(levelsVal, &levels); err != nil {
+		fmt.Fprintf(w, "📈 Levels: %s\n", util.TruncateString(string(levelsVal), 100))
+		return
+	}
+
+	fmt.Fprintf(w, "📈 Total levels: %d\n", len(levels))
+
+	if len(levels) > 0 {
+		fmt.Fprintln(w, "\nSample levels (first 3):")
+		for i := 0; i < min(3, len(levels)); i++ {
+			levelJSON, _ := json.Marshal(levels[i])
+			fmt.Fprintf(w, "  • Level %d: %s\n", i+1, util.TruncateString(string(levelJSON), 100))
+		}
+
+		if len(levels) > 3 {
+			fmt.Fprintf(w, "  ... and %d more levels\n", len(levels)-3)
+		}
 	}
-	return b
 }
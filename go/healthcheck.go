@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/buildinfo"
+	"github.com/dwellir/grpc-code-examples/go/internal/client"
+	"github.com/dwellir/grpc-code-examples/go/internal/color"
+	"github.com/dwellir/grpc-code-examples/go/internal/config"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+)
+
+func main() {
+	cfg := config.Register(flag.CommandLine)
+	probe := flag.Bool("probe", false, "also request an orderbook snapshot to check that the gateway answers calls (dedicated endpoints only)")
+	probeTimeout := flag.Duration("probe-timeout", 10*time.Second, "deadline for the -probe snapshot call")
+	showVersion := flag.Bool("version", false, "print the version, commit and build date and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(buildinfo.String())
+		return
+	}
+
+	if err := cfg.LoadFile(); err != nil {
+		logging.Fatal("invalid -config file", "err", err)
+	}
+	if err := cfg.SetupLogging(); err != nil {
+		logging.Fatal("invalid logging configuration", "err", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	if warning := cfg.SecurityWarning(); warning != "" {
+		slog.Warn(warning)
+	}
+
+	// -dry-run checks the API key even without -probe, and exits
+	if cfg.DryRun {
+		color.Setup(os.Stdout, false)
+		os.Exit(cfg.RunDryRun(os.Stdout))
+	}
+
+	fmt.Println("🩺 Hyperliquid Go gRPC Client - Health Check")
+	fmt.Println("=============================================")
+	fmt.Printf("📡 Endpoints: %s\n", strings.Join(cfg.Endpoints(), ", "))
+	fmt.Printf("🔒 Transport: %s\n\n", cfg.TransportDescription())
+
+	connectOpts := cfg.ConnectOptions()
+	failover := client.NewFailover(cfg.Endpoints(), func(endpoint string) (*grpc.ClientConn, error) {
+		return client.Connect(endpoint, cfg.APIKey, connectOpts...)
+	})
+	ctx := context.Background()
+
+	// Time until the first endpoint in priority order reports READY
+	start := time.Now()
+	conn, err := failover.Connect(ctx, cfg.ConnectTimeout)
+	if err != nil {
+		fmt.Println("❌ Unhealthy: no endpoint became ready")
+		logging.Exit(client.ExitConnectionFailure, "failed to connect", "err", err)
+	}
+	defer conn.Close()
+	connectLatency := time.Since(start)
+
+	fmt.Printf("🔌 Endpoint: %s\n", failover.Active())
+	fmt.Printf("📶 Connectivity state: %s\n", conn.GetState())
+	fmt.Printf("⏱️  Connect latency: %v\n", connectLatency.Round(time.Millisecond))
+
+	if *probe {
+		gateway := client.NewGatewayClient(conn)
+		callCtx, cancel := context.WithTimeout(ctx, *probeTimeout)
+		start := time.Now()
+		response, err := gateway.GetOrderBookSnapshot(callCtx, &pb.Timestamp{Timestamp: 0})
+		roundTrip := time.Since(start)
+		cancel()
+
+		if message, auth := client.ClassifyError(err); auth {
+			fmt.Println("❌ Unhealthy: API key rejected")
+			logging.Exit(client.ExitAuthFailure, message, "err", err)
+		}
+		if err != nil {
+			fmt.Printf("❌ Unhealthy: snapshot probe failed with %s after %v\n", status.Code(err), roundTrip.Round(time.Millisecond))
+			logging.Exit(client.ExitConnectionFailure, "snapshot probe failed", "err", err)
+		}
+		fmt.Printf("📥 Snapshot probe: %d bytes, round trip %v\n", len(response.Data), roundTrip.Round(time.Millisecond))
+	}
+
+	fmt.Println("✅ Healthy")
+}
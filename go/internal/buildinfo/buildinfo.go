@@ -0,0 +1,45 @@
+// Package buildinfo holds the version of the examples, injected at build
+// time with -ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/dwellir/grpc-code-examples/go/internal/buildinfo.Version=v1.2.0" stream_blocks.go
+package buildinfo
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Set with -ldflags -X; the defaults describe a build without them.
+var (
+	Version = "dev"
+	Commit  = "unknown"
+	Date    = "unknown"
+)
+
+// String returns the version, commit and build date in one line. Without an
+// injected commit, the VCS revision recorded by the Go toolchain is used
+// when there is one.
+func String() string {
+	commit := Commit
+	if commit == "unknown" {
+		if revision := vcsRevision(); revision != "" {
+			commit = revision
+		}
+	}
+	return fmt.Sprintf("%s (commit %s, built %s)", Version, commit, Date)
+}
+
+// vcsRevision returns the commit the binary was built from, as recorded by
+// go build inside a git checkout
+func vcsRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,22 @@
+package buildinfo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	defer func(version, commit, date string) {
+		Version, Commit, Date = version, commit, date
+	}(Version, Commit, Date)
+
+	Version, Commit, Date = "v1.2.0", "abc1234", "2025-10-14T07:22:47Z"
+	if got, want := String(), "v1.2.0 (commit abc1234, built 2025-10-14T07:22:47Z)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	Version, Commit, Date = "dev", "unknown", "unknown"
+	if got := String(); !strings.HasPrefix(got, "dev (commit ") {
+		t.Errorf("String() = %q, want the dev version", got)
+	}
+}
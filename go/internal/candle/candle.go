@@ -0,0 +1,148 @@
+// Package candle aggregates fills into OHLCV candles per symbol over fixed
+// time intervals.
+package candle
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/decimal"
+)
+
+// ErrLate is returned for a fill older than the symbol's open candle, e.g.
+// one replayed after a reconnect. Completed candles are never reopened.
+var ErrLate = errors.New("fill is older than the open candle")
+
+// Candle is the open, high, low and close price and the traded volume of one
+// symbol over [Start, Start+Interval). Prices and volume are exact
+// rationals.
+type Candle struct {
+	Symbol   string
+	Start    time.Time
+	Interval time.Duration
+	Open     *big.Rat
+	High     *big.Rat
+	Low      *big.Rat
+	Close    *big.Rat
+	Volume   *big.Rat
+	// Fills is the number of fills in the candle
+	Fills int
+}
+
+// End returns when the candle's interval ends.
+func (c *Candle) End() time.Time {
+	return c.Start.Add(c.Interval)
+}
+
+// Aggregator builds candles from fills arriving in time order. Intervals are
+// aligned to the Unix epoch, so 1m candles start on the minute and 1w (168h)
+// candles on Thursdays, like the epoch itself.
+type Aggregator struct {
+	interval time.Duration
+	open     map[string]*Candle
+}
+
+// NewAggregator returns an Aggregator for candles of the given interval,
+// which must be positive.
+func NewAggregator(interval time.Duration) *Aggregator {
+	return &Aggregator{interval: interval, open: make(map[string]*Candle)}
+}
+
+// start returns the start of the interval containing t, counted from the
+// Unix epoch. time.Time.Truncate counts from Go's zero time instead, which
+// only agrees for intervals that divide a day evenly.
+func (a *Aggregator) start(t time.Time) time.Time {
+	offset := t.UnixNano() % int64(a.interval)
+	if offset < 0 {
+		offset += int64(a.interval)
+	}
+	// Round(0) drops the monotonic clock reading, as Truncate does
+	return t.Add(-time.Duration(offset)).Round(0)
+}
+
+// Advance completes every open candle whose interval ended at or before t
+// and returns them sorted by start and symbol. Call it with each message's
+// time before adding its fills, so a quiet symbol's candle is emitted once
+// the feed moves past it.
+func (a *Aggregator) Advance(t time.Time) []*Candle {
+	var completed []*Candle
+	for symbol, c := range a.open {
+		if !c.End().After(t) {
+			completed = append(completed, c)
+			delete(a.open, symbol)
+		}
+	}
+	sortCandles(completed)
+	return completed
+}
+
+// Add records a fill of symbol at t given the price and size strings from
+// the feed. It returns the symbol's previous candle when the fill starts a
+// new interval, and ErrLate for a fill before the open candle.
+func (a *Aggregator) Add(symbol, price, size string, t time.Time) (completed *Candle, err error) {
+	px, err := decimal.Parse(price)
+	if err != nil {
+		return nil, fmt.Errorf("price for %s: %w", symbol, err)
+	}
+	sz, err := decimal.Parse(size)
+	if err != nil {
+		return nil, fmt.Errorf("size for %s: %w", symbol, err)
+	}
+
+	start := a.start(t)
+	c, ok := a.open[symbol]
+	if ok && start.Before(c.Start) {
+		return nil, ErrLate
+	}
+	if ok && start.After(c.Start) {
+		completed, ok = c, false
+	}
+	if !ok {
+		c = &Candle{
+			Symbol:   symbol,
+			Start:    start,
+			Interval: a.interval,
+			Open:     px,
+			High:     new(big.Rat).Set(px),
+			Low:      new(big.Rat).Set(px),
+			Volume:   new(big.Rat),
+		}
+		a.open[symbol] = c
+	}
+
+	if px.Cmp(c.High) > 0 {
+		c.High.Set(px)
+	}
+	if px.Cmp(c.Low) < 0 {
+		c.Low.Set(px)
+	}
+	c.Close = px
+	c.Volume.Add(c.Volume, sz)
+	c.Fills++
+	return completed, nil
+}
+
+// Flush returns the open candles, which are incomplete, sorted by start and
+// symbol, and forgets them.
+func (a *Aggregator) Flush() []*Candle {
+	open := make([]*Candle, 0, len(a.open))
+	for _, c := range a.open {
+		open = append(open, c)
+	}
+	a.open = make(map[string]*Candle)
+	sortCandles(open)
+	return open
+}
+
+// sortCandles orders candles by start, then symbol
+func sortCandles(candles []*Candle) {
+	sort.Slice(candles, func(i, j int) bool {
+		if !candles[i].Start.Equal(candles[j].Start) {
+			return candles[i].Start.Before(candles[j].Start)
+		}
+		return candles[i].Symbol < candles[j].Symbol
+	})
+}
@@ -0,0 +1,140 @@
+package candle
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/decimal"
+)
+
+var base = time.Date(2025, 10, 14, 10, 4, 0, 0, time.UTC)
+
+func checkCandle(t *testing.T, c *Candle, symbol string, start time.Time, open, high, low, close, volume string, fills int) {
+	t.Helper()
+	if c == nil {
+		t.Fatalf("candle is nil, want %s at %v", symbol, start)
+	}
+	if c.Symbol != symbol || !c.Start.Equal(start) || c.Fills != fills {
+		t.Errorf("candle = %s at %v with %d fills, want %s at %v with %d", c.Symbol, c.Start, c.Fills, symbol, start, fills)
+	}
+	got := []string{
+		decimal.Format(c.Open, decimal.Auto), decimal.Format(c.High, decimal.Auto), decimal.Format(c.Low, decimal.Auto),
+		decimal.Format(c.Close, decimal.Auto), decimal.Format(c.Volume, decimal.Auto),
+	}
+	want := []string{open, high, low, close, volume}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("OHLCV = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestAddBuildsCandle(t *testing.T) {
+	a := NewAggregator(time.Minute)
+	fills := []struct{ price, size string }{
+		{"65000", "0.5"}, {"65010.5", "0.25"}, {"64990", "1"}, {"65005", "0.1"},
+	}
+	for i, f := range fills {
+		completed, err := a.Add("BTC", f.price, f.size, base.Add(time.Duration(i)*10*time.Second))
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		if completed != nil {
+			t.Fatalf("Add completed %+v within the interval", completed)
+		}
+	}
+
+	open := a.Flush()
+	if len(open) != 1 {
+		t.Fatalf("Flush() = %d candles, want 1", len(open))
+	}
+	checkCandle(t, open[0], "BTC", base, "65000", "65010.5", "64990", "65005", "1.85", 4)
+}
+
+func TestAddRollsOverOnNextInterval(t *testing.T) {
+	a := NewAggregator(time.Minute)
+	if _, err := a.Add("ETH", "4000", "1", base.Add(59*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	completed, err := a.Add("ETH", "4010", "2", base.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCandle(t, completed, "ETH", base, "4000", "4000", "4000", "4000", "1", 1)
+
+	open := a.Flush()
+	checkCandle(t, open[0], "ETH", base.Add(time.Minute), "4010", "4010", "4010", "4010", "2", 1)
+}
+
+func TestAddAlignsIntervalsToUnixEpoch(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		want     time.Time
+	}{
+		{time.Hour, time.Date(2025, 10, 14, 10, 0, 0, 0, time.UTC)},
+		// 7m doesn't divide a day, so only the epoch gives these starts
+		{7 * time.Minute, time.Unix(base.Unix()-base.Unix()%420, 0)},
+		// The epoch was a Thursday
+		{7 * 24 * time.Hour, time.Date(2025, 10, 9, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		a := NewAggregator(tt.interval)
+		if _, err := a.Add("BTC", "65000", "1", base); err != nil {
+			t.Fatal(err)
+		}
+		open := a.Flush()
+		if !open[0].Start.Equal(tt.want) {
+			t.Errorf("%v candle starts at %v, want %v", tt.interval, open[0].Start, tt.want)
+		}
+	}
+}
+
+func TestAdvanceCompletesQuietSymbols(t *testing.T) {
+	a := NewAggregator(time.Minute)
+	for _, symbol := range []string{"SOL", "BTC"} {
+		if _, err := a.Add(symbol, "100", "1", base); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := a.Add("ETH", "4000", "1", base.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if completed := a.Advance(base.Add(59 * time.Second)); len(completed) != 0 {
+		t.Fatalf("Advance before the interval end completed %d candles", len(completed))
+	}
+
+	completed := a.Advance(base.Add(time.Minute))
+	if len(completed) != 2 || completed[0].Symbol != "BTC" || completed[1].Symbol != "SOL" {
+		t.Fatalf("Advance() = %+v, want BTC and SOL", completed)
+	}
+	if open := a.Flush(); len(open) != 1 || open[0].Symbol != "ETH" {
+		t.Errorf("Flush() = %+v, want only ETH", open)
+	}
+}
+
+func TestAddRejectsLateFill(t *testing.T) {
+	a := NewAggregator(time.Minute)
+	if _, err := a.Add("BTC", "65000", "1", base.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Add("BTC", "64000", "1", base); !errors.Is(err, ErrLate) {
+		t.Errorf("Add of a late fill = %v, want ErrLate", err)
+	}
+}
+
+func TestAddRejectsInvalidDecimals(t *testing.T) {
+	a := NewAggregator(time.Minute)
+	if _, err := a.Add("BTC", "abc", "1", base); err == nil {
+		t.Error("Add with an invalid price succeeded")
+	}
+	if _, err := a.Add("BTC", "65000", "", base); err == nil {
+		t.Error("Add with an invalid size succeeded")
+	}
+	if open := a.Flush(); len(open) != 0 {
+		t.Errorf("invalid fills opened %d candles", len(open))
+	}
+}
@@ -0,0 +1,39 @@
+// Package capture writes streamed messages to disk exactly as received, to
+// build a corpus for offline testing and replay: one file per message
+// (Writer), or all of them in one gzip-compressed file (GzipWriter).
+package capture
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Writer writes messages into a directory.
+type Writer struct {
+	dir    string
+	prefix string
+}
+
+// New returns a Writer creating files named after kind ("block", "block
+// fills", ...) in dir, which is created if it doesn't exist.
+func New(dir, kind string) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Writer{dir: dir, prefix: strings.ReplaceAll(kind, " ", "-")}, nil
+}
+
+// Write stores data as message number num and returns the file path. Files
+// are named like block-000123.json, with the height appended
+// (block-000123-h812345.json) when it is non-zero, so they sort in receive
+// order and can be matched to a block.
+func (w *Writer) Write(num int, height int64, data []byte) (string, error) {
+	name := fmt.Sprintf("%s-%06d", w.prefix, num)
+	if height != 0 {
+		name += fmt.Sprintf("-h%d", height)
+	}
+	path := filepath.Join(w.dir, name+".json")
+	return path, os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,132 @@
+package capture
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteNamesFilesByNumberAndHeight(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "raw")
+	w, err := New(dir, "block fills")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte(`{"height":812345,"fills":[]}`)
+	path, err := w.Write(123, 812345, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "block-fills-000123-h812345.json"); path != want {
+		t.Errorf("path = %s, want %s", path, want)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("file holds %q, want the message bytes %q", got, data)
+	}
+
+	// Messages whose height is unknown are still numbered
+	path, err = w.Write(124, 0, []byte(`not json`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "block-fills-000124.json"); path != want {
+		t.Errorf("path = %s, want %s", path, want)
+	}
+}
+
+func TestGzipRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocks.gz")
+	w, err := NewGzip(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := [][]byte{[]byte(`{"abci_block":{"height":1}}`), {}, []byte(`{"abci_block":{"height":2}}`)}
+	for _, message := range messages {
+		if err := w.Write(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w.Frames() != 3 {
+		t.Errorf("Frames() = %d, want 3", w.Frames())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readFrames(t, path)
+	if len(got) != len(messages) {
+		t.Fatalf("read %d frames, want %d", len(got), len(messages))
+	}
+	for i := range messages {
+		if !bytes.Equal(got[i], messages[i]) {
+			t.Errorf("frame %d = %q, want %q", i, got[i], messages[i])
+		}
+	}
+}
+
+func TestGzipReaderReportsTruncatedCapture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocks.gz")
+	w, err := NewGzip(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, message := range []string{`{"height":1}`, `{"height":2}`} {
+		if err := w.Write([]byte(message)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Not closed, like a capture whose process was killed
+	w.file.Close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	r, err := NewGzipReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 2 {
+		if _, err := r.Next(); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+	}
+	if _, err := r.Next(); !errors.Is(err, ErrTruncated) {
+		t.Errorf("Next after the last flushed frame = %v, want ErrTruncated", err)
+	}
+}
+
+// readFrames reads every frame of the capture at path
+func readFrames(t *testing.T, path string) [][]byte {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	r, err := NewGzipReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var frames [][]byte
+	for {
+		data, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return frames
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, data)
+	}
+}
@@ -0,0 +1,114 @@
+package capture
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// MaxFrameSize bounds the frames GzipReader accepts, so a corrupt length
+// can't make it allocate unbounded memory. It matches the largest receive
+// limit the examples are used with.
+const MaxFrameSize = 1 << 30 // 1GB
+
+// ErrTruncated is returned by GzipReader.Next for a capture that ends in the
+// middle of a frame or without the gzip trailer, as left behind by a killed
+// process. The frames before it were read intact.
+var ErrTruncated = errors.New("capture is truncated")
+
+// GzipWriter writes messages into a single gzip-compressed file, each as a
+// frame of a 4-byte big-endian length followed by the message bytes. One
+// compressed stream across all messages is far smaller than a file per
+// message, as consecutive blocks share most of their structure. Every frame
+// is flushed, so a capture cut short still holds all messages written
+// before.
+type GzipWriter struct {
+	file   *os.File
+	zw     *gzip.Writer
+	frames int
+}
+
+// NewGzip creates (or truncates) the capture file at path.
+func NewGzip(path string) (*GzipWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &GzipWriter{file: file, zw: gzip.NewWriter(file)}, nil
+}
+
+// Write appends data as the next frame.
+func (w *GzipWriter) Write(data []byte) error {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	if _, err := w.zw.Write(length[:]); err != nil {
+		return err
+	}
+	if _, err := w.zw.Write(data); err != nil {
+		return err
+	}
+	w.frames++
+	return w.zw.Flush()
+}
+
+// Frames returns the number of messages written.
+func (w *GzipWriter) Frames() int {
+	return w.frames
+}
+
+// Close writes the gzip trailer and closes the file.
+func (w *GzipWriter) Close() error {
+	if err := w.zw.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// GzipReader iterates the frames of a capture written by GzipWriter.
+type GzipReader struct {
+	r *bufio.Reader
+}
+
+// NewGzipReader starts reading a capture from r.
+func NewGzipReader(r io.Reader) (*GzipReader, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &GzipReader{r: bufio.NewReader(zr)}, nil
+}
+
+// Next returns the next message. It returns io.EOF after the last frame of a
+// complete capture and ErrTruncated for one that was cut short.
+func (r *GzipReader) Next() ([]byte, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r.r, length[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, truncated(err)
+	}
+
+	size := binary.BigEndian.Uint32(length[:])
+	if size > MaxFrameSize {
+		return nil, fmt.Errorf("frame of %d bytes exceeds the %d byte limit, the capture is corrupt", size, MaxFrameSize)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return nil, truncated(err)
+	}
+	return data, nil
+}
+
+// truncated maps the errors of a stream that ends early to ErrTruncated
+func truncated(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrTruncated
+	}
+	return err
+}
@@ -0,0 +1,326 @@
+// Package checkpoint persists the last processed position of a stream, so a
+// collector restarted after a crash resumes where it stopped instead of at
+// the latest block.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Checkpoint is the last successfully processed block.
+type Checkpoint struct {
+	Height int64 `json:"height"`
+	// Time is the block time in Unix milliseconds, the wire timestamp to
+	// resume from
+	Time int64 `json:"time"`
+	// SavedAt is when the checkpoint was written, for information only
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// Covers reports whether the block at height was already processed. Resuming
+// from Time delivers the checkpointed block again, and blocks without a
+// height are never covered.
+func (c Checkpoint) Covers(height int64) bool {
+	return height != 0 && height <= c.Height
+}
+
+// Load reads the checkpoint at path. A missing file is not an error: ok is
+// then false and the stream starts as configured.
+func Load(path string) (cp Checkpoint, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Checkpoint{}, false, nil
+	}
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return Checkpoint{}, false, fmt.Errorf("checkpoint %s: %w", path, err)
+	}
+	if cp.Time <= 0 {
+		return Checkpoint{}, false, fmt.Errorf("checkpoint %s: no block time", path)
+	}
+	return cp, true, nil
+}
+
+// Save writes cp to path atomically: it is written to a temporary file in
+// the same directory, synced and renamed over path, so a crash leaves either
+// the old or the new checkpoint but never a partial one.
+func Save(path string, cp Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// Removing fails harmlessly once the rename succeeded
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Writer saves the latest processed position at most once per interval. It
+// is not safe for concurrent use.
+type Writer struct {
+	path     string
+	interval time.Duration
+	now      func() time.Time
+
+	latest    Checkpoint
+	dirty     bool
+	lastSave  time.Time
+	saveCount int
+}
+
+// NewWriter returns a Writer saving to path at most every interval; 0 saves
+// on every Update.
+func NewWriter(path string, interval time.Duration) *Writer {
+	return &Writer{path: path, interval: interval, now: time.Now}
+}
+
+// Update records the block at height with blockTime (Unix milliseconds) as
+// processed, and saves it once interval has passed since the last save.
+// Blocks without a time can't be resumed from and are ignored.
+func (w *Writer) Update(height, blockTime int64) error {
+	if blockTime <= 0 {
+		return nil
+	}
+	w.latest = Checkpoint{Height: height, Time: blockTime}
+	w.dirty = true
+
+	if w.now().Sub(w.lastSave) < w.interval {
+		return nil
+	}
+	return w.Flush()
+}
+
+// Flush saves the latest position if it changed since the last save.
+func (w *Writer) Flush() error {
+	if !w.dirty {
+		return nil
+	}
+
+	now := w.now()
+	w.latest.SavedAt = now.UTC()
+	if err := Save(w.path, w.latest); err != nil {
+		return err
+	}
+	w.dirty = false
+	w.lastSave = now
+	w.saveCount++
+	return nil
+}
+
+// Latest returns the latest recorded position, saved or not.
+func (w *Writer) Latest() Checkpoint {
+	return w.latest
+}
+
+// Saves returns how many times the checkpoint was written.
+func (w *Writer) Saves() int {
+	return w.saveCount
+}
+
+// Ordered advances a Writer over blocks that complete out of order, such as
+// asynchronously delivered batches. Blocks are numbered by Start in the
+// order they are processed, and the checkpoint only moves over a block once
+// it and every block started before it succeeded, so one still in flight is
+// never skipped on resume. A failed block holds the checkpoint before it for
+// the rest of the run, so a restart processes it again. Its methods are safe
+// for concurrent use, and a nil *Ordered tracks nothing.
+type Ordered struct {
+	mu     sync.Mutex
+	w      *Writer
+	next   int64 // seq of the next block to start
+	oldest int64 // seq of the oldest block not yet checkpointed
+	// done holds blocks that succeeded after oldest, by seq
+	done map[int64]Checkpoint
+	// failedSeq is the oldest failed block, valid once failed is set
+	failed    bool
+	failedSeq int64
+}
+
+// NewOrdered returns an Ordered advancing w.
+func NewOrdered(w *Writer) *Ordered {
+	return &Ordered{w: w, done: make(map[int64]Checkpoint)}
+}
+
+// Start numbers the next block. Pass the number to Done once the block
+// completes.
+func (o *Ordered) Start() int64 {
+	if o == nil {
+		return 0
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	seq := o.next
+	o.next++
+	return seq
+}
+
+// Done records the block numbered seq, at height with blockTime (Unix
+// milliseconds), as succeeded, or as failed when err is non-nil, and
+// advances the checkpoint as far as every earlier block succeeded. It
+// returns the error of saving the checkpoint, if any.
+func (o *Ordered) Done(seq, height, blockTime int64, err error) error {
+	if o == nil {
+		return nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err != nil {
+		if !o.failed || seq < o.failedSeq {
+			o.failed, o.failedSeq = true, seq
+			// Blocks after a failure can't be checkpointed any more
+			for later := range o.done {
+				if later > seq {
+					delete(o.done, later)
+				}
+			}
+		}
+		return nil
+	}
+	if o.failed && seq > o.failedSeq {
+		return nil
+	}
+	o.done[seq] = Checkpoint{Height: height, Time: blockTime}
+
+	var saveErr error
+	for {
+		cp, ok := o.done[o.oldest]
+		if !ok {
+			return saveErr
+		}
+		delete(o.done, o.oldest)
+		o.oldest++
+		if err := o.w.Update(cp.Height, cp.Time); err != nil {
+			saveErr = err
+		}
+	}
+}
+
+// Held reports whether a failed block holds the checkpoint back.
+func (o *Ordered) Held() bool {
+	if o == nil {
+		return false
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.failed
+}
+
+// Flush saves the latest checkpointed block.
+func (o *Ordered) Flush() error {
+	if o == nil {
+		return nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.w.Flush()
+}
+
+// Latest returns the latest checkpointed block.
+func (o *Ordered) Latest() Checkpoint {
+	if o == nil {
+		return Checkpoint{}
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.w.Latest()
+}
+
+// Batch advances a Writer over blocks stored in batches, such as database
+// transactions: blocks are added to the open batch and only checkpointed
+// once it commits. A block that failed to be added, or a batch that failed to
+// commit, holds the checkpoint before it for the rest of the run, so a
+// restart stores the lost blocks again. It is not safe for concurrent use,
+// and a nil *Batch tracks nothing.
+type Batch struct {
+	w *Writer
+	// last is the latest block of the open batch, valid once pending is set
+	last    Checkpoint
+	pending bool
+	failed  bool
+}
+
+// NewBatch returns a Batch advancing w.
+func NewBatch(w *Writer) *Batch {
+	return &Batch{w: w}
+}
+
+// Add records the block at height with blockTime (Unix milliseconds) as
+// added to the open batch, or as failed when err is non-nil.
+func (b *Batch) Add(height, blockTime int64, err error) {
+	if b == nil {
+		return
+	}
+	if err != nil {
+		b.failed = true
+		return
+	}
+	b.last = Checkpoint{Height: height, Time: blockTime}
+	b.pending = true
+}
+
+// Commit records the open batch as committed, or as rolled back when err is
+// non-nil, and advances the checkpoint to its latest block unless a failure
+// holds it. It returns the error of saving the checkpoint, if any.
+func (b *Batch) Commit(err error) error {
+	if b == nil {
+		return nil
+	}
+	pending := b.pending
+	b.pending = false
+	if err != nil {
+		b.failed = true
+		return nil
+	}
+	if !pending || b.failed {
+		return nil
+	}
+	return b.w.Update(b.last.Height, b.last.Time)
+}
+
+// Held reports whether a failed block or batch holds the checkpoint back.
+func (b *Batch) Held() bool {
+	return b != nil && b.failed
+}
+
+// Flush saves the latest checkpointed block.
+func (b *Batch) Flush() error {
+	if b == nil {
+		return nil
+	}
+	return b.w.Flush()
+}
+
+// Latest returns the latest checkpointed block.
+func (b *Batch) Latest() Checkpoint {
+	if b == nil {
+		return Checkpoint{}
+	}
+	return b.w.Latest()
+}
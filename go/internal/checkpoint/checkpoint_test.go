@@ -0,0 +1,242 @@
+package checkpoint
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocks.checkpoint")
+	want := Checkpoint{Height: 812345, Time: 1760436240123, SavedAt: time.Date(2025, 10, 14, 10, 4, 0, 0, time.UTC)}
+
+	if err := Save(path, want); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, ok, err := Load(path)
+	if err != nil || !ok {
+		t.Fatalf("Load = %v, %v", ok, err)
+	}
+	if got != want {
+		t.Errorf("Load = %+v, want %+v", got, want)
+	}
+
+	// Only the checkpoint itself is left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files after Save, want 1", len(entries))
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, ok, err := Load(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || ok {
+		t.Errorf("Load of a missing file = %v, %v, want false, nil", ok, err)
+	}
+}
+
+func TestLoadRejectsInvalidCheckpoints(t *testing.T) {
+	for name, content := range map[string]string{
+		"truncated": `{"height": 8123`,
+		"no time":   `{"height": 812345}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "checkpoint")
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := Load(path); err == nil {
+				t.Error("Load succeeded")
+			}
+		})
+	}
+}
+
+func TestCovers(t *testing.T) {
+	cp := Checkpoint{Height: 100, Time: 1}
+	for height, want := range map[int64]bool{99: true, 100: true, 101: false, 0: false} {
+		if got := cp.Covers(height); got != want {
+			t.Errorf("Covers(%d) = %v, want %v", height, got, want)
+		}
+	}
+}
+
+func TestWriterSavesAtMostOncePerInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	now := time.Date(2025, 10, 14, 10, 4, 0, 0, time.UTC)
+	w := NewWriter(path, 5*time.Second)
+	w.now = func() time.Time { return now }
+
+	update := func(height int64) {
+		t.Helper()
+		if err := w.Update(height, height*1000); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+	}
+
+	update(1) // first update saves right away
+	now = now.Add(time.Second)
+	update(2)
+	if cp, _, _ := Load(path); cp.Height != 1 || w.Saves() != 1 {
+		t.Fatalf("saved height %d after %d saves, want 1 after 1", cp.Height, w.Saves())
+	}
+
+	now = now.Add(5 * time.Second)
+	update(3)
+	if cp, _, _ := Load(path); cp.Height != 3 || w.Saves() != 2 {
+		t.Fatalf("saved height %d after %d saves, want 3 after 2", cp.Height, w.Saves())
+	}
+
+	// Blocks without a time are skipped, and Flush writes only changes
+	if err := w.Update(4, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if w.Saves() != 2 || w.Latest().Height != 3 {
+		t.Errorf("Flush without changes saved: %d saves, latest %+v", w.Saves(), w.Latest())
+	}
+
+	update(5)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if cp, _, _ := Load(path); cp.Height != 5 || cp.Time != 5000 {
+		t.Errorf("Flush saved %+v, want height 5", cp)
+	}
+}
+
+func TestOrderedAdvancesInStartOrder(t *testing.T) {
+	o := NewOrdered(NewWriter(filepath.Join(t.TempDir(), "checkpoint"), 0))
+	seqs := []int64{o.Start(), o.Start(), o.Start()}
+
+	// The third block completes first, but the first is still in flight
+	if err := o.Done(seqs[2], 30, 3000, nil); err != nil {
+		t.Fatal(err)
+	}
+	if latest := o.Latest(); latest.Height != 0 {
+		t.Fatalf("checkpoint moved to %d past a block in flight", latest.Height)
+	}
+	o.Done(seqs[0], 10, 1000, nil)
+	if latest := o.Latest(); latest.Height != 10 {
+		t.Fatalf("checkpoint at %d, want 10", latest.Height)
+	}
+	o.Done(seqs[1], 20, 2000, nil)
+	if latest := o.Latest(); latest.Height != 30 {
+		t.Errorf("checkpoint at %d, want 30", latest.Height)
+	}
+	if o.Held() {
+		t.Error("Held() without a failure")
+	}
+}
+
+func TestOrderedHoldsCheckpointBeforeFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	o := NewOrdered(NewWriter(path, 0))
+	seqs := []int64{o.Start(), o.Start(), o.Start(), o.Start()}
+
+	o.Done(seqs[0], 10, 1000, nil)
+	o.Done(seqs[2], 30, 3000, nil)
+	o.Done(seqs[1], 20, 2000, errors.New("broker unavailable"))
+	o.Done(seqs[3], 40, 4000, nil)
+
+	// A restart resumes after the last block before the failure, so the
+	// failed block and everything after it are processed again
+	if err := o.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if cp, _, _ := Load(path); cp.Height != 10 {
+		t.Errorf("checkpoint saved at height %d, want 10", cp.Height)
+	}
+	if !o.Held() {
+		t.Error("Held() = false after a failure")
+	}
+}
+
+func TestNilOrdered(t *testing.T) {
+	var o *Ordered
+	if err := o.Done(o.Start(), 10, 1000, nil); err != nil {
+		t.Errorf("Done: %v", err)
+	}
+	if err := o.Flush(); err != nil || o.Latest().Height != 0 || o.Held() {
+		t.Error("a nil Ordered tracked something")
+	}
+}
+
+func TestBatchAdvancesOnCommit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	b := NewBatch(NewWriter(path, 0))
+
+	b.Add(10, 1000, nil)
+	b.Add(20, 2000, nil)
+	if latest := b.Latest(); latest.Height != 0 {
+		t.Fatalf("checkpoint moved to %d before the batch committed", latest.Height)
+	}
+	if err := b.Commit(nil); err != nil {
+		t.Fatal(err)
+	}
+	if cp, _, _ := Load(path); cp.Height != 20 {
+		t.Errorf("checkpoint saved at height %d, want 20", cp.Height)
+	}
+
+	// Committing an empty batch leaves the checkpoint where it is
+	if err := b.Commit(nil); err != nil || b.Latest().Height != 20 || b.Held() {
+		t.Errorf("empty commit: %v, latest %+v, held %v", err, b.Latest(), b.Held())
+	}
+}
+
+func TestBatchHoldsCheckpointBeforeFailure(t *testing.T) {
+	for name, fail := range map[string]func(b *Batch){
+		"failed commit": func(b *Batch) {
+			b.Add(20, 2000, nil)
+			b.Commit(errors.New("database is locked"))
+		},
+		"failed add": func(b *Batch) {
+			b.Add(20, 2000, errors.New("disk I/O error"))
+			b.Add(25, 2500, nil)
+			b.Commit(nil)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "checkpoint")
+			b := NewBatch(NewWriter(path, 0))
+			b.Add(10, 1000, nil)
+			b.Commit(nil)
+
+			fail(b)
+
+			// Later batches commit, but a restart must store the lost
+			// block again
+			b.Add(30, 3000, nil)
+			if err := b.Commit(nil); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if cp, _, _ := Load(path); cp.Height != 10 {
+				t.Errorf("checkpoint saved at height %d, want 10", cp.Height)
+			}
+			if !b.Held() {
+				t.Error("Held() = false after a failure")
+			}
+		})
+	}
+}
+
+func TestNilBatch(t *testing.T) {
+	var b *Batch
+	b.Add(10, 1000, nil)
+	if err := b.Commit(nil); err != nil {
+		t.Errorf("Commit: %v", err)
+	}
+	if err := b.Flush(); err != nil || b.Latest().Height != 0 || b.Held() {
+		t.Error("a nil Batch tracked something")
+	}
+}
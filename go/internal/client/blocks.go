@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/model"
+	"github.com/dwellir/grpc-code-examples/go/internal/stats"
+)
+
+// Block is a streamed block together with its decoded form.
+type Block struct {
+	// Data is the raw JSON message as received.
+	Data []byte
+	// Decoded is the typed block, nil when DecodeErr is set.
+	Decoded *model.Block
+	// DecodeErr reports a message that could not be decoded. The stream
+	// continues after such messages.
+	DecodeErr error
+}
+
+// WithDecodeWorkers decodes blocks on n goroutines in parallel, for feeds
+// where decoding on the receive goroutine can't keep up. Blocks are still
+// delivered in the order they were received. Values below 2 decode on the
+// receive goroutine.
+func WithDecodeWorkers(n int) StreamOption {
+	return func(o *streamOptions) {
+		o.decodeWorkers = n
+	}
+}
+
+// WithDedup drops blocks already recorded in dedup, by height and block time,
+// before they are emitted, so a block re-delivered after a reconnect never
+// reaches the caller. Blocks without a height, including ones that failed to
+// decode, are always emitted. dedup is only used by the stream until its
+// channels are closed, after which dedup.Duplicates can be read.
+func WithDedup(dedup *stats.Dedup[stats.BlockKey]) StreamOption {
+	return func(o *streamOptions) {
+		o.dedup = dedup
+	}
+}
+
+// StreamBlocks streams blocks from gateway and emits each one decoded on the
+// returned block channel. The channel is closed when the server ends the
+// stream, the stream fails or ctx is cancelled; the error channel then yields
+// the error that ended the stream, if any, and is closed. Consumers must read
+// blocks until it is closed.
+func StreamBlocks(ctx context.Context, gateway pb.HyperLiquidL1GatewayClient, request *pb.Timestamp, opts ...StreamOption) (<-chan *Block, <-chan error) {
+	o := newStreamOptions(opts)
+	return streamBlocks(ctx, o, func(handle func(*pb.Block)) error {
+		return receive(ctx, ctx, gateway, pb.HyperLiquidL1GatewayClient.StreamBlocks, request, o, handle)
+	})
+}
+
+// StreamBlocksWithReconnect is StreamBlocks on top of StreamWithReconnect:
+// failed or idle streams are re-established, and cancelling ctx drains the
+// block being received before the channels are closed.
+func StreamBlocksWithReconnect(ctx context.Context, conn *grpc.ClientConn, redial Redialer, request *pb.Timestamp, opts ...StreamOption) (<-chan *Block, <-chan error) {
+	return streamBlocks(ctx, newStreamOptions(opts), func(handle func(*pb.Block)) error {
+		return StreamWithReconnect(ctx, conn, redial, pb.HyperLiquidL1GatewayClient.StreamBlocks, request, handle, opts...)
+	})
+}
+
+// streamBlocks runs a stream in a goroutine, decoding every message onto the
+// block channel unless o.dedup has seen it
+func streamBlocks(ctx context.Context, o streamOptions, run func(handle func(*pb.Block)) error) (<-chan *Block, <-chan error) {
+	blocks := make(chan *Block)
+	errs := make(chan error, 1)
+
+	// Only ever called from one goroutine, in receive order
+	emit := func(block *Block) {
+		if o.dedup != nil && block.Decoded != nil && block.Decoded.ABCIBlock.Height != 0 {
+			key := stats.BlockKey{Height: block.Decoded.ABCIBlock.Height, Time: block.Decoded.ABCIBlock.BlockTime}
+			if o.dedup.Seen(key) {
+				slog.Debug("skipping duplicate block", "height", key.Height, "time", key.Time)
+				return
+			}
+		}
+		blocks <- block
+	}
+
+	go func() {
+		defer close(errs)
+		defer close(blocks)
+
+		var err error
+		if o.decodeWorkers < 2 {
+			err = run(func(msg *pb.Block) {
+				emit(decodeBlock(msg))
+			})
+		} else {
+			err = decodeInParallel(emit, o.decodeWorkers, run)
+		}
+		// A cancelled ctx is how callers stop the stream, not a failure
+		if err != nil && ctx.Err() == nil {
+			errs <- err
+		}
+	}()
+
+	return blocks, errs
+}
+
+// decodeInParallel runs the stream, decoding messages on workers goroutines.
+// Every message gets a result channel that is queued in receive order, and
+// blocks are emitted by reading those channels in turn, so a fast worker
+// can't overtake a slower one. The bounded queue makes the receive loop
+// wait once workers blocks are in flight.
+func decodeInParallel(emit func(*Block), workers int, run func(handle func(*pb.Block)) error) error {
+	type job struct {
+		msg    *pb.Block
+		result chan *Block
+	}
+	jobs := make(chan job, workers)
+	queue := make(chan chan *Block, workers)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				j.result <- decodeBlock(j.msg)
+			}
+		}()
+	}
+
+	emitted := make(chan struct{})
+	go func() {
+		defer close(emitted)
+		for result := range queue {
+			emit(<-result)
+		}
+	}()
+
+	err := run(func(msg *pb.Block) {
+		result := make(chan *Block, 1)
+		queue <- result
+		jobs <- job{msg: msg, result: result}
+	})
+
+	close(jobs)
+	close(queue)
+	wg.Wait()
+	<-emitted
+	return err
+}
+
+// decodeBlock decodes a streamed message
+func decodeBlock(msg *pb.Block) *Block {
+	block := &Block{Data: msg.Data}
+	block.Decoded, block.DecodeErr = model.DecodeBlock(msg.Data)
+	return block
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/mockgateway"
+	"github.com/dwellir/grpc-code-examples/go/internal/stats"
+)
+
+func TestStreamBlocksDecodesIntoChannel(t *testing.T) {
+	server := &mockgateway.Server{
+		Blocks: append(cannedBlocks(2), &pb.Block{Data: []byte("not json")}),
+	}
+	conn, ctx, _ := startGateway(t, server)
+
+	blocks, errs := StreamBlocks(ctx, NewGatewayClient(conn), &pb.Timestamp{})
+
+	var heights []int64
+	decodeErrors := 0
+	for block := range blocks {
+		if block.DecodeErr != nil {
+			decodeErrors++
+			continue
+		}
+		heights = append(heights, block.Decoded.ABCIBlock.Height)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("StreamBlocks: %v", err)
+	}
+
+	if len(heights) != 2 || heights[0] != 1 || heights[1] != 2 {
+		t.Errorf("heights = %v, want [1 2]", heights)
+	}
+	if decodeErrors != 1 {
+		t.Errorf("decode errors = %d, want 1", decodeErrors)
+	}
+}
+
+func TestStreamBlocksDecodeWorkersPreserveOrder(t *testing.T) {
+	server := &mockgateway.Server{Blocks: cannedBlocks(50)}
+	conn, ctx, _ := startGateway(t, server)
+
+	blocks, errs := StreamBlocks(ctx, NewGatewayClient(conn), &pb.Timestamp{}, WithDecodeWorkers(4))
+
+	var want int64 = 1
+	for block := range blocks {
+		if block.DecodeErr != nil {
+			t.Fatalf("block %d: %v", want, block.DecodeErr)
+		}
+		if got := block.Decoded.ABCIBlock.Height; got != want {
+			t.Fatalf("height = %d, want %d", got, want)
+		}
+		want++
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("StreamBlocks: %v", err)
+	}
+	if want != 51 {
+		t.Errorf("received %d blocks, want 50", want-1)
+	}
+}
+
+func TestStreamBlocksWithReconnectSkipsRedeliveredBlocks(t *testing.T) {
+	defer func(initial time.Duration) { initialBackoff = initial }(initialBackoff)
+	initialBackoff = 10 * time.Millisecond
+
+	for _, workers := range []int{1, 4} {
+		// The first stream fails after both blocks, so the second one
+		// delivers them again
+		server := &mockgateway.Server{
+			Blocks:       cannedBlocks(2),
+			StreamErrors: []error{status.Error(codes.Unavailable, "connection reset")},
+		}
+		conn, ctx, redial := startGateway(t, server)
+
+		dedup := stats.NewDedup[stats.BlockKey](64)
+		blocks, errs := StreamBlocksWithReconnect(ctx, conn, redial, &pb.Timestamp{}, WithDedup(dedup), WithDecodeWorkers(workers))
+
+		// Everything reading the channel, counters and sinks alike, sees
+		// each block once
+		var heights []int64
+		for block := range blocks {
+			heights = append(heights, block.Decoded.ABCIBlock.Height)
+		}
+		if err := <-errs; err != nil {
+			t.Fatalf("StreamBlocksWithReconnect: %v", err)
+		}
+
+		if len(heights) != 2 || heights[0] != 1 || heights[1] != 2 {
+			t.Errorf("workers %d: heights = %v, want [1 2]", workers, heights)
+		}
+		if dedup.Duplicates() != 2 {
+			t.Errorf("workers %d: duplicates = %d, want 2", workers, dedup.Duplicates())
+		}
+		if calls := server.Calls(); calls != 2 {
+			t.Errorf("workers %d: server saw %d streams, want 2", workers, calls)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ErrCircuitOpen is returned by StreamWithReconnect when the circuit breaker
+// opens and Breaker.ExitWhenOpen is set. It wraps the last stream error.
+var ErrCircuitOpen = errors.New("circuit breaker open: too many consecutive reconnect failures")
+
+// Breaker configures the circuit breaker of StreamWithReconnect (see
+// WithCircuitBreaker).
+type Breaker struct {
+	// Threshold is the number of consecutive failures within Window that
+	// opens the breaker; 0 disables it
+	Threshold int
+	Window    time.Duration
+	// Cooldown is how long an open breaker waits before letting one attempt
+	// through (half-open)
+	Cooldown time.Duration
+	// ExitWhenOpen gives up with ErrCircuitOpen instead of cooling down, for
+	// when there is no other endpoint to fail over to
+	ExitWhenOpen bool
+}
+
+// WithCircuitBreaker stops StreamWithReconnect from hammering a failing
+// endpoint. Failed streams and failed re-dials count as failures, and a
+// received message resets the count. Once b.Threshold failures happen within
+// b.Window the breaker opens: StreamWithReconnect either returns
+// ErrCircuitOpen (b.ExitWhenOpen) or waits b.Cooldown before a single
+// half-open attempt, which closes the breaker when a message arrives and
+// reopens it when it fails. Transitions are logged.
+func WithCircuitBreaker(b Breaker) StreamOption {
+	return func(o *streamOptions) {
+		o.breaker = b
+	}
+}
+
+// Breaker states
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// circuitBreaker tracks the failures of one StreamWithReconnect call
+type circuitBreaker struct {
+	Breaker
+	state    string
+	failures []time.Time
+}
+
+func newCircuitBreaker(b Breaker) *circuitBreaker {
+	return &circuitBreaker{Breaker: b, state: breakerClosed}
+}
+
+// Success records a received message, closing the breaker
+func (b *circuitBreaker) Success() {
+	if b.state != breakerClosed {
+		b.transition(breakerClosed)
+	}
+	b.failures = b.failures[:0]
+}
+
+// Failure records a failure at now and reports whether the breaker is open
+func (b *circuitBreaker) Failure(now time.Time) bool {
+	if b.Threshold <= 0 {
+		return false
+	}
+
+	// A failed half-open attempt reopens the breaker right away
+	if b.state == breakerHalfOpen {
+		b.transition(breakerOpen)
+		return true
+	}
+
+	// Only failures within the window count
+	kept := b.failures[:0]
+	for _, t := range b.failures {
+		if now.Sub(t) < b.Window {
+			kept = append(kept, t)
+		}
+	}
+	b.failures = append(kept, now)
+
+	if len(b.failures) < b.Threshold {
+		return false
+	}
+	b.transition(breakerOpen)
+	return true
+}
+
+// HalfOpen lets the next attempt through after the cooldown
+func (b *circuitBreaker) HalfOpen() {
+	b.failures = b.failures[:0]
+	b.transition(breakerHalfOpen)
+}
+
+func (b *circuitBreaker) transition(state string) {
+	switch state {
+	case breakerOpen:
+		slog.Warn("circuit breaker open", "from", b.state, "failures", len(b.failures), "window", b.Window, "cooldown", b.Cooldown)
+	case breakerHalfOpen:
+		slog.Info("circuit breaker half-open, trying one reconnect", "from", b.state)
+	default:
+		slog.Info("circuit breaker closed", "from", b.state)
+	}
+	b.state = state
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/mockgateway"
+)
+
+func TestCircuitBreakerCountsFailuresWithinWindow(t *testing.T) {
+	b := newCircuitBreaker(Breaker{Threshold: 3, Window: time.Minute, Cooldown: time.Hour})
+	start := time.Now()
+
+	if b.Failure(start) || b.Failure(start.Add(30*time.Second)) {
+		t.Fatal("breaker opened before the threshold")
+	}
+	// The first failure has left the window by now
+	if b.Failure(start.Add(70 * time.Second)) {
+		t.Fatal("breaker counted a failure outside the window")
+	}
+	if !b.Failure(start.Add(80 * time.Second)) {
+		t.Fatal("breaker didn't open at the threshold")
+	}
+
+	// A failed half-open attempt reopens it at once, a success closes it
+	b.HalfOpen()
+	if !b.Failure(start.Add(2 * time.Hour)) {
+		t.Error("breaker didn't reopen after a failed half-open attempt")
+	}
+	b.HalfOpen()
+	b.Success()
+	if b.state != breakerClosed || b.Failure(start.Add(3*time.Hour)) {
+		t.Error("breaker didn't close after a success")
+	}
+}
+
+func TestStreamWithReconnectExitsWhenBreakerOpens(t *testing.T) {
+	defer func(initial time.Duration) { initialBackoff = initial }(initialBackoff)
+	initialBackoff = time.Millisecond
+
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	server := &mockgateway.Server{StreamErrors: []error{unavailable, unavailable, unavailable, unavailable}}
+	conn, ctx, redial := startGateway(t, server)
+
+	err := StreamWithReconnect(ctx, conn, redial, pb.HyperLiquidL1GatewayClient.StreamBlocks, &pb.Timestamp{}, func(*pb.Block) {},
+		WithCircuitBreaker(Breaker{Threshold: 3, Window: time.Minute, ExitWhenOpen: true}))
+	if !errors.Is(err, ErrCircuitOpen) || status.Code(err) != codes.Unavailable {
+		t.Fatalf("StreamWithReconnect error = %v, want ErrCircuitOpen wrapping Unavailable", err)
+	}
+	if Classify(err).Retryable {
+		t.Error("ErrCircuitOpen classified as retryable")
+	}
+	if calls := server.Calls(); calls != 3 {
+		t.Errorf("server saw %d streams, want 3", calls)
+	}
+}
+
+func TestStreamWithReconnectCoolsDownWhenBreakerOpens(t *testing.T) {
+	defer func(initial time.Duration) { initialBackoff = initial }(initialBackoff)
+	initialBackoff = time.Millisecond
+
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	server := &mockgateway.Server{StreamErrors: []error{unavailable, unavailable}}
+	conn, ctx, redial := startGateway(t, server)
+
+	const cooldown = 50 * time.Millisecond
+	start := time.Now()
+	err := StreamWithReconnect(ctx, conn, redial, pb.HyperLiquidL1GatewayClient.StreamBlocks, &pb.Timestamp{}, func(*pb.Block) {},
+		WithCircuitBreaker(Breaker{Threshold: 1, Window: time.Minute, Cooldown: cooldown}))
+	if err != nil {
+		t.Fatalf("StreamWithReconnect: %v", err)
+	}
+	// Opened by the first failure, reopened by the failed half-open attempt
+	if elapsed := time.Since(start); elapsed < 2*cooldown {
+		t.Errorf("finished after %v, want at least two cooldowns (%v)", elapsed, 2*cooldown)
+	}
+	if calls := server.Calls(); calls != 3 {
+		t.Errorf("server saw %d streams, want 3", calls)
+	}
+}
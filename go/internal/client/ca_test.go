@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/mockgateway"
+)
+
+// newTestCA returns the PEM of a fresh CA certificate and a server
+// certificate it signed for serverName
+func newTestCA(t *testing.T, serverName string) (caPEM []byte, server tls.Certificate) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Internal CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: serverName},
+		DNSNames:     []string{serverName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	serverDER, err := x509.CreateCertificate(rand.Reader, serverTemplate, caTemplate, &serverKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	return caPEM, tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}
+}
+
+func writeFile(t *testing.T, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadCertPoolVerifiesPrivateCA(t *testing.T) {
+	caPEM, serverCert := newTestCA(t, "gateway.internal")
+
+	// A gateway serving a certificate of the private CA
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{serverCert}})))
+	pb.RegisterHyperLiquidL1GatewayServer(server, &mockgateway.Server{Snapshot: &pb.OrderBookSnapshot{Data: []byte(`{}`)}})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	dialer := WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}))
+
+	// Another key in the bundle is skipped
+	bundle := append([]byte("-----BEGIN EC PARAMETERS-----\nBggqhkjOPQMBBw==\n-----END EC PARAMETERS-----\n"), caPEM...)
+	pool, err := LoadCertPool(writeFile(t, bundle))
+	if err != nil {
+		t.Fatalf("LoadCertPool: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		pool   *x509.CertPool
+		wantOK bool
+	}{
+		{"private CA", pool, true},
+		{"system roots", nil, false},
+	} {
+		conn, err := Connect(mockgateway.Target, "", WithTLSConfig(&tls.Config{ServerName: "gateway.internal", RootCAs: tc.pool}), dialer)
+		if err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err = NewGatewayClient(conn).GetOrderBookSnapshot(ctx, &pb.Timestamp{})
+		cancel()
+		conn.Close()
+		if (err == nil) != tc.wantOK {
+			t.Errorf("%s: call error = %v, want success %v", tc.name, err, tc.wantOK)
+		}
+	}
+}
+
+func TestLoadCertPoolErrors(t *testing.T) {
+	caPEM, _ := newTestCA(t, "gateway.internal")
+	block, _ := pem.Decode(caPEM)
+	corrupt := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: block.Bytes[:len(block.Bytes)/2]})
+
+	for name, path := range map[string]string{
+		"missing file":        filepath.Join(t.TempDir(), "missing.pem"),
+		"no certificates":     writeFile(t, []byte("not a certificate\n")),
+		"corrupt certificate": writeFile(t, append(caPEM, corrupt...)),
+	} {
+		if _, err := LoadCertPool(path); err == nil {
+			t.Errorf("%s: LoadCertPool succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package client contains the gRPC connection setup shared by the Hyperliquid
+// examples: transport credentials, dial options and API key call credentials.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+)
+
+// DefaultMaxMessageSize is the receive limit used by the streaming examples.
+const DefaultMaxMessageSize = 150 * 1024 * 1024 // 150MB
+
+// Option configures how Connect builds the connection.
+type Option func(*options)
+
+type options struct {
+	maxMsgSize int
+	tls        bool
+	// tlsExplicit is set when TLS was asked for rather than left at the default
+	tlsExplicit bool
+	tlsConfig   *tls.Config
+	keepalive   *keepalive.ClientParameters
+	dialOptions []grpc.DialOption
+	watchCtx    context.Context
+	headers     []Header
+}
+
+// WithMaxMessageSize sets the maximum size of messages received on the
+// connection.
+func WithMaxMessageSize(size int) Option {
+	return func(o *options) {
+		o.maxMsgSize = size
+	}
+}
+
+// WithTLS controls whether the connection uses TLS. It is enabled by default,
+// except for unix socket endpoints.
+func WithTLS(enabled bool) Option {
+	return func(o *options) {
+		o.tls = enabled
+		o.tlsExplicit = enabled
+	}
+}
+
+// WithTLSConfig enables TLS with cfg instead of the default configuration,
+// e.g. to override the server name checked against the certificate.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(o *options) {
+		o.tls = true
+		o.tlsExplicit = true
+		o.tlsConfig = cfg
+	}
+}
+
+// WithKeepalive pings the server every interval while the connection is
+// idle and closes it when a ping is not acknowledged within timeout, so
+// streams silently dropped by intermediaries are detected. A zero interval
+// leaves keepalive disabled.
+func WithKeepalive(interval, timeout time.Duration) Option {
+	return func(o *options) {
+		if interval <= 0 {
+			o.keepalive = nil
+			return
+		}
+		o.keepalive = &keepalive.ClientParameters{
+			Time:                interval,
+			Timeout:             timeout,
+			PermitWithoutStream: true,
+		}
+	}
+}
+
+// WithDialOptions appends raw gRPC dial options, for tuning that has no
+// dedicated option.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+// Connect creates a client connection to endpoint. When an API key is
+// provided it is sent as x-api-key metadata on every call; with TLS enabled
+// gRPC refuses to send it over an insecure transport. Unix socket endpoints
+// (see IsUnixSocket) connect without TLS, and asking for TLS on one fails
+// with ErrTLSOverUnixSocket.
+func Connect(endpoint, apiKey string, opts ...Option) (*grpc.ClientConn, error) {
+	o := options{
+		maxMsgSize: DefaultMaxMessageSize,
+		tls:        true,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if IsUnixSocket(endpoint) {
+		if o.tlsExplicit {
+			return nil, fmt.Errorf("%s: %w", endpoint, ErrTLSOverUnixSocket)
+		}
+		o.tls = false
+	}
+
+	creds := insecure.NewCredentials()
+	if o.tls {
+		creds = credentials.NewTLS(o.tlsConfig)
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(o.maxMsgSize)),
+	}
+	// Attach the API key only if provided - some endpoints are public
+	if apiKey != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(apiKeyCreds{apiKey: apiKey, requireTLS: o.tls}))
+	}
+	if o.keepalive != nil {
+		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(*o.keepalive))
+	}
+	dialOpts = append(dialOpts, headerDialOptions(o.headers)...)
+	dialOpts = append(dialOpts, o.dialOptions...)
+
+	conn, err := grpc.NewClient(endpoint, dialOpts...)
+	if err == nil && o.watchCtx != nil {
+		go WatchState(o.watchCtx, conn, endpoint)
+	}
+	return conn, err
+}
+
+// NewGatewayClient wraps conn in the generated Hyperliquid gateway client.
+func NewGatewayClient(conn *grpc.ClientConn) pb.HyperLiquidL1GatewayClient {
+	return pb.NewHyperLiquidL1GatewayClient(conn)
+}
+
+// WaitForReady starts connecting conn and blocks until it is ready, ctx is
+// cancelled or timeout elapses. grpc.NewClient connects lazily, so this is
+// what makes a successful connection observable before the first call.
+func WaitForReady(ctx context.Context, conn *grpc.ClientConn, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Shutdown:
+			return errors.New("connection is closed")
+		}
+
+		if !conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("connection not ready after %v (last state: %s)", timeout, state)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// apiKeyCreds attaches the API key as x-api-key metadata to every call made
+// on a connection.
+type apiKeyCreds struct {
+	apiKey string
+	// requireTLS refuses to send the key over a connection without transport
+	// security; it is only off when plaintext was chosen explicitly
+	requireTLS bool
+}
+
+var _ credentials.PerRPCCredentials = apiKeyCreds{}
+
+func (c apiKeyCreds) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"x-api-key": c.apiKey}, nil
+}
+
+func (c apiKeyCreds) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
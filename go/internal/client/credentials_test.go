@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/mockgateway"
+)
+
+func TestConnectSendsAPIKeyOnEveryCall(t *testing.T) {
+	server := &mockgateway.Server{
+		APIKey:   "secret",
+		Snapshot: &pb.OrderBookSnapshot{Data: []byte(`{}`)},
+	}
+	lis := mockgateway.Listen(server)
+	t.Cleanup(lis.Close)
+
+	for _, tc := range []struct {
+		apiKey   string
+		wantAuth bool
+	}{
+		{apiKey: "secret"},
+		{apiKey: "wrong", wantAuth: true},
+		{apiKey: "", wantAuth: true},
+	} {
+		// Plaintext is chosen explicitly, so the key may be sent without TLS
+		conn, err := Connect(mockgateway.Target, tc.apiKey, WithTLS(false), WithDialOptions(lis.DialOption()))
+		if err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+
+		// Twice, to check the key isn't tied to a single call's context
+		for range 2 {
+			_, err = NewGatewayClient(conn).GetOrderBookSnapshot(context.Background(), &pb.Timestamp{})
+			if _, auth := ClassifyError(err); auth != tc.wantAuth {
+				t.Errorf("key %q: err = %v, want auth failure %v", tc.apiKey, err, tc.wantAuth)
+			}
+		}
+		conn.Close()
+	}
+}
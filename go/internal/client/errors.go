@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Exit statuses of the examples, so scripts orchestrating them can branch on
+// the cause of a failure.
+const (
+	// ExitOK is a clean shutdown: the stream ended or was stopped with Ctrl+C.
+	ExitOK = 0
+	// ExitConnectionFailure means no endpoint could be connected to. Invalid
+	// configuration exits with the same status.
+	ExitConnectionFailure = 1
+	// ExitAuthFailure means the gateway rejected the API key.
+	ExitAuthFailure = 2
+	// ExitStreamError means a stream or call failed and was not retried.
+	ExitStreamError = 3
+	// ExitParseFailure means a message could not be parsed with
+	// -on-parse-error fatal.
+	ExitParseFailure = 4
+	// ExitShutdownTimeout means the summary wasn't printed within
+	// -shutdown-timeout of Ctrl+C or SIGTERM.
+	ExitShutdownTimeout = 5
+	// ExitForcedQuit means a second Ctrl+C or SIGTERM forced the exit, the
+	// shell's status for a process ended by SIGINT.
+	ExitForcedQuit = 130
+)
+
+// StreamError is the classification of an error that ended a stream or a
+// call: its gRPC status code, whether trying again can help and a message
+// for users.
+type StreamError struct {
+	Code codes.Code
+	// Retryable reports whether reconnecting or repeating the call may
+	// succeed
+	Retryable bool
+	// Auth reports whether the API key was rejected (Unauthenticated or
+	// PermissionDenied); auth errors are never retryable
+	Auth bool
+	// Goaway reports whether the server closed the connection on purpose,
+	// e.g. with an HTTP/2 GOAWAY to rebalance connections. It is routine, so
+	// the stream can be restarted right away.
+	Goaway bool
+	// Local reports whether the call ended because its own context expired
+	// or was cancelled rather than because of the server; see ClassifyCall
+	Local   bool
+	Message string
+	Err     error
+}
+
+func (e StreamError) Error() string {
+	return e.Message
+}
+
+func (e StreamError) Unwrap() error {
+	return e.Err
+}
+
+// Classify classifies err. Transient failures (Unavailable,
+// ResourceExhausted, Aborted, Internal, Unknown and idle streams, see
+// ErrIdleTimeout) are retryable; connections closed by the server with a
+// GOAWAY are flagged as such. Auth failures get an actionable message
+// instead of the raw status. Everything else, including DeadlineExceeded
+// and Canceled, means the request itself can't succeed or was given up on,
+// so it is not retried. A nil err classifies as codes.OK.
+func Classify(err error) StreamError {
+	if err == nil {
+		return StreamError{Code: codes.OK}
+	}
+
+	c := StreamError{Code: status.Code(err), Message: err.Error(), Err: err}
+	if errors.Is(err, ErrIdleTimeout) {
+		c.Retryable = true
+		return c
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return c
+	}
+
+	switch c.Code {
+	case codes.Unauthenticated:
+		c.Auth = true
+		c.Message = "API key rejected; check API_KEY (or -api-key)"
+	case codes.PermissionDenied:
+		c.Auth = true
+		c.Message = "API key rejected: it has no access to this endpoint; check API_KEY (or -api-key)"
+	case codes.Unavailable:
+		c.Retryable = true
+		c.Goaway = isGoaway(status.Convert(err).Message())
+	case codes.ResourceExhausted, codes.Aborted, codes.Internal, codes.Unknown:
+		c.Retryable = true
+	}
+	return c
+}
+
+// goawayMarkers are found in the messages of Unavailable errors caused by
+// the server closing the connection on purpose
+var goawayMarkers = []string{"goaway", "connection is draining", "connection closed"}
+
+// isGoaway reports whether message describes a connection the server closed
+// on purpose
+func isGoaway(message string) bool {
+	message = strings.ToLower(message)
+	for _, marker := range goawayMarkers {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifyCall classifies err like Classify, using the context the call
+// was made with to tell who ended it. DeadlineExceeded and Canceled look the
+// same whether they were caused locally or sent by the server, so:
+//
+//   - if ctx expired or was cancelled, the call was given up on locally: it
+//     is flagged Local and not retried;
+//   - otherwise the server (or a proxy in front of it) cancelled the call or
+//     applied its own deadline, which says nothing about the request, so it
+//     is retryable like any other transient failure.
+func ClassifyCall(ctx context.Context, err error) StreamError {
+	c := Classify(err)
+	if c.Code != codes.DeadlineExceeded && c.Code != codes.Canceled {
+		return c
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		c.Local = true
+		c.Message = "the call didn't complete before its local deadline"
+	case ctx.Err() != nil:
+		c.Local = true
+		c.Message = "the call was cancelled locally"
+	case c.Code == codes.Canceled:
+		c.Retryable = true
+		c.Message = "the server cancelled the call: " + status.Convert(err).Message()
+	default:
+		c.Retryable = true
+		c.Message = "the server's deadline for the call was exceeded: " + status.Convert(err).Message()
+	}
+	return c
+}
+
+// ClassifyError describes err for users and reports whether it is an
+// authentication failure. It is shorthand for the Message and Auth fields
+// of Classify; nil is described by an empty message.
+func ClassifyError(err error) (message string, auth bool) {
+	c := Classify(err)
+	return c.Message, c.Auth
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err       error
+		code      codes.Code
+		retryable bool
+		auth      bool
+	}{
+		{nil, codes.OK, false, false},
+		{status.Error(codes.Unavailable, "connection reset"), codes.Unavailable, true, false},
+		{status.Error(codes.ResourceExhausted, "too many streams"), codes.ResourceExhausted, true, false},
+		{status.Error(codes.Aborted, "aborted"), codes.Aborted, true, false},
+		{status.Error(codes.Internal, "RST_STREAM with error code 2"), codes.Internal, true, false},
+		{errors.New("connection closed"), codes.Unknown, true, false},
+		{fmt.Errorf("stream: %w", ErrIdleTimeout), codes.Unknown, true, false},
+		{status.Error(codes.Unauthenticated, "missing key"), codes.Unauthenticated, false, true},
+		{status.Error(codes.PermissionDenied, "no access"), codes.PermissionDenied, false, true},
+		{status.Error(codes.InvalidArgument, "bad timestamp"), codes.InvalidArgument, false, false},
+		{status.Error(codes.Unimplemented, "unknown method"), codes.Unimplemented, false, false},
+		{status.Error(codes.DeadlineExceeded, "deadline exceeded"), codes.DeadlineExceeded, false, false},
+		{status.Error(codes.Canceled, "context canceled"), codes.Canceled, false, false},
+	}
+	for _, tt := range tests {
+		got := Classify(tt.err)
+		if got.Code != tt.code || got.Retryable != tt.retryable || got.Auth != tt.auth {
+			t.Errorf("Classify(%v) = {code %v, retryable %v, auth %v}, want {%v, %v, %v}",
+				tt.err, got.Code, got.Retryable, got.Auth, tt.code, tt.retryable, tt.auth)
+		}
+		if tt.err != nil && !errors.Is(got, tt.err) {
+			t.Errorf("Classify(%v) doesn't wrap the original error", tt.err)
+		}
+	}
+}
+
+func TestClassifyCall(t *testing.T) {
+	live := context.Background()
+	cancelled, cancel := context.WithCancel(live)
+	cancel()
+	expired, cancel := context.WithTimeout(live, -time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		err       error
+		retryable bool
+		local     bool
+	}{
+		{"server cancel", live, status.Error(codes.Canceled, "server shutting down"), true, false},
+		{"server deadline", live, status.Error(codes.DeadlineExceeded, "upstream timeout"), true, false},
+		{"local deadline", expired, status.Error(codes.DeadlineExceeded, "context deadline exceeded"), false, true},
+		{"local cancel", cancelled, status.Error(codes.Canceled, "context canceled"), false, true},
+		// Other codes are classified as usual whatever the state of ctx
+		{"unavailable", expired, status.Error(codes.Unavailable, "connection reset"), true, false},
+		{"auth", live, status.Error(codes.Unauthenticated, "missing key"), false, false},
+	}
+	for _, tt := range tests {
+		got := ClassifyCall(tt.ctx, tt.err)
+		if got.Retryable != tt.retryable || got.Local != tt.local {
+			t.Errorf("%s: ClassifyCall = {retryable %v, local %v}, want {%v, %v}",
+				tt.name, got.Retryable, got.Local, tt.retryable, tt.local)
+		}
+		if got.Code != status.Code(tt.err) || !errors.Is(got, tt.err) {
+			t.Errorf("%s: ClassifyCall = {code %v, err %v}, want the original status", tt.name, got.Code, got.Err)
+		}
+	}
+
+	if got := ClassifyCall(live, status.Error(codes.Canceled, "server shutting down")); got.Message != "the server cancelled the call: server shutting down" {
+		t.Errorf("server cancel message = %q", got.Message)
+	}
+	if got := ClassifyCall(expired, status.Error(codes.DeadlineExceeded, "x")); got.Message != "the call didn't complete before its local deadline" {
+		t.Errorf("local deadline message = %q", got.Message)
+	}
+}
+
+func TestClassifyGoaway(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{status.Error(codes.Unavailable, "the connection is draining due to GOAWAY"), true},
+		{status.Error(codes.Unavailable, "error reading from server: EOF, received prior goaway: code: NO_ERROR"), true},
+		{status.Error(codes.Unavailable, "connection closed before server preface received"), true},
+		{status.Error(codes.Unavailable, "connection reset"), false},
+		{status.Error(codes.Internal, "GOAWAY"), false},
+	}
+	for _, tt := range tests {
+		got := Classify(tt.err)
+		if got.Goaway != tt.want || !got.Retryable {
+			t.Errorf("Classify(%v) = {goaway %v, retryable %v}, want {%v, true}", tt.err, got.Goaway, got.Retryable, tt.want)
+		}
+	}
+}
+
+func TestClassifyAuthMessageIsActionable(t *testing.T) {
+	message, auth := ClassifyError(status.Error(codes.Unauthenticated, "invalid API key"))
+	if !auth || message != "API key rejected; check API_KEY (or -api-key)" {
+		t.Errorf("ClassifyError = %q, %v", message, auth)
+	}
+
+	// Other errors keep their own message
+	err := status.Error(codes.Unavailable, "connection reset")
+	if message, auth := ClassifyError(err); auth || message != err.Error() {
+		t.Errorf("ClassifyError = %q, %v; want %q, false", message, auth, err.Error())
+	}
+}
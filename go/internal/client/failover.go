@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Failover connects to an ordered list of endpoints. The first reachable one
+// is used, and every reconnect moves on to the next endpoint in the list,
+// wrapping around after the last. Its methods are safe for concurrent use.
+type Failover struct {
+	dial func(endpoint string) (*grpc.ClientConn, error)
+
+	mu        sync.Mutex
+	endpoints []string
+	active    int
+	// replaced is set by SetEndpoints until the next Redial
+	replaced bool
+}
+
+// NewFailover returns a Failover over endpoints, in priority order, that
+// creates connections with dial.
+func NewFailover(endpoints []string, dial func(endpoint string) (*grpc.ClientConn, error)) *Failover {
+	return &Failover{endpoints: endpoints, dial: dial}
+}
+
+// Active returns the endpoint of the most recent connection.
+func (f *Failover) Active() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.endpoints[f.active]
+}
+
+// SetEndpoints replaces the endpoints, e.g. after a configuration reload. The
+// next Redial connects to the first of them.
+func (f *Failover) SetEndpoints(endpoints []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.endpoints = endpoints
+	f.active = 0
+	f.replaced = true
+}
+
+// Connect tries the endpoints in order and returns a connection to the first
+// one that becomes ready within timeout.
+func (f *Failover) Connect(ctx context.Context, timeout time.Duration) (*grpc.ClientConn, error) {
+	f.mu.Lock()
+	endpoints := f.endpoints
+	f.mu.Unlock()
+
+	var errs []error
+	for i, endpoint := range endpoints {
+		conn, err := f.dial(endpoint)
+		if err == nil {
+			if err = WaitForReady(ctx, conn, timeout); err == nil {
+				f.mu.Lock()
+				f.active = i
+				f.mu.Unlock()
+				return conn, nil
+			}
+			conn.Close()
+		}
+
+		slog.Warn("endpoint unavailable", "endpoint", endpoint, "err", err)
+		errs = append(errs, fmt.Errorf("%s: %w", endpoint, err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// Redial is a Redialer that fails over to the next endpoint, or connects to
+// the first one after SetEndpoints.
+func (f *Failover) Redial() (*grpc.ClientConn, error) {
+	f.mu.Lock()
+	switch {
+	case f.replaced:
+		f.replaced = false
+	case len(f.endpoints) > 1:
+		from := f.endpoints[f.active]
+		f.active = (f.active + 1) % len(f.endpoints)
+		slog.Warn("failing over to the next endpoint", "from", from, "to", f.endpoints[f.active])
+	}
+	endpoint := f.endpoints[f.active]
+	f.mu.Unlock()
+
+	return f.dial(endpoint)
+}
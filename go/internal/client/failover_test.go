@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/mockgateway"
+)
+
+func TestFailoverSkipsUnreachableEndpointsInOrder(t *testing.T) {
+	lis := mockgateway.Listen(&mockgateway.Server{})
+	t.Cleanup(lis.Close)
+
+	var dialed []string
+	failover := NewFailover([]string{"primary", "backup"}, func(endpoint string) (*grpc.ClientConn, error) {
+		dialed = append(dialed, endpoint)
+		if endpoint == "primary" {
+			return nil, errors.New("primary is down")
+		}
+		return Connect(mockgateway.Target, "", WithTLS(false), WithDialOptions(lis.DialOption()))
+	})
+
+	conn, err := failover.Connect(context.Background(), time.Second)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	conn.Close()
+	if active := failover.Active(); active != "backup" {
+		t.Errorf("active endpoint = %s, want backup", active)
+	}
+
+	// A reconnect wraps around to the highest priority endpoint
+	if _, err := failover.Redial(); err == nil {
+		t.Error("Redial to primary succeeded, want its dial error")
+	}
+	if active := failover.Active(); active != "primary" {
+		t.Errorf("active endpoint after Redial = %s, want primary", active)
+	}
+
+	if want := []string{"primary", "backup", "primary"}; len(dialed) != len(want) || dialed[0] != want[0] || dialed[1] != want[1] || dialed[2] != want[2] {
+		t.Errorf("dialed %v, want %v", dialed, want)
+	}
+}
+
+func TestFailoverSetEndpointsRedialsFirstNewEndpoint(t *testing.T) {
+	var dialed []string
+	failover := NewFailover([]string{"old-primary", "old-backup"}, func(endpoint string) (*grpc.ClientConn, error) {
+		dialed = append(dialed, endpoint)
+		return nil, errors.New("down")
+	})
+
+	failover.SetEndpoints([]string{"new-primary", "new-backup"})
+	failover.Redial()
+	failover.Redial()
+
+	if want := []string{"new-primary", "new-backup"}; len(dialed) != 2 || dialed[0] != want[0] || dialed[1] != want[1] {
+		t.Errorf("dialed %v, want %v", dialed, want)
+	}
+	if active := failover.Active(); active != "new-backup" {
+		t.Errorf("active endpoint = %s, want new-backup", active)
+	}
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/model"
+	"github.com/dwellir/grpc-code-examples/go/internal/orderbook"
+)
+
+// BlockFills is a streamed block fills message together with its decoded
+// form.
+type BlockFills struct {
+	// Data is the raw JSON message as received.
+	Data []byte
+	// Decoded holds the typed fills, nil when DecodeErr is set.
+	Decoded *model.BlockFills
+	// DecodeErr reports a message that could not be decoded. The stream
+	// continues after such messages.
+	DecodeErr error
+}
+
+// OrderBookSnapshot is an orderbook snapshot together with its parsed
+// ladders.
+type OrderBookSnapshot struct {
+	// Data is the raw JSON snapshot as received.
+	Data []byte
+	// Ladders holds the bids and asks, nil when LaddersErr is set.
+	Ladders *orderbook.Ladders
+	// LaddersErr reports levels that aren't [bids, asks] ladders.
+	LaddersErr error
+}
+
+// Gateway is a connection to a Hyperliquid gateway with typed methods for
+// each call, so programs don't have to deal with the generated client,
+// request messages or JSON decoding. Its methods are safe for concurrent
+// use.
+type Gateway struct {
+	conn   *grpc.ClientConn
+	client pb.HyperLiquidL1GatewayClient
+	// redial is nil for gateways wrapping a caller's connection, whose
+	// streams then don't reconnect
+	redial Redialer
+}
+
+// Dial connects to endpoint like Connect, with the same options for TLS,
+// message size and metadata, and returns a Gateway whose streams reconnect
+// with fresh connections to endpoint (see StreamWithReconnect).
+func Dial(endpoint, apiKey string, opts ...Option) (*Gateway, error) {
+	conn, err := Connect(endpoint, apiKey, opts...)
+	if err != nil {
+		return nil, err
+	}
+	g := NewGateway(conn)
+	g.redial = func() (*grpc.ClientConn, error) {
+		return Connect(endpoint, apiKey, opts...)
+	}
+	return g, nil
+}
+
+// NewGateway wraps an existing connection. Its streams don't reconnect, and
+// Close closes conn.
+func NewGateway(conn *grpc.ClientConn) *Gateway {
+	return &Gateway{conn: conn, client: NewGatewayClient(conn)}
+}
+
+// Conn returns the underlying connection.
+func (g *Gateway) Conn() *grpc.ClientConn {
+	return g.conn
+}
+
+// Blocks streams decoded blocks starting at from, a Unix time in
+// milliseconds or 0 for the latest block. As with StreamBlocks, the block
+// channel is closed when the stream ends or ctx is cancelled, and the error
+// channel then yields the error that ended it, if any.
+func (g *Gateway) Blocks(ctx context.Context, from int64, opts ...StreamOption) (<-chan *Block, <-chan error) {
+	request := &pb.Timestamp{Timestamp: from}
+	if g.redial == nil {
+		return StreamBlocks(ctx, g.client, request, opts...)
+	}
+	return StreamBlocksWithReconnect(ctx, g.conn, g.redial, request, opts...)
+}
+
+// BlockFills streams decoded block fills starting at from, a Unix time in
+// milliseconds or 0 for the latest block, with the same channel semantics
+// as Blocks.
+func (g *Gateway) BlockFills(ctx context.Context, from int64, opts ...StreamOption) (<-chan *BlockFills, <-chan error) {
+	request := &pb.Timestamp{Timestamp: from}
+	fills := make(chan *BlockFills)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(fills)
+
+		handle := func(msg *pb.BlockFills) {
+			blockFills := &BlockFills{Data: msg.Data}
+			blockFills.Decoded, blockFills.DecodeErr = model.DecodeBlockFills(msg.Data)
+			fills <- blockFills
+		}
+		var err error
+		if g.redial == nil {
+			err = Stream(ctx, g.conn, pb.HyperLiquidL1GatewayClient.StreamBlockFills, request, handle, opts...)
+		} else {
+			err = StreamWithReconnect(ctx, g.conn, g.redial, pb.HyperLiquidL1GatewayClient.StreamBlockFills, request, handle, opts...)
+		}
+		// A cancelled ctx is how callers stop the stream, not a failure
+		if err != nil && ctx.Err() == nil {
+			errs <- err
+		}
+	}()
+
+	return fills, errs
+}
+
+// OrderBook fetches the orderbook snapshot at at, a Unix time in
+// milliseconds or 0 for the latest one. Call errors are returned as is, see
+// Classify; levels that can't be parsed are reported in LaddersErr instead.
+func (g *Gateway) OrderBook(ctx context.Context, at int64, opts ...grpc.CallOption) (*OrderBookSnapshot, error) {
+	response, err := g.client.GetOrderBookSnapshot(ctx, &pb.Timestamp{Timestamp: at}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &OrderBookSnapshot{Data: response.Data}
+	snapshot.Ladders, snapshot.LaddersErr = orderbook.ParseSnapshot(response.Data)
+	return snapshot, nil
+}
+
+// Close closes the connection.
+func (g *Gateway) Close() error {
+	return g.conn.Close()
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/mockgateway"
+)
+
+// dialGateway serves server in memory and returns a Gateway connected to it
+func dialGateway(t *testing.T, server *mockgateway.Server) *Gateway {
+	t.Helper()
+
+	lis := mockgateway.Listen(server)
+	t.Cleanup(lis.Close)
+
+	g, err := Dial(mockgateway.Target, "", WithTLS(false), WithDialOptions(lis.DialOption()))
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { g.Close() })
+	return g
+}
+
+func TestGatewayBlocks(t *testing.T) {
+	g := dialGateway(t, &mockgateway.Server{Blocks: cannedBlocks(3)})
+
+	blocks, errs := g.Blocks(context.Background(), 0)
+	var want int64 = 1
+	for block := range blocks {
+		if block.DecodeErr != nil {
+			t.Fatalf("block %d: %v", want, block.DecodeErr)
+		}
+		if got := block.Decoded.ABCIBlock.Height; got != want {
+			t.Errorf("height = %d, want %d", got, want)
+		}
+		want++
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Blocks: %v", err)
+	}
+	if want != 4 {
+		t.Errorf("received %d blocks, want 3", want-1)
+	}
+}
+
+func TestGatewayBlockFills(t *testing.T) {
+	g := dialGateway(t, &mockgateway.Server{
+		BlockFills: []*pb.BlockFills{
+			{Data: []byte(`{"height":7,"time":1760426567000,"fills":[]}`)},
+			{Data: []byte("not json")},
+		},
+	})
+
+	fills, errs := g.BlockFills(context.Background(), 0)
+	var got []*BlockFills
+	for blockFills := range fills {
+		got = append(got, blockFills)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("BlockFills: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("received %d messages, want 2", len(got))
+	}
+	if got[0].DecodeErr != nil || got[0].Decoded.Height != 7 {
+		t.Errorf("first message = %+v, want height 7", got[0])
+	}
+	if got[1].DecodeErr == nil {
+		t.Error("second message decoded, want a decode error")
+	}
+}
+
+func TestGatewayOrderBook(t *testing.T) {
+	g := dialGateway(t, &mockgateway.Server{
+		Snapshot: &pb.OrderBookSnapshot{Data: []byte(`{"levels":[[{"px":"100","sz":"1","n":1}],[{"px":"101","sz":"2","n":1}]]}`)},
+	})
+
+	snapshot, err := g.OrderBook(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("OrderBook: %v", err)
+	}
+	if snapshot.LaddersErr != nil {
+		t.Fatalf("LaddersErr = %v", snapshot.LaddersErr)
+	}
+	if spread, ok := snapshot.Ladders.Spread(); !ok || spread.FloatString(0) != "1" {
+		t.Errorf("spread = %v, %v; want 1", spread, ok)
+	}
+}
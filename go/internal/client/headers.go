@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// reservedHeaders are set by gRPC or by Connect itself and can't be sent as
+// extra metadata
+var reservedHeaders = map[string]string{
+	"x-api-key":    "use -api-key",
+	"content-type": "set by gRPC",
+	"te":           "set by gRPC",
+	"host":         "set by gRPC from the endpoint",
+}
+
+// userAgentHeader is sent through grpc.WithUserAgent rather than as
+// metadata, which gRPC would drop
+const userAgentHeader = "user-agent"
+
+// Header is an extra metadata entry sent on every call, e.g. a tenant or
+// request id demanded by a gateway in front of the endpoint.
+type Header struct {
+	Key   string
+	Value string
+}
+
+// ParseHeader parses a "key=value" header. Keys are case-insensitive and
+// returned in lower case; they may contain letters, digits, '-', '_' and
+// '.'. Values must be printable ASCII unless the key ends in "-bin".
+// Headers reserved by gRPC (grpc-*, content-type, te, host) and x-api-key
+// are rejected.
+func ParseHeader(s string) (Header, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return Header{}, fmt.Errorf("header %q: expected key=value", s)
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+	if key == "" {
+		return Header{}, fmt.Errorf("header %q: empty key", s)
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return Header{}, fmt.Errorf("header %q: invalid character %q in key", s, r)
+		}
+	}
+	if reason, ok := reservedHeaders[key]; ok {
+		return Header{}, fmt.Errorf("header %q: %s is reserved (%s)", s, key, reason)
+	}
+	if strings.HasPrefix(key, "grpc-") {
+		return Header{}, fmt.Errorf("header %q: grpc-* headers are reserved for gRPC", s)
+	}
+	if !strings.HasSuffix(key, "-bin") {
+		for _, r := range value {
+			if r < 0x20 || r > 0x7e {
+				return Header{}, fmt.Errorf("header %q: value must be printable ASCII (use a -bin key for binary values)", s)
+			}
+		}
+	}
+	return Header{Key: key, Value: value}, nil
+}
+
+// WithHeaders sends headers as metadata on every call made on the
+// connection, alongside x-api-key. A user-agent header is prepended to
+// gRPC's own user agent instead.
+func WithHeaders(headers ...Header) Option {
+	return func(o *options) {
+		o.headers = append(o.headers, headers...)
+	}
+}
+
+// headerDialOptions returns the dial options sending headers
+func headerDialOptions(headers []Header) []grpc.DialOption {
+	var opts []grpc.DialOption
+	var pairs []string
+	for _, header := range headers {
+		if header.Key == userAgentHeader {
+			opts = append(opts, grpc.WithUserAgent(header.Value))
+			continue
+		}
+		pairs = append(pairs, header.Key, header.Value)
+	}
+	if len(pairs) == 0 {
+		return opts
+	}
+
+	unary := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		return invoker(metadata.AppendToOutgoingContext(ctx, pairs...), method, req, reply, cc, callOpts...)
+	}
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(metadata.AppendToOutgoingContext(ctx, pairs...), desc, cc, method, callOpts...)
+	}
+	return append(opts, grpc.WithChainUnaryInterceptor(unary), grpc.WithChainStreamInterceptor(stream))
+}
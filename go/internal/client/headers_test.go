@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/mockgateway"
+)
+
+func TestParseHeader(t *testing.T) {
+	valid := []struct {
+		input string
+		want  Header
+	}{
+		{"x-tenant-id=acme", Header{"x-tenant-id", "acme"}},
+		{"X-Request-ID=abc=123", Header{"x-request-id", "abc=123"}},
+		{"user-agent=my-bot/1.0", Header{"user-agent", "my-bot/1.0"}},
+		{"x-empty=", Header{"x-empty", ""}},
+		{"trace-bin=\x00\x01", Header{"trace-bin", "\x00\x01"}},
+	}
+	for _, tt := range valid {
+		got, err := ParseHeader(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseHeader(%q) = %v, %v, want %v", tt.input, got, err, tt.want)
+		}
+	}
+
+	invalid := []string{
+		"x-tenant-id",
+		"=acme",
+		"x tenant=acme",
+		":authority=example.com",
+		"x-api-key=secret",
+		"Content-Type=text/plain",
+		"grpc-timeout=1S",
+		"host=example.com",
+		"x-tenant-id=café",
+		"x-tenant-id=a\nb",
+	}
+	for _, input := range invalid {
+		if got, err := ParseHeader(input); err == nil {
+			t.Errorf("ParseHeader(%q) = %v, want an error", input, got)
+		}
+	}
+}
+
+func TestConnectSendsHeadersOnEveryCall(t *testing.T) {
+	server := &mockgateway.Server{
+		APIKey:   "secret",
+		Snapshot: &pb.OrderBookSnapshot{Data: []byte(`{}`)},
+	}
+	lis := mockgateway.Listen(server)
+	t.Cleanup(lis.Close)
+
+	conn, err := Connect(mockgateway.Target, "secret", WithTLS(false), WithDialOptions(lis.DialOption()),
+		WithHeaders(Header{"x-tenant-id", "acme"}, Header{"x-tenant-id", "beta"}, Header{"user-agent", "my-bot/1.0"}))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	// A unary call and a stream, to check both interceptors
+	if _, err := NewGatewayClient(conn).GetOrderBookSnapshot(context.Background(), &pb.Timestamp{}); err != nil {
+		t.Fatalf("GetOrderBookSnapshot: %v", err)
+	}
+	checkHeaders(t, server)
+
+	stream, err := NewGatewayClient(conn).StreamBlocks(context.Background(), &pb.Timestamp{})
+	if err != nil {
+		t.Fatalf("StreamBlocks: %v", err)
+	}
+	for {
+		if _, err := stream.Recv(); err != nil {
+			break
+		}
+	}
+	checkHeaders(t, server)
+}
+
+func checkHeaders(t *testing.T, server *mockgateway.Server) {
+	t.Helper()
+	md := server.Metadata()
+	if got := md.Get("x-tenant-id"); len(got) != 2 || got[0] != "acme" || got[1] != "beta" {
+		t.Errorf("x-tenant-id = %q, want [acme beta]", got)
+	}
+	if got := md.Get("x-api-key"); len(got) != 1 || got[0] != "secret" {
+		t.Errorf("x-api-key = %q, want [secret]", got)
+	}
+	if got := md.Get("user-agent"); len(got) != 1 || !strings.HasPrefix(got[0], "my-bot/1.0 ") {
+		t.Errorf("user-agent = %q, want it to start with my-bot/1.0", got)
+	}
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+)
+
+// ErrProbeEnded is returned by Probe when the block stream ends before its
+// first message.
+var ErrProbeEnded = errors.New("block stream ended before the first block")
+
+// Probe checks that the gateway answers calls on conn by opening the block
+// stream at the latest block and waiting up to timeout for its first
+// message, which it returns the delay of. A connection reaching READY only
+// proves the transport: a rejected API key surfaces on the first call, as an
+// error ClassifyError reports as an authentication failure.
+func Probe(ctx context.Context, conn *grpc.ClientConn, timeout time.Duration) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	stream, err := NewGatewayClient(conn).StreamBlocks(ctx, &pb.Timestamp{Timestamp: 0})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := stream.Recv(); err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, ErrProbeEnded
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return 0, fmt.Errorf("no block received within %v", timeout)
+		}
+		return 0, err
+	}
+	return time.Since(start), nil
+}
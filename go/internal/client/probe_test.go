@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/mockgateway"
+)
+
+func TestProbe(t *testing.T) {
+	server := &mockgateway.Server{Blocks: cannedBlocks(1), StreamErrors: []error{mockgateway.ErrStall}}
+	conn, ctx, _ := startGateway(t, server)
+
+	if _, err := Probe(ctx, conn, time.Second); err != nil {
+		t.Errorf("Probe: %v", err)
+	}
+}
+
+func TestProbeReportsRejectedAPIKey(t *testing.T) {
+	server := &mockgateway.Server{APIKey: "secret", Blocks: cannedBlocks(1)}
+	lis := mockgateway.Listen(server)
+	t.Cleanup(lis.Close)
+
+	conn, err := Connect(mockgateway.Target, "wrong", WithTLS(false), WithDialOptions(lis.DialOption()))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	_, err = Probe(context.Background(), conn, time.Second)
+	if _, auth := ClassifyError(err); !auth {
+		t.Errorf("Probe error = %v, want an authentication failure", err)
+	}
+}
+
+func TestProbeFailures(t *testing.T) {
+	for name, tc := range map[string]struct {
+		streamErr error
+		check     func(error) bool
+	}{
+		"stream error": {status.Error(codes.Unavailable, "overloaded"), func(err error) bool { return status.Code(err) == codes.Unavailable }},
+		"ended":        {nil, func(err error) bool { return errors.Is(err, ErrProbeEnded) }},
+		"no block":     {mockgateway.ErrStall, func(err error) bool { return err != nil && strings.Contains(err.Error(), "no block received") }},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := &mockgateway.Server{StreamErrors: []error{tc.streamErr}}
+			conn, ctx, _ := startGateway(t, server)
+
+			if _, err := Probe(ctx, conn, 100*time.Millisecond); !tc.check(err) {
+				t.Errorf("Probe error = %v", err)
+			}
+		})
+	}
+}
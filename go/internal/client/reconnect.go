@@ -0,0 +1,311 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/stats"
+)
+
+// Reconnect backoff bounds, variables so tests can shorten them
+var (
+	initialBackoff = 1 * time.Second
+	maxBackoff     = 30 * time.Second
+)
+
+// drainTimeout bounds how long a stream keeps receiving after ctx is
+// cancelled, so a quiet stream doesn't hold up shutdown until the next
+// message or the idle timeout. A variable so tests can shorten it.
+var drainTimeout = 2 * time.Second
+
+// errDrainTimeout cancels a stream that received nothing within drainTimeout
+// of ctx being cancelled
+var errDrainTimeout = errors.New("nothing received while draining")
+
+// StreamFunc opens a server stream on the gateway. Method expressions such as
+// pb.HyperLiquidL1GatewayClient.StreamBlocks satisfy it.
+type StreamFunc[T any] func(pb.HyperLiquidL1GatewayClient, context.Context, *pb.Timestamp, ...grpc.CallOption) (grpc.ServerStreamingClient[T], error)
+
+// Redialer creates a fresh connection before each reconnect attempt.
+type Redialer func() (*grpc.ClientConn, error)
+
+// ErrIdleTimeout is returned for a stream that was cancelled because no
+// message arrived within the idle timeout.
+var ErrIdleTimeout = errors.New("no message received within the idle timeout")
+
+// ErrRestartRequested is returned by Stream for a stream ended through
+// WithRestart.
+var ErrRestartRequested = errors.New("stream restart requested")
+
+// StreamOption configures StreamWithReconnect and the block streams.
+type StreamOption func(*streamOptions)
+
+type streamOptions struct {
+	idleTimeout   time.Duration
+	decodeWorkers int
+	breaker       Breaker
+	restart       <-chan struct{}
+	dedup         *stats.Dedup[stats.BlockKey]
+}
+
+func newStreamOptions(opts []StreamOption) streamOptions {
+	var o streamOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithIdleTimeout restarts the stream when no message arrives within timeout,
+// which catches servers that stop sending without closing the stream. The
+// timer is reset on every message and paused while it is handled. A zero
+// timeout disables the watchdog.
+func WithIdleTimeout(timeout time.Duration) StreamOption {
+	return func(o *streamOptions) {
+		o.idleTimeout = timeout
+	}
+}
+
+// WithRestart ends the current stream whenever a value is received on
+// restart, e.g. after the endpoint or API key was reloaded. StreamWithReconnect
+// then redials right away, without backoff and without counting a breaker
+// failure, and restarts the stream on the new connection; Stream, which
+// doesn't reconnect, returns ErrRestartRequested.
+func WithRestart(restart <-chan struct{}) StreamOption {
+	return func(o *streamOptions) {
+		o.restart = restart
+	}
+}
+
+// Stream opens a single stream on conn and passes every message to handle,
+// without reconnecting. It returns nil when the server ends the stream or ctx
+// is cancelled, and the error that ended the stream otherwise; an idle stream
+// fails with ErrIdleTimeout (see WithIdleTimeout). As with
+// StreamWithReconnect, cancelling ctx drains the message being received.
+func Stream[T any](ctx context.Context, conn *grpc.ClientConn, open StreamFunc[T], request *pb.Timestamp, handle func(*T), opts ...StreamOption) error {
+	o := newStreamOptions(opts)
+
+	streamCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	return receive(ctx, streamCtx, NewGatewayClient(conn), open, request, o, handle)
+}
+
+// StreamWithReconnect opens a stream on conn and passes every message to
+// handle. When the stream fails or goes idle (see WithIdleTimeout) it
+// re-dials and restarts the stream with exponential backoff (1s doubling up
+// to 30s, reset after each received message). A connection the server closed
+// with a GOAWAY is routine: the stream is restarted right away on the same
+// connection, without backoff, unless the previous restart was for a GOAWAY
+// too and received nothing. It returns nil when the server ends the stream or
+// ctx is cancelled, and the error without reconnecting when it isn't
+// retryable, such as a rejected API key (see ClassifyCall). A stream the
+// server cancelled, or ended with its own deadline, is reconnected. Connections created
+// by redial are closed before returning; conn itself remains owned by the
+// caller.
+//
+// Cancelling ctx drains rather than aborts: a message that is being received
+// is still handled before StreamWithReconnect returns. The stream itself is
+// only cancelled when nothing arrives within a short grace period (2s), so
+// stopping a quiet stream doesn't wait for its next message.
+func StreamWithReconnect[T any](ctx context.Context, conn *grpc.ClientConn, redial Redialer, open StreamFunc[T], request *pb.Timestamp, handle func(*T), opts ...StreamOption) error {
+	o := newStreamOptions(opts)
+
+	streamCtx, cancelStreams := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelStreams()
+
+	current := conn
+	defer func() {
+		if current != conn {
+			current.Close()
+		}
+	}()
+
+	backoff := initialBackoff
+	attempt := 0
+	// goawayRestart is set while a stream restarted after a GOAWAY hasn't
+	// received anything, so repeated GOAWAYs fall back to the backoff
+	goawayRestart := false
+	breaker := newCircuitBreaker(o.breaker)
+
+	for {
+		err := receive(ctx, streamCtx, NewGatewayClient(current), open, request, o, func(msg *T) {
+			backoff = initialBackoff
+			attempt = 0
+			goawayRestart = false
+			breaker.Success()
+			handle(msg)
+		})
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrRestartRequested) {
+			// A requested restart isn't a failure, so it skips the backoff
+			slog.Info("restarting the stream on a new connection")
+			next, redialErr := redial()
+			if redialErr == nil {
+				if current != conn {
+					current.Close()
+				}
+				current = next
+				continue
+			}
+			// Retried below like any other failed reconnect
+			slog.Error("reconnect failed", "err", redialErr)
+			err = redialErr
+		} else {
+			classified := ClassifyCall(ctx, err)
+			// Reconnecting can't fix a rejected API key or an invalid request
+			if !classified.Retryable {
+				return err
+			}
+
+			// The connection re-establishes itself after a GOAWAY, so only the
+			// stream needs restarting
+			if classified.Goaway && !goawayRestart {
+				slog.Info("server closed the connection (GOAWAY), restarting the stream", "err", err)
+				goawayRestart = true
+				continue
+			}
+
+			switch {
+			case errors.Is(err, ErrIdleTimeout):
+				slog.Warn("stream stalled, restarting it", "idle", o.idleTimeout)
+			case classified.Code == codes.Canceled || classified.Code == codes.DeadlineExceeded:
+				// ctx is still live, so the server ended the stream
+				slog.Warn("server ended the stream, reconnecting", "code", classified.Code, "reason", classified.Message)
+			default:
+				slog.Error("stream error", "err", err)
+			}
+		}
+
+		// Keep re-dialing until a connection is created or ctx is cancelled
+		for {
+			if breaker.Failure(time.Now()) {
+				if breaker.ExitWhenOpen {
+					return fmt.Errorf("%w: %w", ErrCircuitOpen, err)
+				}
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(breaker.Cooldown):
+				}
+				breaker.HalfOpen()
+				backoff = initialBackoff
+			}
+
+			attempt++
+			slog.Info("reconnecting", "in", backoff, "attempt", attempt)
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, maxBackoff)
+
+			if current != conn {
+				current.Close()
+				current = conn
+			}
+			next, redialErr := redial()
+			if redialErr != nil {
+				slog.Error("reconnect failed", "err", redialErr)
+				err = redialErr
+				continue
+			}
+			current = next
+			break
+		}
+	}
+}
+
+// receive runs a single stream on streamCtx until it ends or, after handling
+// a message, ctx turns out to be cancelled; drainTimeout after ctx is
+// cancelled the stream is cancelled too. It returns nil in these cases,
+// ErrIdleTimeout when o.idleTimeout (if non-zero) passes without a message
+// and ErrRestartRequested when o.restart fires.
+func receive[T any](ctx, streamCtx context.Context, gateway pb.HyperLiquidL1GatewayClient, open StreamFunc[T], request *pb.Timestamp, o streamOptions, handle func(*T)) error {
+	idleTimeout := o.idleTimeout
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	streamCtx, cancel := context.WithCancelCause(streamCtx)
+	defer cancel(nil)
+
+	// The watchdog cancels the stream so that a blocked Recv returns
+	var watchdog *time.Timer
+	if idleTimeout > 0 {
+		watchdog = time.AfterFunc(idleTimeout, func() { cancel(ErrIdleTimeout) })
+		defer watchdog.Stop()
+	}
+	if o.restart != nil {
+		go func() {
+			select {
+			case <-o.restart:
+				cancel(ErrRestartRequested)
+			case <-streamCtx.Done():
+			}
+		}()
+	}
+	// Bounds the drain of a stream that goes quiet after ctx is cancelled
+	grace := drainTimeout
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-streamCtx.Done():
+			return
+		}
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancel(errDrainTimeout)
+		case <-streamCtx.Done():
+		}
+	}()
+
+	stream, err := open(gateway, streamCtx, request)
+	if err != nil {
+		return err
+	}
+
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			cause := context.Cause(streamCtx)
+			if errors.Is(cause, errDrainTimeout) {
+				return nil
+			}
+			if errors.Is(cause, ErrIdleTimeout) || errors.Is(cause, ErrRestartRequested) {
+				return cause
+			}
+			return err
+		}
+
+		// Time spent handling a message doesn't count as idle
+		if watchdog != nil {
+			watchdog.Stop()
+		}
+		handle(msg)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+		if watchdog != nil {
+			watchdog.Reset(idleTimeout)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/mockgateway"
+)
+
+func cannedBlocks(n int) []*pb.Block {
+	blocks := make([]*pb.Block, n)
+	for i := range blocks {
+		blocks[i] = &pb.Block{Data: []byte(fmt.Sprintf(`{"abci_block":{"height":%d}}`, i+1))}
+	}
+	return blocks
+}
+
+// startGateway serves server in memory and returns a connection to it plus a
+// redialer producing further connections
+func startGateway(t *testing.T, server *mockgateway.Server) (*grpc.ClientConn, context.Context, Redialer) {
+	t.Helper()
+
+	lis := mockgateway.Listen(server)
+	t.Cleanup(lis.Close)
+
+	redial := func() (*grpc.ClientConn, error) {
+		return Connect(mockgateway.Target, "", WithTLS(false), WithDialOptions(lis.DialOption()))
+	}
+	conn, err := redial()
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, context.Background(), redial
+}
+
+func TestStreamReturnsErrorWithoutReconnecting(t *testing.T) {
+	server := &mockgateway.Server{
+		BlockFills:   []*pb.BlockFills{{Data: []byte(`{"height":1}`)}, {Data: []byte(`{"height":2}`)}},
+		StreamErrors: []error{status.Error(codes.Unavailable, "connection reset")},
+	}
+	conn, ctx, _ := startGateway(t, server)
+
+	received := 0
+	err := Stream(ctx, conn, pb.HyperLiquidL1GatewayClient.StreamBlockFills, &pb.Timestamp{}, func(*pb.BlockFills) {
+		received++
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Stream error = %v, want Unavailable", err)
+	}
+	if received != 2 {
+		t.Errorf("received %d block fills, want 2", received)
+	}
+	if calls := server.Calls(); calls != 1 {
+		t.Errorf("server saw %d streams, want 1", calls)
+	}
+}
+
+func TestStreamWithReconnectReceivesAllBlocks(t *testing.T) {
+	server := &mockgateway.Server{Blocks: cannedBlocks(3)}
+	conn, ctx, redial := startGateway(t, server)
+
+	var got []string
+	err := StreamWithReconnect(ctx, conn, redial, pb.HyperLiquidL1GatewayClient.StreamBlocks, &pb.Timestamp{}, func(block *pb.Block) {
+		got = append(got, string(block.Data))
+	})
+	if err != nil {
+		t.Fatalf("StreamWithReconnect: %v", err)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("received %d blocks, want 3", len(got))
+	}
+	for i, data := range got {
+		if want := string(server.Blocks[i].Data); data != want {
+			t.Errorf("block %d = %s, want %s", i, data, want)
+		}
+	}
+}
+
+func TestStreamWithReconnectResumesAfterError(t *testing.T) {
+	defer func(initial time.Duration) { initialBackoff = initial }(initialBackoff)
+	initialBackoff = 10 * time.Millisecond
+
+	server := &mockgateway.Server{
+		Blocks:       cannedBlocks(2),
+		StreamErrors: []error{status.Error(codes.Unavailable, "connection reset")},
+	}
+	conn, ctx, redial := startGateway(t, server)
+
+	received := 0
+	err := StreamWithReconnect(ctx, conn, redial, pb.HyperLiquidL1GatewayClient.StreamBlocks, &pb.Timestamp{}, func(*pb.Block) {
+		received++
+	})
+	if err != nil {
+		t.Fatalf("StreamWithReconnect: %v", err)
+	}
+
+	if received != 4 {
+		t.Errorf("received %d blocks, want 4 across both streams", received)
+	}
+	if calls := server.Calls(); calls != 2 {
+		t.Errorf("server saw %d streams, want 2", calls)
+	}
+}
+
+func TestStreamWithReconnectDrainsOnCancel(t *testing.T) {
+	server := &mockgateway.Server{Blocks: cannedBlocks(5)}
+	conn, ctx, redial := startGateway(t, server)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	received := 0
+	err := StreamWithReconnect(ctx, conn, redial, pb.HyperLiquidL1GatewayClient.StreamBlocks, &pb.Timestamp{}, func(*pb.Block) {
+		received++
+		cancel()
+	})
+	if err != nil {
+		t.Fatalf("StreamWithReconnect: %v", err)
+	}
+
+	if received != 1 {
+		t.Errorf("received %d blocks after cancel, want 1", received)
+	}
+}
+
+func TestStreamWithReconnectStopsQuietStreamAfterCancel(t *testing.T) {
+	defer func(timeout time.Duration) { drainTimeout = timeout }(drainTimeout)
+	drainTimeout = 50 * time.Millisecond
+
+	server := &mockgateway.Server{
+		Blocks:       cannedBlocks(1),
+		StreamErrors: []error{mockgateway.ErrStall},
+	}
+	conn, ctx, redial := startGateway(t, server)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The stream stalls after its only block, without an idle timeout to end it
+	received := 0
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	err := StreamWithReconnect(ctx, conn, redial, pb.HyperLiquidL1GatewayClient.StreamBlocks, &pb.Timestamp{}, func(*pb.Block) {
+		received++
+	})
+	if err != nil {
+		t.Fatalf("StreamWithReconnect: %v", err)
+	}
+
+	if received != 1 {
+		t.Errorf("received %d blocks, want 1", received)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("drain took %v after cancel", elapsed)
+	}
+}
+
+func TestStreamWithReconnectRestartsIdleStream(t *testing.T) {
+	defer func(initial time.Duration) { initialBackoff = initial }(initialBackoff)
+	initialBackoff = 10 * time.Millisecond
+
+	server := &mockgateway.Server{
+		Blocks:       cannedBlocks(1),
+		StreamErrors: []error{mockgateway.ErrStall},
+	}
+	conn, ctx, redial := startGateway(t, server)
+
+	received := 0
+	err := StreamWithReconnect(ctx, conn, redial, pb.HyperLiquidL1GatewayClient.StreamBlocks, &pb.Timestamp{}, func(*pb.Block) {
+		received++
+	}, WithIdleTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("StreamWithReconnect: %v", err)
+	}
+
+	if received != 2 {
+		t.Errorf("received %d blocks, want 2 across both streams", received)
+	}
+	if calls := server.Calls(); calls != 2 {
+		t.Errorf("server saw %d streams, want 2", calls)
+	}
+}
+
+func TestStreamWithReconnectStopsOnAuthError(t *testing.T) {
+	server := &mockgateway.Server{
+		StreamErrors: []error{status.Error(codes.Unauthenticated, "invalid api key")},
+	}
+	conn, ctx, redial := startGateway(t, server)
+
+	err := StreamWithReconnect(ctx, conn, redial, pb.HyperLiquidL1GatewayClient.StreamBlocks, &pb.Timestamp{}, func(*pb.Block) {})
+	if _, auth := ClassifyError(err); !auth {
+		t.Fatalf("StreamWithReconnect error = %v, want an auth failure", err)
+	}
+	if calls := server.Calls(); calls != 1 {
+		t.Errorf("server saw %d streams, want 1 (no reconnect)", calls)
+	}
+}
+
+func TestStreamWithReconnectResumesAfterServerCancel(t *testing.T) {
+	defer func(initial time.Duration) { initialBackoff = initial }(initialBackoff)
+	initialBackoff = 10 * time.Millisecond
+
+	server := &mockgateway.Server{
+		Blocks:       cannedBlocks(1),
+		StreamErrors: []error{status.Error(codes.Canceled, "server shutting down")},
+	}
+	conn, ctx, redial := startGateway(t, server)
+
+	received := 0
+	err := StreamWithReconnect(ctx, conn, redial, pb.HyperLiquidL1GatewayClient.StreamBlocks, &pb.Timestamp{}, func(*pb.Block) {
+		received++
+	})
+	if err != nil {
+		t.Fatalf("StreamWithReconnect: %v", err)
+	}
+	if received != 2 {
+		t.Errorf("received %d blocks, want 2 across both streams", received)
+	}
+	if calls := server.Calls(); calls != 2 {
+		t.Errorf("server saw %d streams, want 2", calls)
+	}
+}
+
+func TestStreamWithReconnectStopsOnNonRetryableError(t *testing.T) {
+	server := &mockgateway.Server{
+		StreamErrors: []error{status.Error(codes.InvalidArgument, "timestamp in the future")},
+	}
+	conn, ctx, redial := startGateway(t, server)
+
+	err := StreamWithReconnect(ctx, conn, redial, pb.HyperLiquidL1GatewayClient.StreamBlocks, &pb.Timestamp{}, func(*pb.Block) {})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("StreamWithReconnect error = %v, want InvalidArgument", err)
+	}
+	if calls := server.Calls(); calls != 1 {
+		t.Errorf("server saw %d streams, want 1 (no reconnect)", calls)
+	}
+}
+
+func TestStreamWithReconnectRestartsImmediatelyAfterGoaway(t *testing.T) {
+	// A restart waiting for the backoff would time the test out
+	defer func(initial time.Duration) { initialBackoff = initial }(initialBackoff)
+	initialBackoff = time.Hour
+
+	server := &mockgateway.Server{
+		Blocks: cannedBlocks(2),
+		StreamErrors: []error{
+			status.Error(codes.Unavailable, "closing transport due to: connection error: desc = \"error reading from server: EOF\", received prior goaway: code: NO_ERROR"),
+			status.Error(codes.Unavailable, "the connection is draining"),
+		},
+	}
+	conn, ctx, _ := startGateway(t, server)
+	redial := func() (*grpc.ClientConn, error) {
+		t.Error("redial called after a GOAWAY")
+		return conn, nil
+	}
+
+	received := 0
+	err := StreamWithReconnect(ctx, conn, redial, pb.HyperLiquidL1GatewayClient.StreamBlocks, &pb.Timestamp{}, func(*pb.Block) {
+		received++
+	})
+	if err != nil {
+		t.Fatalf("StreamWithReconnect: %v", err)
+	}
+	if received != 6 {
+		t.Errorf("received %d blocks, want 6 across three streams", received)
+	}
+}
+
+func TestStreamWithReconnectRestartsOnRequest(t *testing.T) {
+	// The first stream stalls after its blocks until the restart ends it
+	server := &mockgateway.Server{Blocks: cannedBlocks(2), StreamErrors: []error{mockgateway.ErrStall}}
+	conn, ctx, redial := startGateway(t, server)
+
+	redials := 0
+	countingRedial := func() (*grpc.ClientConn, error) {
+		redials++
+		return redial()
+	}
+
+	restart := make(chan struct{}, 1)
+	received := 0
+	start := time.Now()
+	err := StreamWithReconnect(ctx, conn, countingRedial, pb.HyperLiquidL1GatewayClient.StreamBlocks, &pb.Timestamp{}, func(*pb.Block) {
+		received++
+		if received == 2 {
+			restart <- struct{}{}
+		}
+	}, WithRestart(restart))
+	if err != nil {
+		t.Fatalf("StreamWithReconnect: %v", err)
+	}
+
+	if received != 4 || redials != 1 || server.Calls() != 2 {
+		t.Errorf("received %d blocks over %d streams with %d redials, want 4 over 2 with 1", received, server.Calls(), redials)
+	}
+	// A requested restart doesn't wait for the reconnect backoff
+	if elapsed := time.Since(start); elapsed >= initialBackoff {
+		t.Errorf("restart took %v, want no backoff", elapsed)
+	}
+}
+
+func TestStreamReturnsRestartRequested(t *testing.T) {
+	server := &mockgateway.Server{Blocks: cannedBlocks(1), StreamErrors: []error{mockgateway.ErrStall}}
+	conn, ctx, _ := startGateway(t, server)
+
+	restart := make(chan struct{}, 1)
+	err := Stream(ctx, conn, pb.HyperLiquidL1GatewayClient.StreamBlocks, &pb.Timestamp{}, func(*pb.Block) {
+		restart <- struct{}{}
+	}, WithRestart(restart))
+	if !errors.Is(err, ErrRestartRequested) {
+		t.Errorf("Stream error = %v, want ErrRestartRequested", err)
+	}
+}
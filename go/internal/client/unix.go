@@ -0,0 +1,17 @@
+package client
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrTLSOverUnixSocket is returned by Connect when TLS is explicitly
+// requested for a unix socket endpoint.
+var ErrTLSOverUnixSocket = errors.New("TLS is not supported on unix socket endpoints")
+
+// IsUnixSocket reports whether endpoint is a unix domain socket target, such
+// as unix:///run/gateway.sock, unix:relative.sock or unix-abstract:name.
+// Sockets are local to the host, so they are dialed without TLS.
+func IsUnixSocket(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "unix:") || strings.HasPrefix(endpoint, "unix-abstract:")
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/mockgateway"
+)
+
+func TestIsUnixSocket(t *testing.T) {
+	for endpoint, want := range map[string]bool{
+		"unix:///run/gateway.sock": true,
+		"unix:gateway.sock":        true,
+		"unix-abstract:gateway":    true,
+		"localhost:50051":          false,
+		"dns:///unix.example:443":  false,
+	} {
+		if got := IsUnixSocket(endpoint); got != want {
+			t.Errorf("IsUnixSocket(%q) = %v, want %v", endpoint, got, want)
+		}
+	}
+}
+
+func TestConnectOverUnixSocket(t *testing.T) {
+	// Socket paths are limited to about 100 bytes, which t.TempDir can exceed
+	dir, err := os.MkdirTemp("", "gw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "gateway.sock")
+
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterHyperLiquidL1GatewayServer(server, &mockgateway.Server{
+		APIKey:   "secret",
+		Snapshot: &pb.OrderBookSnapshot{Data: []byte(`{}`)},
+	})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	// TLS is on by default but skipped for the socket, and the API key is
+	// still sent
+	conn, err := Connect("unix://"+path, "secret")
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+	if _, err := NewGatewayClient(conn).GetOrderBookSnapshot(context.Background(), &pb.Timestamp{}); err != nil {
+		t.Errorf("GetOrderBookSnapshot: %v", err)
+	}
+
+	if _, err := Connect("unix://"+path, "", WithTLS(true)); !errors.Is(err, ErrTLSOverUnixSocket) {
+		t.Errorf("Connect with TLS = %v, want ErrTLSOverUnixSocket", err)
+	}
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// WithStateLogging logs every connectivity state transition of the
+// connections created by Connect until ctx is cancelled or the connection
+// is closed. See WatchState.
+func WithStateLogging(ctx context.Context) Option {
+	return func(o *options) {
+		o.watchCtx = ctx
+	}
+}
+
+// WatchState logs every connectivity state transition of conn (IDLE,
+// CONNECTING, READY, TRANSIENT_FAILURE, SHUTDOWN) together with how long the
+// previous state lasted. It returns when ctx is cancelled or conn is closed.
+func WatchState(ctx context.Context, conn *grpc.ClientConn, endpoint string) {
+	state := conn.GetState()
+	since := time.Now()
+	slog.Info("connection state", "endpoint", endpoint, "state", state)
+
+	for state != connectivity.Shutdown {
+		if !conn.WaitForStateChange(ctx, state) {
+			return
+		}
+		next := conn.GetState()
+		slog.Info("connection state changed", "endpoint", endpoint, "from", state, "to", next,
+			"after", time.Since(since).Round(time.Millisecond))
+		state, since = next, time.Now()
+	}
+}
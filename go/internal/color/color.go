@@ -0,0 +1,68 @@
+// Package color highlights key fields of the human-readable output with ANSI
+// colors: green for successes, red for errors and yellow for warnings.
+// Colors are off until Setup enables them, and Setup leaves them off when
+// the output isn't a terminal, NO_COLOR is set or -no-color is given, so
+// piped and redirected output stays plain.
+package color
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/output"
+)
+
+// ANSI SGR codes
+const (
+	red    = "31"
+	green  = "32"
+	yellow = "33"
+)
+
+// enabled is set once by Setup, before any output is written
+var enabled bool
+
+// Setup enables colors for output written to w, unless noColor is set, the
+// NO_COLOR environment variable is non-empty (see https://no-color.org) or w
+// isn't a terminal. Call it once at startup.
+func Setup(w io.Writer, noColor bool) {
+	enabled = !noColor && os.Getenv("NO_COLOR") == "" && output.IsTerminal(w)
+}
+
+// Enabled reports whether Setup enabled colors.
+func Enabled() bool {
+	return enabled
+}
+
+// Green formats v for successes.
+func Green(v any) string {
+	return paint(green, v)
+}
+
+// Red formats v for errors.
+func Red(v any) string {
+	return paint(red, v)
+}
+
+// Yellow formats v for warnings, such as height gaps.
+func Yellow(v any) string {
+	return paint(yellow, v)
+}
+
+// If formats v with paint when cond holds and plainly otherwise, e.g. to
+// color a count only when it isn't zero.
+func If(cond bool, paint func(any) string, v any) string {
+	if !cond {
+		return fmt.Sprint(v)
+	}
+	return paint(v)
+}
+
+// paint formats v with fmt.Sprint, wrapped in the color code when enabled
+func paint(code string, v any) string {
+	if !enabled {
+		return fmt.Sprint(v)
+	}
+	return "\x1b[" + code + "m" + fmt.Sprint(v) + "\x1b[0m"
+}
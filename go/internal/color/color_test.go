@@ -0,0 +1,41 @@
+package color
+
+import (
+	"bytes"
+	"testing"
+)
+
+// setEnabled sets the package state for one test
+func setEnabled(t *testing.T, on bool) {
+	t.Helper()
+	prev := enabled
+	enabled = on
+	t.Cleanup(func() { enabled = prev })
+}
+
+func TestPaint(t *testing.T) {
+	setEnabled(t, true)
+	if got, want := Green(3), "\x1b[32m3\x1b[0m"; got != want {
+		t.Errorf("Green(3) = %q, want %q", got, want)
+	}
+	if got, want := Red("error"), "\x1b[31merror\x1b[0m"; got != want {
+		t.Errorf("Red(\"error\") = %q, want %q", got, want)
+	}
+
+	if got := If(false, Red, 0); got != "0" {
+		t.Errorf("If(false, Red, 0) = %q, want plain 0", got)
+	}
+
+	setEnabled(t, false)
+	if got := Yellow(12); got != "12" {
+		t.Errorf("Yellow(12) with colors off = %q, want plain 12", got)
+	}
+}
+
+func TestSetupDisablesColorsForNonTerminals(t *testing.T) {
+	setEnabled(t, true)
+	Setup(&bytes.Buffer{}, false)
+	if Enabled() {
+		t.Error("colors enabled for a buffer")
+	}
+}
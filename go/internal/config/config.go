@@ -0,0 +1,337 @@
+// Package config resolves the settings shared by the examples from
+// command-line flags, environment variables, the .env file and an optional
+// YAML config file.
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/client"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+	"github.com/dwellir/grpc-code-examples/go/internal/model"
+)
+
+// Precedence describes how conflicting settings are resolved. It is printed in
+// the startup banner of every example.
+const Precedence = "command-line flags > environment variables > .env file > -config file"
+
+// Config holds the connection settings common to all examples.
+type Config struct {
+	Endpoint       string
+	EndpointList   string
+	APIKey         string
+	Timestamp      int64
+	From           string
+	ConnectTimeout time.Duration
+	TLSServerName  string
+	TLSInsecure    bool
+	TLSCA          string
+	Plaintext      bool
+	LogLevel       string
+	LogFormat      string
+	ConfigFile     string
+	// DryRun stops after checking the configuration and connectivity, see
+	// RunDryRun
+	DryRun bool
+	// Headers are sent as extra metadata on every call (-header, repeatable)
+	Headers []client.Header
+
+	fs        *flag.FlagSet
+	startMode string // resolved by Validate: latest, now or timestamp
+	// caPool holds the -tls-ca certificates, loaded by Validate
+	caPool *x509.CertPool
+	// explicit holds the flags set on the command line and fromFile the ones
+	// set from the -config file, for Reload
+	explicit map[string]bool
+	fromFile map[string]bool
+}
+
+// Start modes selected with -from
+const (
+	// FromLatest sends timestamp 0: the server starts at its most recent block
+	FromLatest = "latest"
+	// FromNow sends the local time at startup: only data from that moment on
+	FromNow = "now"
+	// FromTimestamp sends a past time: history is replayed from it
+	FromTimestamp = "timestamp"
+)
+
+// Register loads the .env file (if present) and registers the common flags on
+// fs, using environment values as their defaults so that flags take precedence.
+// Call fs.Parse, LoadFile and then Validate before using the returned Config.
+func Register(fs *flag.FlagSet) *Config {
+	if err := godotenv.Load(); err != nil {
+		slog.Warn(".env file not found")
+	}
+
+	var timestamp int64
+	if v := os.Getenv("HYPERLIQUID_TIMESTAMP"); v != "" {
+		ts, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			slog.Warn("ignoring invalid HYPERLIQUID_TIMESTAMP", "value", v)
+		}
+		timestamp = ts
+	}
+
+	cfg := &Config{fs: fs}
+	fs.StringVar(&cfg.ConfigFile, "config", "", "YAML file with default settings, overridden by flags and environment variables")
+	fs.StringVar(&cfg.Endpoint, "endpoint", os.Getenv("HYPERLIQUID_ENDPOINT"), "gRPC endpoint as host:port or unix:///path/to/socket (env HYPERLIQUID_ENDPOINT)")
+	fs.StringVar(&cfg.EndpointList, "endpoints", os.Getenv("HYPERLIQUID_ENDPOINTS"), "comma-separated endpoints in priority order for failover, overrides -endpoint (env HYPERLIQUID_ENDPOINTS)")
+	fs.StringVar(&cfg.APIKey, "api-key", os.Getenv("API_KEY"), "optional API key (env API_KEY)")
+	fs.DurationVar(&cfg.ConnectTimeout, "connect-timeout", 10*time.Second, "how long to wait for the connection to become ready")
+	fs.StringVar(&cfg.TLSServerName, "tls-server-name", "", "server name to verify the TLS certificate against instead of the endpoint host")
+	fs.BoolVar(&cfg.TLSInsecure, "tls-insecure", false, "skip TLS certificate verification (testing only)")
+	fs.StringVar(&cfg.TLSCA, "tls-ca", "", "PEM file of CA certificates to verify the server against instead of the system roots, for private CAs")
+	fs.BoolVar(&cfg.Plaintext, "plaintext", false, "connect without TLS, e.g. to a local mock gateway")
+	fs.StringVar(&cfg.LogLevel, "log-level", "info", "minimum level of log records on stderr: debug, info, warn or error")
+	fs.StringVar(&cfg.LogFormat, "log-format", "text", "log record format on stderr: text or json")
+	fs.Int64Var(&cfg.Timestamp, "timestamp", timestamp, "Unix start time in seconds or milliseconds, 0 means latest (env HYPERLIQUID_TIMESTAMP); -from takes precedence")
+	fs.Var((*headerList)(&cfg.Headers), "header", "extra metadata sent on every call as key=value, e.g. x-tenant-id=acme (repeatable)")
+	fs.BoolVar(&cfg.DryRun, "dry-run", false, "check the configuration, connection and API key, print the result and exit without streaming")
+	fs.StringVar(&cfg.From, "from", "", "where to start: latest (wire timestamp 0), now (the local time at startup) or a Unix time in seconds or milliseconds")
+	return cfg
+}
+
+// Endpoints returns the endpoints to connect to in priority order: the
+// -endpoints list when set, otherwise the single -endpoint.
+func (c *Config) Endpoints() []string {
+	if c.EndpointList == "" {
+		if c.Endpoint == "" {
+			return nil
+		}
+		return []string{c.Endpoint}
+	}
+
+	var endpoints []string
+	for _, endpoint := range strings.Split(c.EndpointList, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// SetupLogging installs the structured logger selected by -log-level and
+// -log-format. Call it right after fs.Parse.
+func (c *Config) SetupLogging() error {
+	return logging.Setup(c.LogLevel, c.LogFormat)
+}
+
+// Validate reports missing required settings.
+func (c *Config) Validate() error {
+	if len(c.Endpoints()) == 0 {
+		return errors.New("Error: an endpoint is required.\n" +
+			"Pass -endpoint or set HYPERLIQUID_ENDPOINT (e.g. in a .env file created from .env.example).")
+	}
+	if c.Plaintext && (c.TLSServerName != "" || c.TLSInsecure || c.TLSCA != "") {
+		return errors.New("Error: -plaintext disables TLS and cannot be combined with -tls-server-name, -tls-insecure or -tls-ca")
+	}
+	if c.TLSServerName != "" || c.TLSInsecure || c.TLSCA != "" {
+		for _, endpoint := range c.Endpoints() {
+			if client.IsUnixSocket(endpoint) {
+				return fmt.Errorf("Error: %s is a unix socket, which connects without TLS and cannot be combined with -tls-server-name, -tls-insecure or -tls-ca", endpoint)
+			}
+		}
+	}
+	c.caPool = nil
+	if c.TLSCA != "" {
+		// Verification is what the CA is for, so skipping it is a contradiction
+		if c.TLSInsecure {
+			return errors.New("Error: -tls-insecure skips certificate verification, so the CA of -tls-ca would never be used; drop -tls-insecure to verify against the CA")
+		}
+		pool, err := client.LoadCertPool(c.TLSCA)
+		if err != nil {
+			return fmt.Errorf("Error: cannot load -tls-ca: %w", err)
+		}
+		c.caPool = pool
+	}
+	if err := c.resolveStart(); err != nil {
+		return err
+	}
+	if c.Timestamp < 0 {
+		return fmt.Errorf("Error: timestamp must not be negative, got %d", c.Timestamp)
+	}
+	return nil
+}
+
+// resolveStart applies -from to Timestamp and records the start mode. Without
+// -from the mode follows -timestamp.
+func (c *Config) resolveStart() error {
+	switch from := strings.ToLower(strings.TrimSpace(c.From)); from {
+	case "":
+	case FromLatest:
+		c.Timestamp = 0
+	case FromNow:
+		c.Timestamp = time.Now().UnixMilli()
+		c.startMode = FromNow
+		return nil
+	default:
+		ts, err := strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Error: -from must be latest, now or a Unix time in seconds or milliseconds, got %q", c.From)
+		}
+		c.Timestamp = ts
+	}
+
+	c.startMode = FromTimestamp
+	if c.Timestamp == 0 {
+		c.startMode = FromLatest
+	}
+	return nil
+}
+
+// RequestTimestamp returns the start time in milliseconds as expected by the
+// gateway. Timestamp may be given in seconds or milliseconds; 0 stays 0,
+// meaning latest.
+func (c *Config) RequestTimestamp() int64 {
+	if c.Timestamp == 0 {
+		return 0
+	}
+	return model.UnixMillis(c.Timestamp)
+}
+
+// ResumeFrom starts the stream at ts, a Unix time in milliseconds, as if it
+// had been passed with -from. Call it after Validate, e.g. with the time of
+// a saved checkpoint.
+func (c *Config) ResumeFrom(ts int64) {
+	c.Timestamp = ts
+	c.startMode = FromTimestamp
+}
+
+// StartMode returns FromLatest, FromNow or FromTimestamp. It is resolved by
+// Validate.
+func (c *Config) StartMode() string {
+	return c.startMode
+}
+
+// StartDescription describes the start mode and the wire timestamp it maps to
+// for the startup banner.
+func (c *Config) StartDescription() string {
+	ts := c.RequestTimestamp()
+	switch c.startMode {
+	case FromLatest:
+		return "latest (wire timestamp 0)"
+	case FromNow:
+		return fmt.Sprintf("now, %s (wire timestamp %d)", model.UnixTime(ts).UTC().Format("2006-01-02 15:04:05 UTC"), ts)
+	default:
+		return fmt.Sprintf("%s (wire timestamp %d)", model.UnixTime(ts).UTC().Format("2006-01-02 15:04:05 UTC"), ts)
+	}
+}
+
+// StreamDescription tells whether a stream delivers history or only live
+// data, for the startup banner of the streaming examples.
+func (c *Config) StreamDescription() string {
+	switch c.startMode {
+	case FromLatest:
+		return "live: starts at the server's most recent block, which may predate startup, then follows the chain"
+	case FromNow:
+		return "live: only data from startup on, then follows the chain"
+	default:
+		return "historical replay from the start time, then live once caught up"
+	}
+}
+
+// ConnectOptions returns the client options for the transport security
+// settings and -header. Examples pass them to client.Connect ahead of their
+// own options.
+func (c *Config) ConnectOptions() []client.Option {
+	var opts []client.Option
+	switch {
+	case c.Plaintext:
+		opts = append(opts, client.WithTLS(false))
+	case c.TLSServerName != "" || c.TLSInsecure || c.caPool != nil:
+		opts = append(opts, client.WithTLSConfig(&tls.Config{
+			ServerName:         c.TLSServerName,
+			InsecureSkipVerify: c.TLSInsecure,
+			RootCAs:            c.caPool,
+		}))
+	}
+	if len(c.Headers) > 0 {
+		opts = append(opts, client.WithHeaders(c.Headers...))
+	}
+	return opts
+}
+
+// headerList is the flag.Value of the repeatable -header flag
+type headerList []client.Header
+
+func (h *headerList) String() string {
+	if h == nil {
+		return ""
+	}
+	pairs := make([]string, len(*h))
+	for i, header := range *h {
+		pairs[i] = header.Key + "=" + header.Value
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (h *headerList) Set(s string) error {
+	header, err := client.ParseHeader(s)
+	if err != nil {
+		return err
+	}
+	*h = append(*h, header)
+	return nil
+}
+
+// TransportDescription describes the transport security mode for the
+// startup banner.
+func (c *Config) TransportDescription() string {
+	unix := 0
+	for _, endpoint := range c.Endpoints() {
+		if client.IsUnixSocket(endpoint) {
+			unix++
+		}
+	}
+	switch {
+	case unix > 0 && unix == len(c.Endpoints()):
+		return "unix socket (no TLS)"
+	case unix > 0 && !c.Plaintext:
+		return c.networkTransport() + ", unix sockets without TLS"
+	default:
+		return c.networkTransport()
+	}
+}
+
+// networkTransport describes the transport security of TCP endpoints
+func (c *Config) networkTransport() string {
+	switch {
+	case c.Plaintext:
+		return "plaintext (no TLS)"
+	case c.TLSInsecure:
+		return "TLS without certificate verification"
+	}
+
+	var details []string
+	if c.TLSServerName != "" {
+		details = append(details, "server name "+c.TLSServerName)
+	}
+	if c.TLSCA != "" {
+		details = append(details, "CA "+c.TLSCA)
+	}
+	if len(details) == 0 {
+		return "TLS"
+	}
+	return fmt.Sprintf("TLS (%s)", strings.Join(details, ", "))
+}
+
+// SecurityWarning returns a warning to print when the settings weaken
+// transport security, or "" when they don't.
+func (c *Config) SecurityWarning() string {
+	if !c.TLSInsecure {
+		return ""
+	}
+	return "TLS certificate verification is DISABLED (-tls-insecure): the connection is encrypted but the server is not authenticated, use this for testing only"
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/client"
+	"github.com/dwellir/grpc-code-examples/go/internal/color"
+)
+
+// RunDryRun performs the checks of -dry-run on a validated Config: it
+// connects to the endpoints in priority order like the examples do and, when
+// an API key is set, opens a stream to check that the key is accepted. It
+// prints the outcome to w and returns the exit status, ExitOK when the
+// example is ready to stream. Failures are logged as well.
+func (c *Config) RunDryRun(w io.Writer) int {
+	fmt.Fprintln(w, "🧪 Dry run: checking the setup without streaming")
+	fmt.Fprintf(w, "✅ Configuration valid: %s, %s\n", strings.Join(c.Endpoints(), ", "), c.TransportDescription())
+
+	failover := client.NewFailover(c.Endpoints(), func(endpoint string) (*grpc.ClientConn, error) {
+		return client.Connect(endpoint, c.APIKey, c.ConnectOptions()...)
+	})
+	ctx := context.Background()
+
+	start := time.Now()
+	conn, err := failover.Connect(ctx, c.ConnectTimeout)
+	if err != nil {
+		fmt.Fprintln(w, color.Red("❌ Not ready: no endpoint became ready within "+c.ConnectTimeout.String()))
+		slog.Error("failed to connect", "err", err)
+		return client.ExitConnectionFailure
+	}
+	defer conn.Close()
+	fmt.Fprintf(w, "✅ Connected to %s in %v\n", failover.Active(), time.Since(start).Round(time.Millisecond))
+
+	// READY proves the transport only: the key is checked on the first call
+	if c.APIKey == "" {
+		fmt.Fprintln(w, "ℹ️  No API key provided, authentication not checked")
+	} else {
+		delay, err := client.Probe(ctx, conn, c.ConnectTimeout)
+		if message, auth := client.ClassifyError(err); auth {
+			fmt.Fprintln(w, color.Red("❌ Not ready: "+message))
+			slog.Error(message, "err", err)
+			return client.ExitAuthFailure
+		}
+		if err != nil {
+			fmt.Fprintln(w, color.Red("❌ Not ready: the block stream failed: "+err.Error()))
+			slog.Error("block stream probe failed", "err", err)
+			return client.ExitStreamError
+		}
+		fmt.Fprintf(w, "✅ API key accepted, first block after %v\n", delay.Round(time.Millisecond))
+	}
+
+	fmt.Fprintln(w, color.Green("✅ Ready to stream"))
+	return client.ExitOK
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the schema of the -config YAML file. Keys are named after the
+// flags they set, and every key is optional except that one of endpoint and
+// endpoints is required. Keys for flags an example doesn't have (output, say)
+// are ignored by it, so one file can be shared by all examples.
+//
+//	endpoint: api-hyperliquid-mainnet-grpc.n.dwellir.com:443
+//	endpoints: [primary:443, backup:443]  # failover list, overrides endpoint
+//	api-key: your-api-key
+//	timestamp: 0                          # Unix seconds or milliseconds, 0 means latest
+//	from: latest                          # latest, now or a Unix time; overrides timestamp
+//	connect-timeout: 10s
+//	max-msg-size: 256MB
+//	output: pretty                        # stream_blocks.go only
+//	symbols: [BTC, ETH]                   # stream_block_fills.go only
+//	alerts: ["BTC>70000", "ETH<3000"]     # stream_block_fills.go only
+//	tls-server-name: example.internal
+//	tls-insecure: false
+//	tls-ca: /etc/ssl/internal-ca.pem
+//	plaintext: false
+//	log-level: info
+//	log-format: text
+type File struct {
+	Endpoint       string   `yaml:"endpoint"`
+	Endpoints      []string `yaml:"endpoints"`
+	APIKey         string   `yaml:"api-key"`
+	Timestamp      *int64   `yaml:"timestamp"`
+	From           string   `yaml:"from"`
+	ConnectTimeout string   `yaml:"connect-timeout"`
+	MaxMsgSize     string   `yaml:"max-msg-size"`
+	Output         string   `yaml:"output"`
+	Symbols        []string `yaml:"symbols"`
+	Alerts         []string `yaml:"alerts"`
+	TLSServerName  string   `yaml:"tls-server-name"`
+	TLSInsecure    *bool    `yaml:"tls-insecure"`
+	TLSCA          string   `yaml:"tls-ca"`
+	Plaintext      *bool    `yaml:"plaintext"`
+	LogLevel       string   `yaml:"log-level"`
+	LogFormat      string   `yaml:"log-format"`
+}
+
+// flagEnv maps flags to the environment variables that override file values
+var flagEnv = map[string]string{
+	"endpoint":  "HYPERLIQUID_ENDPOINT",
+	"endpoints": "HYPERLIQUID_ENDPOINTS",
+	"api-key":   "API_KEY",
+	"timestamp": "HYPERLIQUID_TIMESTAMP",
+}
+
+// ReadFile reads and validates the config file at path. Unknown keys are
+// rejected so that typos don't go unnoticed.
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file File
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if file.Endpoint == "" && len(file.Endpoints) == 0 {
+		return nil, fmt.Errorf("%s: endpoint or endpoints is required", path)
+	}
+	return &file, nil
+}
+
+// values returns the file's settings as flag values keyed by flag name,
+// leaving out the keys that are not set
+func (f *File) values() map[string]string {
+	values := map[string]string{
+		"endpoint":        f.Endpoint,
+		"endpoints":       strings.Join(f.Endpoints, ","),
+		"api-key":         f.APIKey,
+		"from":            f.From,
+		"connect-timeout": f.ConnectTimeout,
+		"max-msg-size":    f.MaxMsgSize,
+		"output":          f.Output,
+		"symbols":         strings.Join(f.Symbols, ","),
+		"alert":           strings.Join(f.Alerts, ","),
+		"tls-server-name": f.TLSServerName,
+		"tls-ca":          f.TLSCA,
+		"log-level":       f.LogLevel,
+		"log-format":      f.LogFormat,
+	}
+	if f.Timestamp != nil {
+		values["timestamp"] = strconv.FormatInt(*f.Timestamp, 10)
+	}
+	if f.TLSInsecure != nil {
+		values["tls-insecure"] = strconv.FormatBool(*f.TLSInsecure)
+	}
+	if f.Plaintext != nil {
+		values["plaintext"] = strconv.FormatBool(*f.Plaintext)
+	}
+	for name, value := range values {
+		if value == "" {
+			delete(values, name)
+		}
+	}
+	return values
+}
+
+// LoadFile applies the file given with -config, if any. A file value only
+// takes effect when neither the flag nor its environment variable is set, so
+// the file has the lowest precedence. Call it right after fs.Parse, before
+// SetupLogging.
+func (c *Config) LoadFile() error {
+	if c.ConfigFile == "" {
+		return nil
+	}
+
+	file, err := ReadFile(c.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	// Remembered for Reload, as setting the file's values marks them as set too
+	c.explicit = make(map[string]bool)
+	c.fs.Visit(func(f *flag.Flag) {
+		c.explicit[f.Name] = true
+	})
+
+	c.fromFile = make(map[string]bool)
+	for name, value := range file.values() {
+		if !c.fileApplies(name) {
+			continue
+		}
+		if err := c.fs.Set(name, value); err != nil {
+			return fmt.Errorf("%s: invalid %s %q: %w", c.ConfigFile, name, value, err)
+		}
+		c.fromFile[name] = true
+	}
+	return nil
+}
+
+// fileApplies reports whether the file may set the named flag: the example
+// has it, and neither the flag nor its environment variable is set.
+func (c *Config) fileApplies(name string) bool {
+	if c.explicit[name] || c.fs.Lookup(name) == nil {
+		return false
+	}
+	env, ok := flagEnv[name]
+	return !ok || os.Getenv(env) == ""
+}
+
+// resetter is implemented by flag values that accumulate repeated Sets, such
+// as -alert, so that a reload replaces their value rather than appending to it
+type resetter interface {
+	Reset()
+}
+
+// setFlag sets f to value, replacing rather than appending to the value of a
+// repeatable flag
+func setFlag(f *flag.Flag, value string) error {
+	if r, ok := f.Value.(resetter); ok {
+		r.Reset()
+		if value == "" {
+			return nil
+		}
+	}
+	return f.Value.Set(value)
+}
+
+// reconnectFlags are the settings that only take effect on a new connection
+var reconnectFlags = map[string]bool{
+	"endpoint":        true,
+	"endpoints":       true,
+	"api-key":         true,
+	"tls-server-name": true,
+	"tls-insecure":    true,
+	"tls-ca":          true,
+	"plaintext":       true,
+}
+
+// NeedsReconnect reports whether a setting changed by Reload only takes
+// effect on a new connection.
+func NeedsReconnect(name string) bool {
+	return reconnectFlags[name]
+}
+
+// Reload re-reads the -config file, e.g. on SIGHUP, applies it with the same
+// precedence as LoadFile and returns the names of the flags whose value
+// changed, sorted. Flags whose key was removed from the file return to their
+// default. The file is applied completely or not at all: on an error, such
+// as an invalid value, the previous values are kept. A changed -log-level or
+// -log-format reinstalls the logger, while the start of the stream isn't
+// changed, since it has already begun. Reload is not safe for
+// concurrent use with anything reading the Config or its flags.
+func (c *Config) Reload() (changed []string, err error) {
+	if c.ConfigFile == "" {
+		return nil, errors.New("no -config file to reload")
+	}
+	file, err := ReadFile(c.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	fileValues := file.values()
+	values := make(map[string]string, len(fileValues))
+	for name, value := range fileValues {
+		values[name] = value
+	}
+	for name := range c.fromFile {
+		if _, ok := fileValues[name]; !ok {
+			values[name] = c.fs.Lookup(name).DefValue
+		}
+	}
+
+	timestamp, startMode, caPool := c.Timestamp, c.startMode, c.caPool
+	previous := make(map[string]string)
+	restore := func() {
+		for name, value := range previous {
+			// The value was valid before, so it can be set again
+			_ = setFlag(c.fs.Lookup(name), value)
+		}
+		c.Timestamp, c.startMode, c.caPool = timestamp, startMode, caPool
+	}
+
+	fromFile := make(map[string]bool)
+	for name, value := range values {
+		if !c.fileApplies(name) {
+			continue
+		}
+		f := c.fs.Lookup(name)
+		if _, ok := fileValues[name]; ok {
+			fromFile[name] = true
+		}
+		if f.Value.String() == value {
+			continue
+		}
+
+		previous[name] = f.Value.String()
+		if err := setFlag(f, value); err != nil {
+			restore()
+			return nil, fmt.Errorf("%s: invalid %s %q: %w", c.ConfigFile, name, value, err)
+		}
+		changed = append(changed, name)
+	}
+
+	if err := c.Validate(); err != nil {
+		restore()
+		return nil, err
+	}
+	_, levelChanged := previous["log-level"]
+	_, formatChanged := previous["log-format"]
+	if levelChanged || formatChanged {
+		if err := c.SetupLogging(); err != nil {
+			restore()
+			return nil, err
+		}
+	}
+	c.Timestamp, c.startMode = timestamp, startMode
+	c.fromFile = fromFile
+	sort.Strings(changed)
+	return changed, nil
+}
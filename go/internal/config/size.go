@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits maps size suffixes to their multiplier. Sizes are binary, so
+// 1MB is 1024*1024 bytes as elsewhere in the examples.
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	// Longer suffixes first so "MB" isn't read as "B"
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+	{"B", 1},
+}
+
+// ParseSize parses a human size such as "256MB", "1GB" or "4096" (bytes).
+func ParseSize(s string) (int, error) {
+	text := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(text, unit.suffix) {
+			text = strings.TrimSpace(strings.TrimSuffix(text, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	// NaN fails every comparison, so it is rejected explicitly, and so is
+	// a fraction that comes out below one byte
+	value, err := strconv.ParseFloat(text, 64)
+	bytes := value * float64(multiplier)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) || bytes < 1 {
+		return 0, fmt.Errorf("invalid size %q (expected e.g. 256MB, 1GB or a number of bytes)", s)
+	}
+	// float64(math.MaxInt) rounds up to 2^63, which doesn't fit an int
+	if bytes >= float64(math.MaxInt) {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return int(bytes), nil
+}
+
+// FormatSize formats bytes in the largest unit that divides it evenly.
+func FormatSize(bytes int) string {
+	switch {
+	case bytes >= 1<<30 && bytes%(1<<30) == 0:
+		return fmt.Sprintf("%dGB", bytes>>30)
+	case bytes >= 1<<20 && bytes%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", bytes>>20)
+	case bytes >= 1<<10 && bytes%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", bytes>>10)
+	}
+	return fmt.Sprintf("%dB", bytes)
+}
+
+// ByteSize is a flag.Value accepting human sizes (see ParseSize).
+type ByteSize int
+
+// String implements flag.Value.
+func (b *ByteSize) String() string {
+	return FormatSize(int(*b))
+}
+
+// Set implements flag.Value.
+func (b *ByteSize) Set(s string) error {
+	size, err := ParseSize(s)
+	if err != nil {
+		return err
+	}
+	*b = ByteSize(size)
+	return nil
+}
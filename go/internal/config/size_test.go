@@ -0,0 +1,107 @@
+package config
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int{
+		"4096":      4096,
+		"1":         1,
+		"1.5":       1,
+		"512B":      512,
+		"64KB":      64 << 10,
+		"64K":       64 << 10,
+		"64KiB":     64 << 10,
+		"256MB":     256 << 20,
+		"256mb":     256 << 20,
+		"256Mib":    256 << 20,
+		"1GB":       1 << 30,
+		"1g":        1 << 30,
+		"0.5MB":     512 << 10,
+		"1.5GB":     3 << 29,
+		" 128 MB ":  128 << 20,
+		"\t2GiB\n":  2 << 30,
+		"8000000GB": 8000000 << 30,
+	} {
+		if got, err := ParseSize(s); err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+}
+
+func TestParseSizeRejectsInvalidSizes(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"MB",
+		"lots",
+		"12XB",
+		"0",
+		"0MB",
+		"-1",
+		"-256MB",
+		"0.5",
+		"0.0001KB",
+		"nan",
+		"NaNMB",
+		"inf",
+		"+Inf",
+		"-inf",
+		"Infinity",
+		"1e300",
+		"9223372036854775807",
+		"8589934592GB",
+	} {
+		if got, err := ParseSize(s); err == nil {
+			t.Errorf("ParseSize(%q) = %d, want an error", s, got)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for bytes, want := range map[int]string{
+		0:             "0B",
+		1:             "1B",
+		1000:          "1000B",
+		1 << 10:       "1KB",
+		1536:          "1536B",
+		64 << 10:      "64KB",
+		1 << 20:       "1MB",
+		(1 << 20) + 1: "1048577B",
+		1536 << 10:    "1536KB",
+		256 << 20:     "256MB",
+		1 << 30:       "1GB",
+		3 << 29:       "1536MB",
+		8000000 << 30: "8000000GB",
+	} {
+		if got := FormatSize(bytes); got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", bytes, got, want)
+		}
+	}
+}
+
+func TestFormatSizeRoundTrips(t *testing.T) {
+	for _, bytes := range []int{1, 1000, 1 << 10, 1536, 64 << 10, (1 << 20) + 1, 256 << 20, 3 << 29, 1 << 30, 8000000 << 30} {
+		got, err := ParseSize(FormatSize(bytes))
+		if err != nil || got != bytes {
+			t.Errorf("ParseSize(FormatSize(%d)) = %d, %v", bytes, got, err)
+		}
+	}
+}
+
+func TestByteSizeFlag(t *testing.T) {
+	size := ByteSize(256 << 20)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&size, "max-msg-size", "")
+
+	if err := fs.Parse([]string{"-max-msg-size", "1GB"}); err != nil {
+		t.Fatal(err)
+	}
+	if size != 1<<30 || size.String() != "1GB" {
+		t.Errorf("-max-msg-size 1GB = %d (%s)", size, size.String())
+	}
+	if err := size.Set("nan"); err == nil || size != 1<<30 {
+		t.Errorf("Set(nan) = %v, size %d; want an error and the size unchanged", err, size)
+	}
+}
@@ -0,0 +1,103 @@
+// Package decimal parses the decimal strings the gateway uses for prices and
+// sizes into exact rationals, so large notionals keep every digit.
+package decimal
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Auto is the precision that shows as many decimals as needed, up to
+// MaxPlaces, without trailing zeros.
+const Auto = -1
+
+// MaxPlaces bounds the decimals shown with Auto, for values such as a VWAP
+// that don't have a finite decimal expansion.
+const MaxPlaces = 8
+
+// Parse parses a plain decimal such as "65000", "-0.0015" or "1.5e3". Unlike
+// big.Rat.SetString it rejects fractions ("1/3"), hex and surrounding
+// whitespace, which never appear in the feed and usually point at a
+// misread field.
+func Parse(s string) (*big.Rat, error) {
+	if !isDecimal(s) {
+		return nil, fmt.Errorf("invalid decimal %q", s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", s)
+	}
+	return r, nil
+}
+
+// Format formats r with places decimals, rounding half away from zero, or
+// with Auto (any negative places) as many as needed up to MaxPlaces. It only
+// affects display; r itself is left untouched.
+func Format(r *big.Rat, places int) string {
+	if places >= 0 {
+		return r.FloatString(places)
+	}
+	s := r.FloatString(MaxPlaces)
+	if strings.Contains(s, ".") {
+		s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// FormatString formats the decimal string s with Format. With Auto, and for
+// strings that aren't decimals, s is returned as is.
+func FormatString(s string, places int) string {
+	if places < 0 {
+		return s
+	}
+	r, err := Parse(s)
+	if err != nil {
+		return s
+	}
+	return Format(r, places)
+}
+
+// isDecimal reports whether s is [sign] digits [. digits] [e [sign] digits],
+// with at least one digit in the mantissa
+func isDecimal(s string) bool {
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+
+	digits := 0
+	for ; i < len(s) && isDigit(s[i]); i++ {
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		for i++; i < len(s) && isDigit(s[i]); i++ {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return false
+	}
+
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		exponent := 0
+		for ; i < len(s) && isDigit(s[i]); i++ {
+			exponent++
+		}
+		if exponent == 0 {
+			return false
+		}
+	}
+	return i == len(s)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,108 @@
+package decimal
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseKeepsDigitsLostByFloat64(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"9007199254740993", "9007199254740993"},                           // 2^53 + 1, float64 rounds to 2^53
+		{"123456789.123456789", "123456789123456789/1000000000"},           // a BTC-sized notional with satoshi precision
+		{"98765432109876.000000001", "98765432109876000000001/1000000000"}, // float64 drops the last digit
+		{"0.1", "1/10"},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.in)
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v", tt.in, err)
+		}
+		if got := r.RatString(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseSumIsExact(t *testing.T) {
+	// 0.1 + 0.2 != 0.3 in float64
+	sum := new(big.Rat)
+	for _, s := range []string{"0.1", "0.2"} {
+		r, err := Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum.Add(sum, r)
+	}
+	if want := big.NewRat(3, 10); sum.Cmp(want) != 0 {
+		t.Errorf("0.1 + 0.2 = %s, want 3/10", sum.RatString())
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	valid := []string{"0", "-0.0015", "+42", ".5", "5.", "1.5e3", "2E-8"}
+	for _, s := range valid {
+		if _, err := Parse(s); err != nil {
+			t.Errorf("Parse(%q) = %v, want success", s, err)
+		}
+	}
+
+	invalid := []string{"", "-", ".", "1/3", "0x10", " 1", "1 ", "1e", "1.2.3", "NaN", "Inf", "1,000"}
+	for _, s := range invalid {
+		if r, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) = %s, want an error", s, r.RatString())
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input  string
+		places int
+		want   string
+	}{
+		{"65000.5", Auto, "65000.5"},
+		{"65000", Auto, "65000"},
+		{"0.000012345678", Auto, "0.00001235"},
+		{"-0.000000001", Auto, "0"},
+		{"1.5e3", Auto, "1500"},
+		{"65000.5", 2, "65000.50"},
+		{"0.00012345", 2, "0.00"},
+		{"0.00012345", 6, "0.000123"},
+		{"2.5", 0, "3"},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.input)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.input, err)
+		}
+		if got := Format(r, tt.places); got != tt.want {
+			t.Errorf("Format(%s, %d) = %q, want %q", tt.input, tt.places, got, tt.want)
+		}
+	}
+
+	third := big.NewRat(1, 3)
+	if got := Format(third, Auto); got != "0.33333333" {
+		t.Errorf("Format(1/3, Auto) = %q, want 0.33333333", got)
+	}
+}
+
+func TestFormatString(t *testing.T) {
+	tests := []struct {
+		input  string
+		places int
+		want   string
+	}{
+		{"65000.50", Auto, "65000.50"},
+		{"65000.5", 2, "65000.50"},
+		{"0.00012345", 5, "0.00012"},
+		{"n/a", 2, "n/a"},
+	}
+	for _, tt := range tests {
+		if got := FormatString(tt.input, tt.places); got != tt.want {
+			t.Errorf("FormatString(%q, %d) = %q, want %q", tt.input, tt.places, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Package display prints the human-readable summaries shared by the live
+// streaming examples and the offline replay tool, so both render a message
+// the same way.
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/color"
+	"github.com/dwellir/grpc-code-examples/go/internal/model"
+)
+
+// BlockSummary writes the details of block number blockNum to w.
+func BlockSummary(w io.Writer, summary *model.BlockSummary, blockNum int) {
+	fmt.Fprintf(w, "🧱 BLOCK #%d DETAILS\n", blockNum)
+	fmt.Fprintln(w, "===================")
+
+	// Display height
+	if summary.Height != 0 {
+		fmt.Fprintf(w, "📏 Height: %d\n", summary.Height)
+	}
+
+	// Display proposer
+	if summary.Proposer != "" {
+		fmt.Fprintf(w, "👤 Proposer: %s\n", summary.Proposer)
+	}
+
+	fmt.Fprintln(w, "📋 Action types:")
+	for actionType, count := range summary.ActionCounts {
+		fmt.Fprintf(w, "  • %s: %d\n", actionType, count)
+	}
+	fmt.Fprintf(w, "  Total actions: %d\n", summary.TotalActions)
+
+	fmt.Fprintln(w, "\n📊 Order Statuses:")
+	fmt.Fprintf(w, "  ✅ Success: %s\n", color.Green(summary.Success))
+	fmt.Fprintf(w, "  ❌ Error: %s\n", color.If(summary.Errors > 0, color.Red, summary.Errors))
+	fmt.Fprintf(w, "  Total statuses: %d\n", summary.TotalStatuses())
+
+	fmt.Fprintf(w, "\n🔍 Match check: Actions=%d, Statuses=%d, Match=%s\n", summary.TotalActions, summary.TotalStatuses(), color.If(!summary.Match, color.Red, summary.Match))
+
+	// Name the action types behind a mismatch
+	for _, m := range summary.TypeMismatches() {
+		fmt.Fprintf(w, "  • %s: %d actions vs %d statuses\n", m.Type, m.Actions, m.Statuses)
+	}
+}
+
+// TypeMismatches formats per-type discrepancies on one line, e.g.
+// "cancel 2 vs 0, order 5 vs 4" for actions vs statuses.
+func TypeMismatches(mismatches []model.TypeMismatch) string {
+	parts := make([]string, len(mismatches))
+	for i, m := range mismatches {
+		parts[i] = fmt.Sprintf("%s %d vs %d", m.Type, m.Actions, m.Statuses)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,60 @@
+package display
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/model"
+)
+
+func TestBlockSummaryWritesToWriter(t *testing.T) {
+	summary := &model.BlockSummary{
+		Height:       42,
+		Proposer:     "0xabc",
+		ActionCounts: map[string]int{"order": 3},
+		TotalActions: 3,
+		Success:      2,
+		Errors:       1,
+		StatusCounts: map[string]int{"order": 3},
+		Match:        true,
+	}
+
+	var buf bytes.Buffer
+	BlockSummary(&buf, summary, 7)
+
+	for _, want := range []string{
+		"BLOCK #7 DETAILS",
+		"Height: 42",
+		"Proposer: 0xabc",
+		"• order: 3",
+		"Total statuses: 3",
+		"Match=true",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output is missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestBlockSummaryNamesMismatchingTypes(t *testing.T) {
+	summary := &model.BlockSummary{
+		ActionCounts: map[string]int{"order": 2, "cancel": 1},
+		TotalActions: 3,
+		Success:      2,
+		StatusCounts: map[string]int{"order": 2},
+	}
+
+	var buf bytes.Buffer
+	BlockSummary(&buf, summary, 1)
+
+	if want := "• cancel: 1 actions vs 0 statuses"; !strings.Contains(buf.String(), want) {
+		t.Errorf("output is missing %q:\n%s", want, buf.String())
+	}
+	if strings.Contains(buf.String(), "order: 2 actions") {
+		t.Errorf("output lists the matching order type:\n%s", buf.String())
+	}
+	if got, want := TypeMismatches(summary.TypeMismatches()), "cancel 1 vs 0"; got != want {
+		t.Errorf("TypeMismatches() = %q, want %q", got, want)
+	}
+}
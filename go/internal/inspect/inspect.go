@@ -0,0 +1,71 @@
+// Package inspect keeps the most recent messages of a stream in memory and
+// serves them over HTTP, so a running example can be looked into without
+// stopping it.
+package inspect
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// Ring holds the last size items added to it. It is safe for concurrent use.
+type Ring[T any] struct {
+	mu    sync.Mutex
+	items []T
+	next  int
+	full  bool
+}
+
+// NewRing returns a Ring keeping the last size items.
+func NewRing[T any](size int) *Ring[T] {
+	return &Ring[T]{items: make([]T, size)}
+}
+
+// Add stores item, evicting the oldest one when the ring is full.
+func (r *Ring[T]) Add(item T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.items[r.next] = item
+	r.next = (r.next + 1) % len(r.items)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Items returns a copy of the stored items, oldest first.
+func (r *Ring[T]) Items() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]T{}, r.items[:r.next]...)
+	}
+	items := make([]T, 0, len(r.items))
+	items = append(items, r.items[r.next:]...)
+	return append(items, r.items[:r.next]...)
+}
+
+// Handler serves the items of ring as a JSON array, oldest first.
+func Handler[T any](ring *Ring[T]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ring.Items()); err != nil {
+			slog.Error("failed to serve recent items", "err", err)
+		}
+	})
+}
+
+// Serve serves ring at /recent on addr in the background.
+func Serve[T any](addr string, ring *Ring[T]) {
+	mux := http.NewServeMux()
+	mux.Handle("/recent", Handler(ring))
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			slog.Error("inspection server stopped", "addr", addr, "err", err)
+		}
+	}()
+}
@@ -0,0 +1,81 @@
+package inspect
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestRingKeepsLastItemsInOrder(t *testing.T) {
+	ring := NewRing[int](3)
+	if got := ring.Items(); len(got) != 0 {
+		t.Fatalf("empty ring items = %v", got)
+	}
+
+	ring.Add(1)
+	ring.Add(2)
+	if got, want := ring.Items(), []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("items = %v, want %v", got, want)
+	}
+
+	for i := 3; i <= 7; i++ {
+		ring.Add(i)
+	}
+	if got, want := ring.Items(), []int{5, 6, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("items after wrapping = %v, want %v", got, want)
+	}
+}
+
+func TestRingConcurrentAccess(t *testing.T) {
+	ring := NewRing[int](8)
+
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				ring.Add(w*100 + i)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if n := len(ring.Items()); n > 8 {
+					t.Errorf("ring returned %d items, want at most 8", n)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(ring.Items()); n != 8 {
+		t.Errorf("full ring has %d items, want 8", n)
+	}
+}
+
+func TestHandlerServesJSON(t *testing.T) {
+	type entry struct {
+		Block int `json:"block"`
+	}
+	ring := NewRing[entry](2)
+	ring.Add(entry{Block: 1})
+	ring.Add(entry{Block: 2})
+	ring.Add(entry{Block: 3})
+
+	rec := httptest.NewRecorder()
+	Handler(ring).ServeHTTP(rec, httptest.NewRequest("GET", "/recent", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var got []entry
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("response is not JSON: %v\n%s", err, rec.Body)
+	}
+	if want := []entry{{Block: 2}, {Block: 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("served %v, want %v", got, want)
+	}
+}
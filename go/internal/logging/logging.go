@@ -0,0 +1,89 @@
+// Package logging sets up the structured logger used by the examples for
+// operational events (connects, reconnects, errors, gaps). Human-readable
+// stream output stays on stdout; log records go to stderr.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Until Setup is called, records are written to stderr in the default text
+// format, so messages logged while the flags and config file are still being
+// read (a missing .env file, an invalid -config) look like every later one
+// instead of going through the standard log package's format.
+func init() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+}
+
+// New returns a logger writing to w at level ("debug", "info", "warn" or
+// "error") in format ("text" or "json").
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q (expected debug, info, warn or error)", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q (expected text or json)", format)
+	}
+}
+
+// Setup installs a logger writing to stderr as the slog default. The standard
+// log package is routed through it as well.
+func Setup(level, format string) error {
+	logger, err := New(os.Stderr, level, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// exitHooks run before Fatal and Exit end the process
+var exitHooks []func()
+
+// exitHookTimeout bounds how long Exit waits for the hooks, so a hook stuck
+// on a blocked writer can't keep a forced quit from exiting
+const exitHookTimeout = 2 * time.Second
+
+// OnExit registers fn to run before Fatal or Exit ends the process, e.g. to
+// flush buffered output that os.Exit would otherwise lose. Register hooks at
+// startup, before any goroutine can call Fatal or Exit.
+func OnExit(fn func()) {
+	exitHooks = append(exitHooks, fn)
+}
+
+// Fatal logs msg at error level and exits with status 1.
+func Fatal(msg string, args ...any) {
+	Exit(1, msg, args...)
+}
+
+// Exit logs msg at error level and exits with status code once the OnExit
+// hooks have run, or after a couple of seconds if they block.
+func Exit(code int, msg string, args ...any) {
+	slog.Error(msg, args...)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, fn := range exitHooks {
+			fn()
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(exitHookTimeout):
+		slog.Error("exit hooks did not finish in time", "timeout", exitHookTimeout)
+	}
+	os.Exit(code)
+}
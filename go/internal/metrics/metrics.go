@@ -0,0 +1,145 @@
+// Package metrics exposes Prometheus metrics about the streams consumed by
+// the examples, and can send the same metrics to StatsD.
+package metrics
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	blocksReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "hyperliquid_blocks_received_total",
+		Help: "Messages received from the gateway stream.",
+	}, []string{"stream"})
+
+	bytesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "hyperliquid_bytes_received_total",
+		Help: "Payload bytes received from the gateway stream.",
+	}, []string{"stream"})
+
+	parseErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "hyperliquid_parse_errors_total",
+		Help: "Messages whose JSON payload could not be parsed.",
+	}, []string{"stream"})
+
+	messageSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "hyperliquid_message_size_bytes",
+		Help:    "Size of received message payloads.",
+		Buckets: prometheus.ExponentialBuckets(1024, 4, 11), // 1KB to 1GB
+	}, []string{"stream"})
+
+	feedLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hyperliquid_feed_lag_seconds",
+		Help: "Delay between the time of the last message and when it was received.",
+	}, []string{"stream"})
+)
+
+// Option enables a destination for the metrics of a Stream.
+type Option func(*Stream)
+
+// WithPrometheus records the metrics in the Prometheus registry served by
+// Serve.
+func WithPrometheus() Option {
+	return func(s *Stream) {
+		s.prometheus = &prometheusStream{
+			blocks:      blocksReceived.WithLabelValues(s.name),
+			bytes:       bytesReceived.WithLabelValues(s.name),
+			parseErrors: parseErrors.WithLabelValues(s.name),
+			sizes:       messageSize.WithLabelValues(s.name),
+			lag:         feedLag.WithLabelValues(s.name),
+		}
+	}
+}
+
+// WithStatsD sends the metrics to c, named after the stream (see StatsD).
+func WithStatsD(c *StatsD) Option {
+	return func(s *Stream) {
+		s.statsd = c
+	}
+}
+
+// Stream records metrics for one gateway stream. A nil *Stream is valid and
+// records nothing, so callers need not check whether metrics are enabled.
+type Stream struct {
+	name       string
+	prometheus *prometheusStream
+	statsd     *StatsD
+}
+
+// prometheusStream holds the Prometheus metrics of one stream
+type prometheusStream struct {
+	blocks      prometheus.Counter
+	bytes       prometheus.Counter
+	parseErrors prometheus.Counter
+	sizes       prometheus.Observer
+	lag         prometheus.Gauge
+}
+
+// NewStream returns the metrics for the stream with the given name, e.g.
+// "blocks" or "block_fills", recorded to every destination in opts. It
+// returns nil when opts enable none, so metrics cost nothing when disabled.
+func NewStream(name string, opts ...Option) *Stream {
+	if len(opts) == 0 {
+		return nil
+	}
+	s := &Stream{name: name}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Received records a message with a payload of size bytes.
+func (s *Stream) Received(size int) {
+	if s == nil {
+		return
+	}
+	if p := s.prometheus; p != nil {
+		p.blocks.Inc()
+		p.bytes.Add(float64(size))
+		p.sizes.Observe(float64(size))
+	}
+	s.statsd.Count(s.name+".received", 1)
+	s.statsd.Count(s.name+".bytes", int64(size))
+}
+
+// ParseError records a payload that could not be parsed.
+func (s *Stream) ParseError() {
+	if s == nil {
+		return
+	}
+	if p := s.prometheus; p != nil {
+		p.parseErrors.Inc()
+	}
+	s.statsd.Count(s.name+".parse_errors", 1)
+}
+
+// Lag records the delay between the time of a message and when it was
+// received.
+func (s *Stream) Lag(lag time.Duration) {
+	if s == nil {
+		return
+	}
+	if p := s.prometheus; p != nil {
+		p.lag.Set(lag.Seconds())
+	}
+	s.statsd.Timing(s.name+".feed_lag", lag)
+}
+
+// Serve exposes the metrics at http://addr/metrics in the background.
+func Serve(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			slog.Error("metrics server stopped", "addr", addr, "err", err)
+		}
+	}()
+}
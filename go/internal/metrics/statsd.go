@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// StatsDPrefix starts the name of every metric sent to StatsD, e.g.
+// "hyperliquid.blocks.received".
+const StatsDPrefix = "hyperliquid."
+
+// StatsD sends metrics to a StatsD (or DogStatsD) server over UDP, one
+// metric per datagram. Sending is fire and forget: a server that is down or
+// unreachable loses the metrics without slowing or failing the stream. A nil
+// *StatsD sends nothing.
+type StatsD struct {
+	conn net.Conn
+}
+
+// DialStatsD returns a client sending to the server at addr (host:port).
+// UDP is connectionless, so only resolving addr can fail.
+func DialStatsD(addr string) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("statsd: %w", err)
+	}
+	return &StatsD{conn: conn}, nil
+}
+
+// Count adds n to the counter name.
+func (c *StatsD) Count(name string, n int64) {
+	c.send(name, strconv.FormatInt(n, 10), "c")
+}
+
+// Timing records a duration of the timer name in milliseconds.
+func (c *StatsD) Timing(name string, d time.Duration) {
+	c.send(name, strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64), "ms")
+}
+
+// send writes one metric in the StatsD line format, name:value|type
+func (c *StatsD) send(name, value, kind string) {
+	if c == nil {
+		return
+	}
+	// Errors are ignored, e.g. a refused port reported by the previous write
+	c.conn.Write([]byte(StatsDPrefix + name + ":" + value + "|" + kind))
+}
+
+// Close closes the UDP socket.
+func (c *StatsD) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// listenStatsD returns a UDP socket standing in for a StatsD server
+func listenStatsD(t *testing.T) net.PacketConn {
+	t.Helper()
+	lis, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket: %v", err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	return lis
+}
+
+// readDatagrams reads n datagrams from lis
+func readDatagrams(t *testing.T, lis net.PacketConn, n int) []string {
+	t.Helper()
+	lis.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	var got []string
+	for range n {
+		size, _, err := lis.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom after %d datagrams: %v", len(got), err)
+		}
+		got = append(got, string(buf[:size]))
+	}
+	return got
+}
+
+func TestStreamSendsToStatsD(t *testing.T) {
+	lis := listenStatsD(t)
+	c, err := DialStatsD(lis.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("DialStatsD: %v", err)
+	}
+	defer c.Close()
+
+	s := NewStream("blocks", WithStatsD(c))
+	s.Received(2048)
+	s.ParseError()
+	s.Lag(1500 * time.Microsecond)
+
+	want := []string{
+		"hyperliquid.blocks.received:1|c",
+		"hyperliquid.blocks.bytes:2048|c",
+		"hyperliquid.blocks.parse_errors:1|c",
+		"hyperliquid.blocks.feed_lag:1.5|ms",
+	}
+	got := readDatagrams(t, lis, len(want))
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("datagram %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNewStreamWithoutDestinations(t *testing.T) {
+	s := NewStream("blocks")
+	if s != nil {
+		t.Fatalf("NewStream() = %v, want nil", s)
+	}
+	// A nil Stream and a nil StatsD record nothing
+	s.Received(1)
+	s.ParseError()
+	s.Lag(time.Second)
+	var c *StatsD
+	c.Count("x", 1)
+	if err := c.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+}
+
+func TestDialStatsDRejectsBadAddress(t *testing.T) {
+	if _, err := DialStatsD("no-port"); err == nil {
+		t.Error("DialStatsD accepted an address without a port")
+	}
+}
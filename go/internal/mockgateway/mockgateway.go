@@ -0,0 +1,169 @@
+// Package mockgateway provides an in-process HyperLiquidL1Gateway server that
+// serves canned responses, for exercising the examples' receive loops
+// without a live endpoint.
+package mockgateway
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+)
+
+// Target is the dial target to use with Listener.DialOption.
+const Target = "passthrough:///mockgateway"
+
+// ErrStall can be put in StreamErrors to keep the stream open without sending
+// anything further until the client cancels it.
+var ErrStall = errors.New("mockgateway: stall")
+
+// Server is a gateway serving canned messages. Each stream call sends all
+// messages of its kind and then ends with the next entry of StreamErrors, or
+// cleanly once those are used up.
+type Server struct {
+	pb.UnimplementedHyperLiquidL1GatewayServer
+
+	Blocks       []*pb.Block
+	BlockFills   []*pb.BlockFills
+	Snapshot     *pb.OrderBookSnapshot
+	StreamErrors []error
+	// APIKey, when set, is the x-api-key every call requires
+	APIKey string
+
+	mu       sync.Mutex
+	calls    int
+	metadata metadata.MD
+}
+
+// StreamBlocks sends the canned blocks.
+func (s *Server) StreamBlocks(_ *pb.Timestamp, stream grpc.ServerStreamingServer[pb.Block]) error {
+	s.record(stream.Context())
+	if err := s.authorize(stream.Context()); err != nil {
+		return err
+	}
+	for _, block := range s.Blocks {
+		if err := stream.Send(block); err != nil {
+			return err
+		}
+	}
+	return s.finish(stream.Context())
+}
+
+// StreamBlockFills sends the canned block fills.
+func (s *Server) StreamBlockFills(_ *pb.Timestamp, stream grpc.ServerStreamingServer[pb.BlockFills]) error {
+	s.record(stream.Context())
+	if err := s.authorize(stream.Context()); err != nil {
+		return err
+	}
+	for _, fills := range s.BlockFills {
+		if err := stream.Send(fills); err != nil {
+			return err
+		}
+	}
+	return s.finish(stream.Context())
+}
+
+// GetOrderBookSnapshot returns the canned snapshot.
+func (s *Server) GetOrderBookSnapshot(ctx context.Context, _ *pb.Timestamp) (*pb.OrderBookSnapshot, error) {
+	s.record(ctx)
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.Snapshot == nil {
+		return nil, status.Error(codes.Unimplemented, "no snapshot configured")
+	}
+	return s.Snapshot, nil
+}
+
+// Calls returns how many streams have been served.
+func (s *Server) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// Metadata returns the metadata received with the most recent call.
+func (s *Server) Metadata() metadata.MD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.metadata
+}
+
+// record keeps the metadata of an incoming call
+func (s *Server) record(ctx context.Context) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metadata = md
+}
+
+// authorize rejects a call without the configured API key
+func (s *Server) authorize(ctx context.Context) error {
+	if s.APIKey == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if keys := md.Get("x-api-key"); len(keys) != 1 || keys[0] != s.APIKey {
+		return status.Error(codes.Unauthenticated, "invalid API key")
+	}
+	return nil
+}
+
+// finish ends a stream with the next error, stalling first if it is ErrStall
+func (s *Server) finish(ctx context.Context) error {
+	err := s.nextError()
+	if errors.Is(err, ErrStall) {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return err
+}
+
+func (s *Server) nextError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.calls > len(s.StreamErrors) {
+		return nil
+	}
+	return s.StreamErrors[s.calls-1]
+}
+
+// Listener is a running in-memory gateway.
+type Listener struct {
+	lis    *bufconn.Listener
+	server *grpc.Server
+}
+
+// Listen starts serving s on an in-memory connection.
+func Listen(s *Server) *Listener {
+	l := &Listener{
+		lis:    bufconn.Listen(1024 * 1024),
+		server: grpc.NewServer(),
+	}
+	pb.RegisterHyperLiquidL1GatewayServer(l.server, s)
+	go l.server.Serve(l.lis)
+	return l
+}
+
+// DialOption routes connections to Target through the in-memory listener.
+// The listener does not use TLS.
+func (l *Listener) DialOption() grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return l.lis.DialContext(ctx)
+	})
+}
+
+// Close stops the server.
+func (l *Listener) Close() {
+	l.server.Stop()
+}
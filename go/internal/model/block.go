@@ -0,0 +1,409 @@
+// Package model contains typed representations of the JSON payloads carried
+// by the gateway's Block messages.
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// Block is a decoded replica_cmds block.
+type Block struct {
+	ABCIBlock ABCIBlock `json:"abci_block"`
+	Resps     Resps     `json:"resps"`
+}
+
+// ABCIBlock holds the block header and the actions it contains.
+type ABCIBlock struct {
+	Height              int64          `json:"height"`
+	Time                string         `json:"time"`
+	BlockTime           string         `json:"block_time"`
+	Proposer            string         `json:"proposer"`
+	SignedActionBundles []ActionBundle `json:"signed_action_bundles"`
+}
+
+// Timestamp returns when the block was produced, from its time or
+// block_time field, and false when neither holds a valid time.
+func (a ABCIBlock) Timestamp() (time.Time, bool) {
+	for _, value := range []string{a.Time, a.BlockTime} {
+		if value == "" {
+			continue
+		}
+		if t, err := ParseTime(value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ActionBundle is one [hash, {signed_actions: [...]}] pair of a block.
+type ActionBundle struct {
+	Hash          string
+	SignedActions []SignedAction `json:"signed_actions"`
+}
+
+// SignedAction wraps a single user action.
+type SignedAction struct {
+	Action Action `json:"action"`
+}
+
+// Action is a user action. Only the fields needed for counting are decoded:
+// the nested requests of order, cancel, cancelByCloid, modify and
+// batchModify actions. Actions of other types keep their raw JSON in Raw so
+// new message shapes can still be inspected.
+type Action struct {
+	Type   string            `json:"type"`
+	Orders []json.RawMessage `json:"orders"`
+	// Cancels holds the requests of a cancel action
+	Cancels []Cancel `json:"-"`
+	// CancelsByCloid holds the requests of a cancelByCloid action
+	CancelsByCloid []CancelByCloid `json:"-"`
+	// Modifies holds the requests of a batchModify action, or the single
+	// request of a modify action
+	Modifies []Modify        `json:"-"`
+	Raw      json.RawMessage `json:"-"`
+}
+
+// Cancel asks to cancel an order by its id.
+type Cancel struct {
+	Asset   int   `json:"a"`
+	OrderID int64 `json:"o"`
+}
+
+// CancelByCloid asks to cancel an order by its client order id.
+type CancelByCloid struct {
+	Asset int    `json:"asset"`
+	Cloid string `json:"cloid"`
+}
+
+// Modify replaces a resting order with Order. The order is identified by
+// its id or its client order id, so OrderID is kept as raw JSON.
+type Modify struct {
+	OrderID json.RawMessage `json:"oid"`
+	Order   json.RawMessage `json:"order"`
+}
+
+// Resps holds the per-action responses of a block.
+type Resps struct {
+	Full []ResponseBundle `json:"Full"`
+}
+
+// ResponseBundle is one [hash, [responses...]] pair matching an ActionBundle.
+type ResponseBundle struct {
+	Hash      string
+	Responses []ActionResponse
+}
+
+// ActionResponse is the result of a single action.
+type ActionResponse struct {
+	User string `json:"user"`
+	Res  struct {
+		Status   string `json:"status"`
+		Response struct {
+			Type string `json:"type"`
+			Data struct {
+				Statuses []OrderStatus `json:"statuses"`
+			} `json:"data"`
+		} `json:"response"`
+	} `json:"res"`
+}
+
+// OrderStatus is one entry of an order or cancel response, keyed by its
+// outcome ("resting", "filled", "success", "error", ...).
+type OrderStatus map[string]json.RawMessage
+
+// IsError reports whether the order was rejected.
+func (s OrderStatus) IsError() bool {
+	_, ok := s["error"]
+	return ok
+}
+
+// UnmarshalJSON decodes a status object, or an outcome without details sent
+// as a plain string, such as a cancel's "success", which is keyed with a
+// null value.
+func (s *OrderStatus) UnmarshalJSON(data []byte) error {
+	var outcome string
+	if err := json.Unmarshal(data, &outcome); err == nil {
+		*s = OrderStatus{outcome: nil}
+		return nil
+	}
+	var status map[string]json.RawMessage
+	if err := json.Unmarshal(data, &status); err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
+
+// statusResponseTypes are the response types whose statuses answer the
+// requests of an action one by one: orders, batchModify and both kinds of
+// cancel
+var statusResponseTypes = map[string]bool{"order": true, "cancel": true}
+
+// DecodeBlock decodes a block payload. Fields whose JSON type does not match
+// the model are skipped rather than failing the whole block, so only
+// syntactically invalid payloads return an error.
+func DecodeBlock(data []byte) (*Block, error) {
+	var block Block
+	if err := unmarshalLenient(data, &block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// ActionCounts counts the actions in the block by type. Actions batching
+// several requests count every request they contain, see Action.Count.
+func (b *Block) ActionCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, bundle := range b.ABCIBlock.SignedActionBundles {
+		for _, signedAction := range bundle.SignedActions {
+			action := signedAction.Action
+			if action.Type == "" {
+				continue
+			}
+			counts[action.Type] += action.Count()
+		}
+	}
+	return counts
+}
+
+// OrderStatusCounts counts the successful and rejected statuses of the
+// block's order and cancel responses.
+func (b *Block) OrderStatusCounts() (success, failed int) {
+	for _, bundle := range b.Resps.Full {
+		for _, response := range bundle.Responses {
+			if !statusResponseTypes[response.Res.Response.Type] {
+				continue
+			}
+			for _, status := range response.Res.Response.Data.Statuses {
+				if status == nil {
+					continue
+				}
+				if status.IsError() {
+					failed++
+				} else {
+					success++
+				}
+			}
+		}
+	}
+	return success, failed
+}
+
+// StatusCounts counts the block's order and cancel statuses by the type of
+// the action they answer. Responses are matched to actions by their position
+// in the bundle; statuses of responses without a matching action are counted
+// under the response type.
+func (b *Block) StatusCounts() map[string]int {
+	counts := make(map[string]int)
+	for i, bundle := range b.Resps.Full {
+		var actions []SignedAction
+		if i < len(b.ABCIBlock.SignedActionBundles) {
+			actions = b.ABCIBlock.SignedActionBundles[i].SignedActions
+		}
+		for j, response := range bundle.Responses {
+			if !statusResponseTypes[response.Res.Response.Type] {
+				continue
+			}
+			actionType := response.Res.Response.Type
+			if j < len(actions) && actions[j].Action.Type != "" {
+				actionType = actions[j].Action.Type
+			}
+			for _, status := range response.Res.Response.Data.Statuses {
+				if status != nil {
+					counts[actionType]++
+				}
+			}
+		}
+	}
+	return counts
+}
+
+// Count returns how many actions a counts for: the number of orders,
+// cancels or modifies it batches for order, cancel, cancelByCloid and
+// batchModify actions, otherwise one. An action whose list didn't decode
+// counts as one as well.
+func (a Action) Count() int {
+	var n int
+	var ok bool
+	switch a.Type {
+	case "order":
+		n, ok = len(a.Orders), a.Orders != nil
+	case "cancel":
+		n, ok = len(a.Cancels), a.Cancels != nil
+	case "cancelByCloid":
+		n, ok = len(a.CancelsByCloid), a.CancelsByCloid != nil
+	case "batchModify":
+		n, ok = len(a.Modifies), a.Modifies != nil
+	}
+	if !ok {
+		return 1
+	}
+	return n
+}
+
+// UnmarshalJSON decodes the known action fields and keeps the raw JSON of
+// action types the model does not describe.
+func (a *Action) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Type     string            `json:"type"`
+		Orders   []json.RawMessage `json:"orders"`
+		Cancels  json.RawMessage   `json:"cancels"`
+		Modifies []Modify          `json:"modifies"`
+	}
+	if err := unmarshalLenient(data, &fields); err != nil {
+		return err
+	}
+
+	*a = Action{Type: fields.Type}
+	// The requests of cancel and cancelByCloid share a key but not a shape
+	switch a.Type {
+	case "order":
+		a.Orders = fields.Orders
+	case "cancel":
+		// A missing or malformed list leaves the requests nil
+		_ = unmarshalLenient(fields.Cancels, &a.Cancels)
+	case "cancelByCloid":
+		_ = unmarshalLenient(fields.Cancels, &a.CancelsByCloid)
+	case "modify":
+		var modify Modify
+		if err := unmarshalLenient(data, &modify); err != nil {
+			return err
+		}
+		a.Modifies = []Modify{modify}
+	case "batchModify":
+		a.Modifies = fields.Modifies
+	default:
+		a.Raw = append(json.RawMessage(nil), data...)
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes the [hash, bundle] pair. Entries of any other shape
+// are left empty.
+func (b *ActionBundle) UnmarshalJSON(data []byte) error {
+	hash, body, ok := splitPair(data)
+	if !ok {
+		return nil
+	}
+
+	var bundle struct {
+		SignedActions []SignedAction `json:"signed_actions"`
+	}
+	if err := unmarshalLenient(body, &bundle); err != nil {
+		return err
+	}
+
+	*b = ActionBundle{Hash: hash, SignedActions: bundle.SignedActions}
+	return nil
+}
+
+// UnmarshalJSON decodes the [hash, responses] pair. Entries of any other
+// shape are left empty.
+func (b *ResponseBundle) UnmarshalJSON(data []byte) error {
+	hash, body, ok := splitPair(data)
+	if !ok {
+		return nil
+	}
+
+	var responses []ActionResponse
+	if err := unmarshalLenient(body, &responses); err != nil {
+		return err
+	}
+
+	*b = ResponseBundle{Hash: hash, Responses: responses}
+	return nil
+}
+
+// splitPair splits a two-element JSON array into its string head and raw
+// second element.
+func splitPair(data []byte) (head string, body json.RawMessage, ok bool) {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(data, &pair); err != nil || len(pair) < 2 {
+		return "", nil, false
+	}
+	// The head is informational only, so a non-string value is ignored
+	_ = json.Unmarshal(pair[0], &head)
+	return head, pair[1], true
+}
+
+// unmarshalLenient behaves like json.Unmarshal but ignores values whose type
+// does not match the destination; encoding/json skips those and still fills
+// in every other field.
+func unmarshalLenient(data []byte, v any) error {
+	err := json.Unmarshal(data, v)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return nil
+	}
+	return err
+}
+
+// BlockSummary is the per-block overview printed by the stream_blocks example.
+// The JSON keys are part of its -output json format and must stay stable.
+type BlockSummary struct {
+	Height       int64          `json:"height"`
+	Proposer     string         `json:"proposer"`
+	ActionCounts map[string]int `json:"action_counts"`
+	TotalActions int            `json:"total_actions"`
+	Success      int            `json:"success"`
+	Errors       int            `json:"errors"`
+	// StatusCounts holds the order statuses by the type of action they
+	// answer, see Block.StatusCounts.
+	StatusCounts map[string]int `json:"status_counts"`
+	// Match reports whether every counted action has an order status.
+	Match bool `json:"match"`
+}
+
+// TotalStatuses returns the number of order statuses in the block.
+func (s BlockSummary) TotalStatuses() int {
+	return s.Success + s.Errors
+}
+
+// TypeMismatch is an action type whose action count differs from the number
+// of order statuses answering it.
+type TypeMismatch struct {
+	Type     string
+	Actions  int
+	Statuses int
+}
+
+// TypeMismatches compares the action and status counts of every type and
+// returns the types that differ, sorted by type. Types can differ even when
+// the totals match.
+func (s BlockSummary) TypeMismatches() []TypeMismatch {
+	var mismatches []TypeMismatch
+	for actionType, actions := range s.ActionCounts {
+		if statuses := s.StatusCounts[actionType]; statuses != actions {
+			mismatches = append(mismatches, TypeMismatch{Type: actionType, Actions: actions, Statuses: statuses})
+		}
+	}
+	// Statuses answering no counted action are over-represented as well
+	for statusType, statuses := range s.StatusCounts {
+		if _, ok := s.ActionCounts[statusType]; !ok && statuses > 0 {
+			mismatches = append(mismatches, TypeMismatch{Type: statusType, Statuses: statuses})
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].Type < mismatches[j].Type
+	})
+	return mismatches
+}
+
+// Summary counts the block's actions and order statuses.
+func (b *Block) Summary() BlockSummary {
+	summary := BlockSummary{
+		Height:       b.ABCIBlock.Height,
+		Proposer:     b.ABCIBlock.Proposer,
+		ActionCounts: b.ActionCounts(),
+	}
+	for _, count := range summary.ActionCounts {
+		summary.TotalActions += count
+	}
+	summary.Success, summary.Errors = b.OrderStatusCounts()
+	summary.StatusCounts = b.StatusCounts()
+	summary.Match = summary.TotalActions == summary.TotalStatuses()
+	return summary
+}
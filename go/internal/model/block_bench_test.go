@@ -0,0 +1,182 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// largeBlockCopies is how many times the bundles of block_orders.json are
+// repeated to build the large block
+const largeBlockCopies = 500
+
+// BenchmarkProcessBlock measures decoding a block and counting its actions
+// and statuses with the typed decoder, against the map[string]interface{}
+// walk the examples used before it as a baseline. Run it with
+//
+//	go test -bench ProcessBlock -benchmem ./internal/model
+func BenchmarkProcessBlock(b *testing.B) {
+	payloads := []struct {
+		name string
+		data []byte
+	}{
+		{"orders", loadFixture(b, "block_orders.json")},
+		{"mixed", loadFixture(b, "block_mixed.json")},
+		{"large", largeBlock(b, loadFixture(b, "block_orders.json"), largeBlockCopies)},
+	}
+
+	for _, p := range payloads {
+		b.Run("typed/"+p.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(p.data)))
+			for i := 0; i < b.N; i++ {
+				block, err := DecodeBlock(p.data)
+				if err != nil {
+					b.Fatal(err)
+				}
+				_ = block.Summary()
+			}
+		})
+		b.Run("interface/"+p.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(p.data)))
+			for i := 0; i < b.N; i++ {
+				if _, err := interfaceSummary(p.data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// largeBlock repeats the action bundles and responses of data copies times,
+// standing in for a busy block
+func largeBlock(b *testing.B, data []byte, copies int) []byte {
+	b.Helper()
+
+	var block struct {
+		ABCIBlock map[string]json.RawMessage `json:"abci_block"`
+		Resps     struct {
+			Full []json.RawMessage `json:"Full"`
+		} `json:"resps"`
+	}
+	if err := json.Unmarshal(data, &block); err != nil {
+		b.Fatal(err)
+	}
+
+	var bundles []json.RawMessage
+	if err := json.Unmarshal(block.ABCIBlock["signed_action_bundles"], &bundles); err != nil {
+		b.Fatal(err)
+	}
+	var allBundles, allResps []json.RawMessage
+	for i := 0; i < copies; i++ {
+		allBundles = append(allBundles, bundles...)
+		allResps = append(allResps, block.Resps.Full...)
+	}
+
+	var err error
+	if block.ABCIBlock["signed_action_bundles"], err = json.Marshal(allBundles); err != nil {
+		b.Fatal(err)
+	}
+	block.Resps.Full = allResps
+	large, err := json.Marshal(block)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return large
+}
+
+// interfaceSummary counts actions and order statuses by type-asserting their
+// way through a generic decode, as the examples did before the typed
+// decoder. It is kept only as the benchmark baseline.
+func interfaceSummary(data []byte) (BlockSummary, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return BlockSummary{}, err
+	}
+
+	summary := BlockSummary{ActionCounts: make(map[string]int)}
+	abciBlock, _ := raw["abci_block"].(map[string]interface{})
+	if height, ok := abciBlock["height"].(float64); ok {
+		summary.Height = int64(height)
+	}
+	summary.Proposer, _ = abciBlock["proposer"].(string)
+
+	bundles, _ := abciBlock["signed_action_bundles"].([]interface{})
+	for _, bundle := range bundles {
+		pair, ok := bundle.([]interface{})
+		if !ok || len(pair) < 2 {
+			continue
+		}
+		bundleData, _ := pair[1].(map[string]interface{})
+		signedActions, _ := bundleData["signed_actions"].([]interface{})
+		for _, signedAction := range signedActions {
+			signedActionMap, _ := signedAction.(map[string]interface{})
+			action, _ := signedActionMap["action"].(map[string]interface{})
+			actionType, ok := action["type"].(string)
+			if !ok {
+				continue
+			}
+			if orders, ok := action["orders"].([]interface{}); ok && actionType == "order" {
+				summary.ActionCounts[actionType] += len(orders)
+			} else {
+				summary.ActionCounts[actionType]++
+			}
+		}
+	}
+	for _, count := range summary.ActionCounts {
+		summary.TotalActions += count
+	}
+
+	resps, _ := raw["resps"].(map[string]interface{})
+	full, _ := resps["Full"].([]interface{})
+	for _, item := range full {
+		pair, ok := item.([]interface{})
+		if !ok || len(pair) < 2 {
+			continue
+		}
+		entries, _ := pair[1].([]interface{})
+		for _, entry := range entries {
+			entryMap, _ := entry.(map[string]interface{})
+			res, _ := entryMap["res"].(map[string]interface{})
+			response, _ := res["response"].(map[string]interface{})
+			if responseType, _ := response["type"].(string); responseType != "order" {
+				continue
+			}
+			responseData, _ := response["data"].(map[string]interface{})
+			statuses, _ := responseData["statuses"].([]interface{})
+			for _, status := range statuses {
+				statusMap, ok := status.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if _, hasError := statusMap["error"]; hasError {
+					summary.Errors++
+				} else {
+					summary.Success++
+				}
+			}
+		}
+	}
+
+	summary.Match = summary.TotalActions == summary.TotalStatuses()
+	return summary, nil
+}
+
+func TestInterfaceSummaryMatchesTyped(t *testing.T) {
+	data := loadFixture(t, "block_orders.json")
+	block, err := DecodeBlock(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := interfaceSummary(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The baseline doesn't pair statuses with their actions
+	want := block.Summary()
+	want.StatusCounts = nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("interfaceSummary = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,210 @@
+package model
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func loadFixture(t testing.TB, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return data
+}
+
+func TestBlockSummary(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    BlockSummary
+	}{
+		{
+			fixture: "block_orders.json",
+			want: BlockSummary{
+				Height:       761244301,
+				Proposer:     "0x5ac99df645f3414876c816caa18b2d234024b487",
+				ActionCounts: map[string]int{"order": 3},
+				TotalActions: 3,
+				Success:      2,
+				Errors:       1,
+				StatusCounts: map[string]int{"order": 3},
+				Match:        true,
+			},
+		},
+		{
+			// Malformed entries are skipped and the evmRawTx has no
+			// statuses, so the block does not reconcile
+			fixture: "block_mixed.json",
+			want: BlockSummary{
+				Height:       761244302,
+				Proposer:     "0x80f0cd23da5bf3a0101110cfd0f89c8a69a1384d",
+				ActionCounts: map[string]int{"order": 2, "cancel": 2, "evmRawTx": 1},
+				TotalActions: 5,
+				Success:      4,
+				Errors:       0,
+				StatusCounts: map[string]int{"order": 2, "cancel": 2},
+				Match:        false,
+			},
+		},
+		{
+			// Batched cancels and modifies count every request, and the
+			// cancel and order statuses are matched to them by position
+			fixture: "block_cancels_modifies.json",
+			want: BlockSummary{
+				Height:       761244304,
+				Proposer:     "0x5ac99df645f3414876c816caa18b2d234024b487",
+				ActionCounts: map[string]int{"cancel": 3, "cancelByCloid": 2, "batchModify": 2},
+				TotalActions: 7,
+				Success:      6,
+				Errors:       1,
+				StatusCounts: map[string]int{"cancel": 3, "cancelByCloid": 2, "batchModify": 2},
+				Match:        true,
+			},
+		},
+		{
+			fixture: "block_empty.json",
+			want: BlockSummary{
+				Height:       761244303,
+				Proposer:     "0x5ac99df645f3414876c816caa18b2d234024b487",
+				ActionCounts: map[string]int{},
+				StatusCounts: map[string]int{},
+				Match:        true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			block, err := DecodeBlock(loadFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("DecodeBlock: %v", err)
+			}
+			if got := block.Summary(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Summary() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypeMismatches(t *testing.T) {
+	block, err := DecodeBlock(loadFixture(t, "block_mixed.json"))
+	if err != nil {
+		t.Fatalf("DecodeBlock: %v", err)
+	}
+
+	want := []TypeMismatch{{Type: "evmRawTx", Actions: 1, Statuses: 0}}
+	if got := block.Summary().TypeMismatches(); !reflect.DeepEqual(got, want) {
+		t.Errorf("TypeMismatches() = %+v, want %+v", got, want)
+	}
+
+	block, err = DecodeBlock(loadFixture(t, "block_orders.json"))
+	if err != nil {
+		t.Fatalf("DecodeBlock: %v", err)
+	}
+	if got := block.Summary().TypeMismatches(); len(got) != 0 {
+		t.Errorf("TypeMismatches() of a matching block = %+v, want none", got)
+	}
+}
+
+func TestStatusCountsWithoutMatchingAction(t *testing.T) {
+	// An order response beyond the bundle's actions keeps its response type
+	block, err := DecodeBlock([]byte(`{
+		"abci_block": {"signed_action_bundles": [["0x1", {"signed_actions": []}]]},
+		"resps": {"Full": [["0x1", [{"res": {"status": "ok", "response": {"type": "order", "data": {"statuses": [{"filled": {}}]}}}}]]]}
+	}`))
+	if err != nil {
+		t.Fatalf("DecodeBlock: %v", err)
+	}
+
+	summary := block.Summary()
+	want := []TypeMismatch{{Type: "order", Actions: 0, Statuses: 1}}
+	if got := summary.TypeMismatches(); !reflect.DeepEqual(got, want) {
+		t.Errorf("TypeMismatches() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeCancelAndModifyActions(t *testing.T) {
+	block, err := DecodeBlock(loadFixture(t, "block_cancels_modifies.json"))
+	if err != nil {
+		t.Fatalf("DecodeBlock: %v", err)
+	}
+	actions := block.ABCIBlock.SignedActionBundles[0].SignedActions
+	if len(actions) != 3 {
+		t.Fatalf("decoded %d actions, want 3", len(actions))
+	}
+
+	cancel := actions[0].Action
+	if len(cancel.Cancels) != 3 || cancel.Cancels[2] != (Cancel{Asset: 5, OrderID: 199337070903}) {
+		t.Errorf("cancel requests = %+v", cancel.Cancels)
+	}
+	byCloid := actions[1].Action
+	if len(byCloid.CancelsByCloid) != 2 || byCloid.CancelsByCloid[1].Cloid != "0x00000000000000000000000000000002" {
+		t.Errorf("cancelByCloid requests = %+v", byCloid.CancelsByCloid)
+	}
+	batch := actions[2].Action
+	if len(batch.Modifies) != 2 || string(batch.Modifies[1].OrderID) != `"0x00000000000000000000000000000003"` {
+		t.Errorf("batchModify requests = %+v", batch.Modifies)
+	}
+
+	var modify Action
+	if err := json.Unmarshal([]byte(`{"type": "modify", "oid": 199337070904, "order": {"a": 1, "b": true, "p": "4000.0", "s": "0.1", "r": false, "t": {"limit": {"tif": "Gtc"}}}}`), &modify); err != nil {
+		t.Fatalf("decode modify: %v", err)
+	}
+	if len(modify.Modifies) != 1 || string(modify.Modifies[0].OrderID) != "199337070904" || len(modify.Modifies[0].Order) == 0 {
+		t.Errorf("modify request = %+v", modify.Modifies)
+	}
+	var malformed Action
+	if err := json.Unmarshal([]byte(`{"type": "cancel", "cancels": "not-a-list"}`), &malformed); err != nil {
+		t.Fatalf("decode malformed cancel: %v", err)
+	}
+	if malformed.Cancels != nil || malformed.Count() != 1 {
+		t.Errorf("malformed cancel = %+v, count %d; want no requests, count 1", malformed.Cancels, malformed.Count())
+	}
+
+	// Known shapes don't keep their raw JSON
+	for _, action := range []Action{actions[0].Action, actions[1].Action, batch, modify, malformed} {
+		if action.Raw != nil {
+			t.Errorf("%s action kept its raw JSON", action.Type)
+		}
+	}
+}
+
+func TestDecodeStringStatuses(t *testing.T) {
+	var statuses []OrderStatus
+	if err := json.Unmarshal([]byte(`["success", {"error": "Order was never placed."}, {"resting": {"oid": 1}}]`), &statuses); err != nil {
+		t.Fatalf("decode statuses: %v", err)
+	}
+	if len(statuses) != 3 || statuses[0].IsError() || !statuses[1].IsError() || statuses[2].IsError() {
+		t.Errorf("statuses = %v", statuses)
+	}
+	if _, ok := statuses[0]["success"]; !ok {
+		t.Errorf("string status = %v, want keyed by its outcome", statuses[0])
+	}
+}
+
+func TestDecodeBlockKeepsRawUnknownActions(t *testing.T) {
+	block, err := DecodeBlock(loadFixture(t, "block_mixed.json"))
+	if err != nil {
+		t.Fatalf("DecodeBlock: %v", err)
+	}
+
+	for _, signed := range block.ABCIBlock.SignedActionBundles[0].SignedActions {
+		if signed.Action.Type == "evmRawTx" {
+			if len(signed.Action.Raw) == 0 {
+				t.Error("evmRawTx action has no raw JSON")
+			}
+			return
+		}
+	}
+	t.Error("evmRawTx action not decoded")
+}
+
+func TestDecodeBlockInvalidJSON(t *testing.T) {
+	if _, err := DecodeBlock([]byte(`{"abci_block": `)); err == nil {
+		t.Error("DecodeBlock accepted truncated JSON")
+	}
+}
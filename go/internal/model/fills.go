@@ -0,0 +1,29 @@
+package model
+
+import "encoding/json"
+
+// BlockFills is a block fills payload as streamed by StreamBlockFills.
+type BlockFills struct {
+	Height int64  `json:"height"`
+	Time   int64  `json:"time"`
+	Fills  []Fill `json:"fills"`
+}
+
+// Fill is a single fill. Price and size are kept as the decimal strings
+// sent by the gateway.
+type Fill struct {
+	Symbol string `json:"symbol"`
+	Side   string `json:"side"`
+	Price  string `json:"price"`
+	Size   string `json:"size"`
+	Hash   string `json:"hash"`
+}
+
+// DecodeBlockFills decodes a block fills payload.
+func DecodeBlockFills(data []byte) (*BlockFills, error) {
+	var blockFills BlockFills
+	if err := json.Unmarshal(data, &blockFills); err != nil {
+		return nil, err
+	}
+	return &blockFills, nil
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+// millisThreshold separates Unix timestamps in seconds from those in
+// milliseconds: the feed uses milliseconds, which are always larger.
+const millisThreshold = 10_000_000_000
+
+// UnixTime converts a Unix timestamp in seconds or milliseconds to a time.
+func UnixTime(ts int64) time.Time {
+	if ts > millisThreshold {
+		return time.UnixMilli(ts)
+	}
+	return time.Unix(ts, 0)
+}
+
+// UnixMillis normalizes a Unix timestamp in seconds or milliseconds to
+// milliseconds.
+func UnixMillis(ts int64) int64 {
+	if ts > millisThreshold {
+		return ts
+	}
+	return ts * 1000
+}
+
+// blockTimeLayout is the layout of block times, which are UTC without a zone
+const blockTimeLayout = "2006-01-02T15:04:05.999999999"
+
+// ParseTime parses a block time: an RFC 3339 time, a UTC time without a zone
+// as sent in blocks, or a Unix timestamp in seconds or milliseconds.
+func ParseTime(s string) (time.Time, error) {
+	if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return UnixTime(ts), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(blockTimeLayout, s)
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	want := time.Date(2025, 10, 14, 7, 22, 47, 793012000, time.UTC)
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2025-10-14T07:22:47.793012", want},
+		{"2025-10-14T07:22:47.793012Z", want},
+		{"2025-10-14T09:22:47.793012+02:00", want},
+		{"1760426567793", want.Truncate(time.Millisecond)},
+		{"1760426567", want.Truncate(time.Second)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.input)
+		if err != nil {
+			t.Errorf("ParseTime(%q) error: %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	if _, err := ParseTime("yesterday"); err == nil {
+		t.Error("ParseTime(\"yesterday\") succeeded, want an error")
+	}
+}
+
+func TestBlockTimestamp(t *testing.T) {
+	block, err := DecodeBlock(loadFixture(t, "block_mixed.json"))
+	if err != nil {
+		t.Fatalf("DecodeBlock: %v", err)
+	}
+	got, ok := block.ABCIBlock.Timestamp()
+	if want := time.Date(2025, 10, 14, 7, 22, 47, 793012000, time.UTC); !ok || !got.Equal(want) {
+		t.Errorf("Timestamp() = %v, %v, want %v, true", got, ok, want)
+	}
+
+	if _, ok := (ABCIBlock{}).Timestamp(); ok {
+		t.Error("Timestamp() of a block without time succeeded")
+	}
+}
@@ -0,0 +1,249 @@
+// Package orderbook parses the bid and ask ladders of orderbook snapshots.
+package orderbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/decimal"
+)
+
+// Level is one price level of a ladder.
+type Level struct {
+	Price  *big.Rat
+	Size   *big.Rat
+	Orders int
+}
+
+// Ladders holds both sides of the book.
+type Ladders struct {
+	Bids []Level
+	Asks []Level
+}
+
+// rawLevel is a level as encoded in the snapshot
+type rawLevel struct {
+	Px string `json:"px"`
+	Sz string `json:"sz"`
+	N  int    `json:"n"`
+}
+
+// ParseLevels parses a snapshot's "levels" value, which is expected to be a
+// two-element array of bid and ask ladders. An error is returned for any
+// other shape so callers can fall back to a raw display.
+func ParseLevels(raw []byte) (*Ladders, error) {
+	var sides [][]rawLevel
+	if err := json.Unmarshal(raw, &sides); err != nil {
+		return nil, fmt.Errorf("levels are not [bids, asks] ladders: %w", err)
+	}
+	if len(sides) != 2 {
+		return nil, fmt.Errorf("expected 2 ladders, got %d", len(sides))
+	}
+
+	bids, err := parseSide(sides[0])
+	if err != nil {
+		return nil, fmt.Errorf("bids: %w", err)
+	}
+	asks, err := parseSide(sides[1])
+	if err != nil {
+		return nil, fmt.Errorf("asks: %w", err)
+	}
+	return &Ladders{Bids: bids, Asks: asks}, nil
+}
+
+// ParseSnapshot parses the "levels" of a whole snapshot message, see
+// ParseLevels.
+func ParseSnapshot(data []byte) (*Ladders, error) {
+	var snapshot struct {
+		Levels json.RawMessage `json:"levels"`
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return ParseLevels(snapshot.Levels)
+}
+
+func parseSide(raw []rawLevel) ([]Level, error) {
+	levels := make([]Level, len(raw))
+	for i, r := range raw {
+		level, err := parseLevel(r)
+		if err != nil {
+			return nil, fmt.Errorf("level %d: %w", i, err)
+		}
+		levels[i] = level
+	}
+	return levels, nil
+}
+
+func parseLevel(r rawLevel) (Level, error) {
+	price, err := decimal.Parse(r.Px)
+	if err != nil {
+		return Level{}, fmt.Errorf("price: %w", err)
+	}
+	size, err := decimal.Parse(r.Sz)
+	if err != nil {
+		return Level{}, fmt.Errorf("size: %w", err)
+	}
+	return Level{Price: price, Size: size, Orders: r.N}, nil
+}
+
+// BestBid returns the highest bid, with ok false when there are no bids.
+func (l *Ladders) BestBid() (best Level, ok bool) {
+	return bestLevel(l.Bids, func(price, best *big.Rat) bool { return price.Cmp(best) > 0 })
+}
+
+// BestAsk returns the lowest ask, with ok false when there are no asks.
+func (l *Ladders) BestAsk() (best Level, ok bool) {
+	return bestLevel(l.Asks, func(price, best *big.Rat) bool { return price.Cmp(best) < 0 })
+}
+
+// Spread returns best ask minus best bid, with ok false when either side is
+// empty.
+func (l *Ladders) Spread() (spread *big.Rat, ok bool) {
+	bid, hasBid := l.BestBid()
+	ask, hasAsk := l.BestAsk()
+	if !hasBid || !hasAsk {
+		return nil, false
+	}
+	return new(big.Rat).Sub(ask.Price, bid.Price), true
+}
+
+// Mid returns the mid-price, the average of the best bid and best ask, with
+// ok false when either side is empty.
+func (l *Ladders) Mid() (mid *big.Rat, ok bool) {
+	bid, hasBid := l.BestBid()
+	ask, hasAsk := l.BestAsk()
+	if !hasBid || !hasAsk {
+		return nil, false
+	}
+	mid = new(big.Rat).Add(bid.Price, ask.Price)
+	return mid.Quo(mid, big.NewRat(2, 1)), true
+}
+
+// Microprice returns the mid-price weighted by the size on the other side
+// of the top of book, (bid × ask size + ask × bid size) / (bid size + ask
+// size). It leans towards the ask when more size is bid, where the price is
+// more likely to move next. ok is false when either side is empty or both
+// best levels have no size.
+func (l *Ladders) Microprice() (microprice *big.Rat, ok bool) {
+	bid, hasBid := l.BestBid()
+	ask, hasAsk := l.BestAsk()
+	if !hasBid || !hasAsk {
+		return nil, false
+	}
+	total := new(big.Rat).Add(bid.Size, ask.Size)
+	if total.Sign() == 0 {
+		return nil, false
+	}
+	microprice = new(big.Rat).Mul(bid.Price, ask.Size)
+	microprice.Add(microprice, new(big.Rat).Mul(ask.Price, bid.Size))
+	return microprice.Quo(microprice, total), true
+}
+
+// Imbalance returns the share of bid size in the size of the best depth
+// levels of each side, bid size / (bid size + ask size): 1 is only bids,
+// 0.5 balanced and 0 only asks. A side with fewer levels counts them all.
+// ok is false when depth is less than 1 or there is no size at all.
+func (l *Ladders) Imbalance(depth int) (imbalance *big.Rat, ok bool) {
+	if depth < 1 {
+		return nil, false
+	}
+	bidSize := TotalSize(topLevels(l.Bids, depth, 1))
+	total := new(big.Rat).Add(bidSize, TotalSize(topLevels(l.Asks, depth, -1)))
+	if total.Sign() == 0 {
+		return nil, false
+	}
+	return bidSize.Quo(bidSize, total), true
+}
+
+// topLevels returns the best depth levels, better being the sign of the
+// price comparison that makes a level better. Ladders are normally sorted
+// best first, but the order is not relied on.
+func topLevels(levels []Level, depth, better int) []Level {
+	sorted := append([]Level(nil), levels...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Price.Cmp(sorted[j].Price) == better
+	})
+	return sorted[:min(depth, len(sorted))]
+}
+
+// TotalSize sums the size of levels.
+func TotalSize(levels []Level) *big.Rat {
+	total := new(big.Rat)
+	for _, level := range levels {
+		total.Add(total, level.Size)
+	}
+	return total
+}
+
+// bestLevel returns the level whose price is preferred by better. Ladders
+// are normally sorted best first, but the order is not relied on.
+func bestLevel(levels []Level, better func(price, best *big.Rat) bool) (Level, bool) {
+	if len(levels) == 0 {
+		return Level{}, false
+	}
+	best := levels[0]
+	for _, level := range levels[1:] {
+		if better(level.Price, best.Price) {
+			best = level
+		}
+	}
+	return best, true
+}
+
+// Kinds of level changes reported by Diff
+const (
+	Added   = "added"
+	Removed = "removed"
+	Resized = "resized"
+)
+
+// Change is a price level that differs between two snapshots.
+type Change struct {
+	// Side is "bid" or "ask"
+	Side  string
+	Kind  string
+	Price *big.Rat
+	// OldSize is nil for added levels, NewSize for removed ones
+	OldSize *big.Rat
+	NewSize *big.Rat
+}
+
+// Diff compares the levels of prev and next by price and returns the levels
+// that were added, removed or resized, bids before asks and each side in
+// ascending price order. Order counts alone don't make a change.
+func Diff(prev, next *Ladders) []Change {
+	changes := diffSide("bid", prev.Bids, next.Bids)
+	return append(changes, diffSide("ask", prev.Asks, next.Asks)...)
+}
+
+func diffSide(side string, prev, next []Level) []Change {
+	// Prices are keyed by their exact rational form, so "1.50" and "1.5" match
+	before := make(map[string]Level, len(prev))
+	for _, level := range prev {
+		before[level.Price.RatString()] = level
+	}
+
+	var changes []Change
+	for _, level := range next {
+		key := level.Price.RatString()
+		old, ok := before[key]
+		delete(before, key)
+		switch {
+		case !ok:
+			changes = append(changes, Change{Side: side, Kind: Added, Price: level.Price, NewSize: level.Size})
+		case old.Size.Cmp(level.Size) != 0:
+			changes = append(changes, Change{Side: side, Kind: Resized, Price: level.Price, OldSize: old.Size, NewSize: level.Size})
+		}
+	}
+	for _, old := range before {
+		changes = append(changes, Change{Side: side, Kind: Removed, Price: old.Price, OldSize: old.Size})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Price.Cmp(changes[j].Price) < 0
+	})
+	return changes
+}
@@ -0,0 +1,140 @@
+package orderbook
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func mustParse(t *testing.T, levels string) *Ladders {
+	t.Helper()
+	ladders, err := ParseLevels([]byte(levels))
+	if err != nil {
+		t.Fatalf("ParseLevels(%s): %v", levels, err)
+	}
+	return ladders
+}
+
+func TestParseLevels(t *testing.T) {
+	ladders := mustParse(t, `[[{"px":"100.5","sz":"2","n":3},{"px":"100","sz":"1","n":1}],[{"px":"101","sz":"0.5","n":2}]]`)
+
+	bid, ok := ladders.BestBid()
+	if !ok || bid.Price.FloatString(1) != "100.5" || bid.Orders != 3 {
+		t.Errorf("best bid = %+v", bid)
+	}
+	spread, ok := ladders.Spread()
+	if !ok || spread.FloatString(1) != "0.5" {
+		t.Errorf("spread = %v, %v; want 0.5", spread, ok)
+	}
+
+	if _, err := ParseLevels([]byte(`[[{"px":"1/3","sz":"1","n":1}],[]]`)); err == nil {
+		t.Error("ParseLevels accepted a fraction as price")
+	}
+	if _, err := ParseLevels([]byte(`[[]]`)); err == nil {
+		t.Error("ParseLevels accepted a single ladder")
+	}
+}
+
+func TestParseSnapshot(t *testing.T) {
+	ladders, err := ParseSnapshot([]byte(`{"time":1760426567000,"levels":[[{"px":"100","sz":"1","n":1}],[{"px":"101","sz":"2","n":1}]]}`))
+	if err != nil {
+		t.Fatalf("ParseSnapshot: %v", err)
+	}
+	if len(ladders.Bids) != 1 || len(ladders.Asks) != 1 {
+		t.Errorf("got %d bids and %d asks, want 1 each", len(ladders.Bids), len(ladders.Asks))
+	}
+
+	if _, err := ParseSnapshot([]byte(`{"time":1760426567000}`)); err == nil {
+		t.Error("ParseSnapshot accepted a snapshot without levels")
+	}
+}
+
+func TestMicrostructure(t *testing.T) {
+	// Asks are sent out of order on purpose
+	ladders := mustParse(t, `[
+		[{"px":"100","sz":"3","n":1},{"px":"99","sz":"1","n":1},{"px":"98","sz":"4","n":1}],
+		[{"px":"103","sz":"2","n":1},{"px":"101","sz":"1","n":1},{"px":"102","sz":"1","n":1}]
+	]`)
+
+	if mid, ok := ladders.Mid(); !ok || mid.RatString() != "201/2" {
+		t.Errorf("Mid = %v, %v; want 100.5", mid, ok)
+	}
+	// (100 × 1 + 101 × 3) / (3 + 1)
+	if microprice, ok := ladders.Microprice(); !ok || microprice.RatString() != "403/4" {
+		t.Errorf("Microprice = %v, %v; want 100.75", microprice, ok)
+	}
+
+	tests := []struct {
+		depth int
+		want  string
+	}{
+		{1, "3/4"},   // 3 / (3 + 1)
+		{2, "2/3"},   // 4 / (4 + 2)
+		{3, "8/12"},  // 8 / (8 + 4)
+		{10, "8/12"}, // every level
+	}
+	for _, tt := range tests {
+		want, _ := new(big.Rat).SetString(tt.want)
+		if got, ok := ladders.Imbalance(tt.depth); !ok || got.Cmp(want) != 0 {
+			t.Errorf("Imbalance(%d) = %v, %v; want %v", tt.depth, got, ok, want)
+		}
+	}
+	if _, ok := ladders.Imbalance(0); ok {
+		t.Error("Imbalance(0) is ok")
+	}
+
+	oneSided := mustParse(t, `[[{"px":"100","sz":"1","n":1}],[]]`)
+	if _, ok := oneSided.Mid(); ok {
+		t.Error("Mid of a one-sided book is ok")
+	}
+	if _, ok := oneSided.Microprice(); ok {
+		t.Error("Microprice of a one-sided book is ok")
+	}
+	if imbalance, ok := oneSided.Imbalance(5); !ok || imbalance.Cmp(big.NewRat(1, 1)) != 0 {
+		t.Errorf("Imbalance of bids only = %v, %v; want 1", imbalance, ok)
+	}
+
+	empty := mustParse(t, `[[{"px":"100","sz":"0","n":0}],[{"px":"101","sz":"0","n":0}]]`)
+	if _, ok := empty.Microprice(); ok {
+		t.Error("Microprice without size is ok")
+	}
+	if _, ok := empty.Imbalance(1); ok {
+		t.Error("Imbalance without size is ok")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	prev := mustParse(t, `[
+		[{"px":"100","sz":"1","n":1},{"px":"99","sz":"2","n":1},{"px":"98","sz":"3","n":1}],
+		[{"px":"101","sz":"1","n":1},{"px":"102","sz":"4","n":2}]
+	]`)
+	next := mustParse(t, `[
+		[{"px":"100.0","sz":"1","n":5},{"px":"99","sz":"2.5","n":1},{"px":"97","sz":"1","n":1}],
+		[{"px":"101","sz":"1","n":1}]
+	]`)
+
+	var got []string
+	for _, c := range Diff(prev, next) {
+		got = append(got, fmt.Sprintf("%s %s %s %v->%v", c.Side, c.Kind, c.Price.RatString(), ratString(c.OldSize), ratString(c.NewSize)))
+	}
+	want := []string{
+		"bid added 97 -->1",
+		"bid removed 98 3->-",
+		"bid resized 99 2->5/2",
+		"ask removed 102 4->-",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Diff =\n%q\nwant\n%q", got, want)
+	}
+
+	if changes := Diff(next, next); len(changes) != 0 {
+		t.Errorf("Diff of identical ladders = %v, want none", changes)
+	}
+}
+
+func ratString(r *big.Rat) string {
+	if r == nil {
+		return "-"
+	}
+	return r.RatString()
+}
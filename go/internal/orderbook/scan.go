@@ -0,0 +1,169 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// SideSummary is one side of the book as counted by Scan.
+type SideSummary struct {
+	Levels    int
+	TotalSize *big.Rat
+	// Best is the best level, zero when there are no levels
+	Best Level
+	// Sample holds the first levels in the order they were sent
+	Sample []Level
+}
+
+// Summary is what Scan extracts from a snapshot without keeping its levels.
+type Summary struct {
+	// Keys are the top-level keys in the order they were sent
+	Keys []string
+	// Time is the raw "time" value, nil when there is none
+	Time json.RawMessage
+	// HasLevels reports whether the snapshot has "levels"
+	HasLevels bool
+	Bids      SideSummary
+	Asks      SideSummary
+}
+
+// Spread returns best ask minus best bid, with ok false when either side is
+// empty.
+func (s *Summary) Spread() (spread *big.Rat, ok bool) {
+	if s.Bids.Levels == 0 || s.Asks.Levels == 0 {
+		return nil, false
+	}
+	return new(big.Rat).Sub(s.Asks.Best.Price, s.Bids.Best.Price), true
+}
+
+// Scan reads a snapshot from r token by token and summarises its [bids,
+// asks] ladders, keeping at most sample levels per side. Only one level is
+// decoded at a time, so memory use doesn't grow with the size of the book.
+// Other values than "time" are skipped without being decoded. Levels of any
+// other shape than ParseLevels accepts are an error.
+func Scan(r io.Reader, sample int) (*Summary, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	s := &Summary{}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+		s.Keys = append(s.Keys, key)
+
+		switch key {
+		case "time":
+			if err := dec.Decode(&s.Time); err != nil {
+				return nil, fmt.Errorf("time: %w", err)
+			}
+		case "levels":
+			s.HasLevels = true
+			if err := scanLevels(dec, s, sample); err != nil {
+				return nil, err
+			}
+		default:
+			if err := skipValue(dec); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+	return s, expectDelim(dec, '}')
+}
+
+// scanLevels counts the two ladders of a "levels" value into s
+func scanLevels(dec *json.Decoder, s *Summary, sample int) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return fmt.Errorf("levels are not [bids, asks] ladders: %w", err)
+	}
+
+	// A bid is better when its price is higher, an ask when it is lower
+	sides := []struct {
+		name    string
+		summary *SideSummary
+		better  int
+	}{{"bids", &s.Bids, 1}, {"asks", &s.Asks, -1}}
+	count := 0
+	for ; dec.More(); count++ {
+		if count == len(sides) {
+			return errors.New("expected 2 ladders, got more")
+		}
+		side := sides[count]
+		if err := scanSide(dec, side.summary, side.better, sample); err != nil {
+			return fmt.Errorf("%s: %w", side.name, err)
+		}
+	}
+	if count != len(sides) {
+		return fmt.Errorf("expected 2 ladders, got %d", count)
+	}
+	return expectDelim(dec, ']')
+}
+
+// scanSide counts one ladder into side. better is the sign of the price
+// comparison that makes a level better than the best so far.
+func scanSide(dec *json.Decoder, side *SideSummary, better, sample int) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+
+	side.TotalSize = new(big.Rat)
+	for dec.More() {
+		var raw rawLevel
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("level %d: %w", side.Levels, err)
+		}
+		level, err := parseLevel(raw)
+		if err != nil {
+			return fmt.Errorf("level %d: %w", side.Levels, err)
+		}
+
+		if side.Levels == 0 || level.Price.Cmp(side.Best.Price) == better {
+			side.Best = level
+		}
+		side.TotalSize.Add(side.TotalSize, level.Size)
+		if len(side.Sample) < sample {
+			side.Sample = append(side.Sample, level)
+		}
+		side.Levels++
+	}
+	return expectDelim(dec, ']')
+}
+
+// expectDelim reads the next token and fails unless it is delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+// skipValue reads past the next value, nested or not, one token at a time
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('['), json.Delim('{'):
+			depth++
+		case json.Delim(']'), json.Delim('}'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
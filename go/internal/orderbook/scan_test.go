@@ -0,0 +1,77 @@
+package orderbook
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	snapshot := `{"coin":{"meta":[1,{"x":[]}]},"time":1760426567000,"levels":[` +
+		`[{"px":"100","sz":"1","n":1},{"px":"100.5","sz":"2","n":3},{"px":"99","sz":"0.25","n":1}],` +
+		`[{"px":"101","sz":"0.5","n":2},{"px":"102","sz":"4","n":1}]]}`
+
+	summary, err := Scan(strings.NewReader(snapshot), 2)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if strings.Join(summary.Keys, ",") != "coin,time,levels" || string(summary.Time) != "1760426567000" || !summary.HasLevels {
+		t.Errorf("keys %v, time %s, has levels %v", summary.Keys, summary.Time, summary.HasLevels)
+	}
+
+	// The counts match the fully parsed ladders
+	ladders, err := ParseSnapshot([]byte(snapshot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, side := range []struct {
+		name    string
+		summary SideSummary
+		levels  []Level
+	}{{"bids", summary.Bids, ladders.Bids}, {"asks", summary.Asks, ladders.Asks}} {
+		if side.summary.Levels != len(side.levels) || side.summary.TotalSize.Cmp(TotalSize(side.levels)) != 0 {
+			t.Errorf("%s: %d levels of total size %v, want %d of %v", side.name,
+				side.summary.Levels, side.summary.TotalSize, len(side.levels), TotalSize(side.levels))
+		}
+		if len(side.summary.Sample) != min(2, len(side.levels)) || side.summary.Sample[0].Price.Cmp(side.levels[0].Price) != 0 {
+			t.Errorf("%s: sample %+v doesn't start the ladder", side.name, side.summary.Sample)
+		}
+	}
+
+	bid, _ := ladders.BestBid()
+	ask, _ := ladders.BestAsk()
+	if summary.Bids.Best.Price.Cmp(bid.Price) != 0 || summary.Bids.Best.Orders != 3 || summary.Asks.Best.Price.Cmp(ask.Price) != 0 {
+		t.Errorf("best bid %v, best ask %v; want %v and %v", summary.Bids.Best.Price, summary.Asks.Best.Price, bid.Price, ask.Price)
+	}
+	if spread, ok := summary.Spread(); !ok || spread.FloatString(1) != "0.5" {
+		t.Errorf("spread = %v, %v; want 0.5", spread, ok)
+	}
+}
+
+func TestScanWithoutLevels(t *testing.T) {
+	summary, err := Scan(strings.NewReader(`{"time":1}`), 3)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if summary.HasLevels {
+		t.Error("HasLevels is set for a snapshot without levels")
+	}
+	if _, ok := summary.Spread(); ok {
+		t.Error("Spread is ok without levels")
+	}
+}
+
+func TestScanRejectsInvalidSnapshots(t *testing.T) {
+	for name, snapshot := range map[string]string{
+		"not an object":   `[1,2]`,
+		"single ladder":   `{"levels":[[]]}`,
+		"three ladders":   `{"levels":[[],[],[]]}`,
+		"levels object":   `{"levels":{"bids":[]}}`,
+		"fraction price":  `{"levels":[[{"px":"1/3","sz":"1","n":1}],[]]}`,
+		"truncated":       `{"time":1,"levels":[[{"px":"1","sz":"1","n":1}`,
+		"truncated other": `{"coin":{"meta":[1,`,
+	} {
+		if _, err := Scan(strings.NewReader(snapshot), 3); err == nil {
+			t.Errorf("%s: Scan succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package output
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultBufferSize and DefaultFlushInterval are the defaults of the
+// -out-buffer and -out-flush-interval flags.
+const (
+	DefaultBufferSize    = 64 << 10
+	DefaultFlushInterval = 100 * time.Millisecond
+)
+
+// Buffered collects small writes to w, typically returned by Open, and hands
+// them to w in large ones: when size bytes have accumulated, at least every
+// flush interval, and on Flush and Close. On a fast feed this turns one write
+// system call per printed line into one per buffer. It is safe for concurrent
+// use, so a goroutine printing rates can share it with the receive loop.
+type Buffered struct {
+	mu  sync.Mutex
+	w   io.WriteCloser
+	buf *bufio.Writer // nil when writes go straight to w
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewBuffered returns a writer buffering up to size bytes for w and flushing
+// them every interval. A zero interval disables buffering, so every write
+// reaches w immediately. Close the writer to flush what is left.
+func NewBuffered(w io.WriteCloser, size int, interval time.Duration) *Buffered {
+	b := &Buffered{w: w}
+	if interval <= 0 {
+		return b
+	}
+	b.buf = bufio.NewWriterSize(w, size)
+	b.stop = make(chan struct{})
+	b.done = make(chan struct{})
+	go b.flushEvery(interval)
+	return b
+}
+
+// flushEvery flushes the buffer every interval until Close is called. An
+// empty buffer costs nothing, so the ticker is cheap on a quiet stream.
+func (b *Buffered) flushEvery(interval time.Duration) {
+	defer close(b.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.Flush()
+		}
+	}
+}
+
+// Write buffers p, writing the buffer to w first if p doesn't fit.
+func (b *Buffered) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.buf == nil {
+		return b.w.Write(p)
+	}
+	return b.buf.Write(p)
+}
+
+// Flush writes the buffered output to w.
+func (b *Buffered) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.buf == nil || b.buf.Buffered() == 0 {
+		return nil
+	}
+	return b.buf.Flush()
+}
+
+// Close stops the periodic flush, flushes the buffer and closes w. Writes
+// after Close fail or are lost, so call it once all output is written.
+func (b *Buffered) Close() error {
+	if b.stop != nil {
+		close(b.stop)
+		<-b.done
+	}
+	err := b.Flush()
+	if closeErr := b.w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,169 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a WriteCloser counting the writes that reach it
+type recorder struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+	closed bool
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes++
+	return r.buf.Write(p)
+}
+
+func (r *recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+func (r *recorder) state() (string, int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.String(), r.writes, r.closed
+}
+
+func TestBufferedHoldsWritesUntilFlush(t *testing.T) {
+	rec := &recorder{}
+	// An hour never ticks during the test, so only Flush and Close write
+	b := NewBuffered(rec, 1024, time.Hour)
+
+	for i := range 3 {
+		fmt.Fprintf(b, "line %d\n", i)
+	}
+	if got, _, _ := rec.state(); got != "" {
+		t.Fatalf("written before Flush: %q", got)
+	}
+
+	if err := b.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	got, writes, _ := rec.state()
+	if got != "line 0\nline 1\nline 2\n" || writes != 1 {
+		t.Errorf("after Flush: %q in %d writes, want 3 lines in 1 write", got, writes)
+	}
+
+	fmt.Fprintln(b, "last")
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	got, _, closed := rec.state()
+	if got != "line 0\nline 1\nline 2\nlast\n" || !closed {
+		t.Errorf("after Close: %q, closed %v", got, closed)
+	}
+}
+
+func TestBufferedFlushesFullBuffer(t *testing.T) {
+	rec := &recorder{}
+	b := NewBuffered(rec, 16, time.Hour)
+	defer b.Close()
+
+	fmt.Fprint(b, "0123456789")
+	fmt.Fprint(b, "0123456789")
+	if got, _, _ := rec.state(); got != "0123456789012345" {
+		t.Errorf("written once the buffer filled: %q, want its 16 bytes", got)
+	}
+}
+
+func TestBufferedFlushesEveryInterval(t *testing.T) {
+	rec := &recorder{}
+	b := NewBuffered(rec, 1024, 10*time.Millisecond)
+	defer b.Close()
+
+	fmt.Fprintln(b, "tick")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if got, _, _ := rec.state(); got == "tick\n" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("buffered output wasn't flushed by the interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBufferedWithoutInterval(t *testing.T) {
+	rec := &recorder{}
+	b := NewBuffered(rec, 1024, 0)
+
+	fmt.Fprintln(b, "now")
+	if got, writes, _ := rec.state(); got != "now\n" || writes != 1 {
+		t.Errorf("unbuffered write: %q in %d writes", got, writes)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, _, closed := rec.state(); !closed {
+		t.Error("Close didn't close the underlying writer")
+	}
+}
+
+func TestBufferedConcurrentWrites(t *testing.T) {
+	rec := &recorder{}
+	b := NewBuffered(rec, 64, time.Millisecond)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				fmt.Fprintln(b, "0123456789")
+			}
+		}()
+	}
+	wg.Wait()
+	b.Close()
+
+	// Lines are never split by another goroutine's write
+	got, _, _ := rec.state()
+	if want := bytes.Repeat([]byte("0123456789\n"), 400); got != string(want) {
+		t.Errorf("concurrent writes interleaved: %d bytes", len(got))
+	}
+}
+
+// BenchmarkWriteLines measures printing short summary lines to a file, as
+// the streaming examples do per block, written straight to the file against
+// through the default buffer. Run it with
+//
+//	go test -bench WriteLines ./internal/output
+func BenchmarkWriteLines(b *testing.B) {
+	for _, bc := range []struct {
+		name     string
+		interval time.Duration
+	}{
+		{"unbuffered", 0},
+		{"buffered", DefaultFlushInterval},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			f, err := os.Create(filepath.Join(b.TempDir(), "out.log"))
+			if err != nil {
+				b.Fatal(err)
+			}
+			w := NewBuffered(f, DefaultBufferSize, bc.interval)
+			b.ResetTimer()
+			for i := range b.N {
+				fmt.Fprintf(w, "📦 Block #%d: 42 actions, 40 statuses, 38 success\n", i)
+			}
+			if err := w.Close(); err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
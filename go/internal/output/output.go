@@ -0,0 +1,41 @@
+// Package output opens the destination of the examples' human-readable
+// output: stdout by default, or a file chosen with -out-file. Operational
+// logs stay on stderr either way.
+package output
+
+import (
+	"io"
+	"os"
+)
+
+// Open returns a writer for path, creating or truncating the file, or stdout
+// when path is empty. Closing the stdout writer is a no-op.
+func Open(path string) (io.WriteCloser, error) {
+	if path == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
+
+// IsTerminal reports whether w, typically returned by Open or wrapped by
+// NewBuffered, writes to a terminal rather than a file or pipe.
+func IsTerminal(w io.Writer) bool {
+	if b, ok := w.(*Buffered); ok {
+		w = b.w
+	}
+	if n, ok := w.(nopCloser); ok {
+		w = n.Writer
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,119 @@
+// Package parseerr decides what happens to a streamed message that can't be
+// parsed: skip it and keep streaming, dump its bytes to a file for
+// inspection, or exit.
+package parseerr
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/client"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+	"github.com/dwellir/grpc-code-examples/go/internal/util"
+)
+
+// Policy is the action taken on a parse error. It implements flag.Value.
+type Policy string
+
+const (
+	// Skip logs the error and moves on to the next message
+	Skip Policy = "skip"
+	// Dump logs the error and writes the offending bytes to a file
+	Dump Policy = "dump"
+	// Fatal logs the error and exits with client.ExitParseFailure
+	Fatal Policy = "fatal"
+)
+
+func (p *Policy) String() string {
+	return string(*p)
+}
+
+// Set parses a policy name.
+func (p *Policy) Set(s string) error {
+	switch policy := Policy(strings.ToLower(s)); policy {
+	case Skip, Dump, Fatal:
+		*p = policy
+		return nil
+	default:
+		return fmt.Errorf("unknown policy %q (expected skip, dump or fatal)", s)
+	}
+}
+
+// rawPreview is how many bytes of the offending message are included in the log
+const rawPreview = 200
+
+// Handler applies a Policy to the parse errors of one kind of message.
+type Handler struct {
+	Policy Policy
+	// Kind names the message in logs and dump file names, e.g. "block"
+	Kind string
+	// Dir is where Dump writes payloads; it is created when needed
+	Dir string
+
+	panics int
+}
+
+// Guard runs process for message number num, holding data. A panic in
+// process, such as a failed type assertion on an unexpected payload, is
+// recovered, logged with the message number and length and counted, so one
+// malformed message can't end a long-running stream. It reports whether
+// process panicked.
+func (h *Handler) Guard(num int, data []byte, process func()) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			h.panics++
+			panicked = true
+			slog.Error("recovered from panic while processing "+h.Kind, "block", num, "bytes", len(data),
+				"panic", r, "stack", string(debug.Stack()))
+		}
+	}()
+	process()
+	return false
+}
+
+// Panics returns the number of panics recovered by Guard.
+func (h *Handler) Panics() int {
+	return h.panics
+}
+
+// Handle reports that message number num, holding data, failed to parse with
+// err. The error is logged with the message number and length, then the policy
+// is applied.
+func (h *Handler) Handle(num int, data []byte, err error) {
+	msg := "failed to parse " + h.Kind
+	args := []any{"block", num, "bytes", len(data), "err", err,
+		"raw", string(util.ClampBytes(data, rawPreview))}
+
+	switch h.Policy {
+	case Fatal:
+		logging.Exit(client.ExitParseFailure, msg, args...)
+	case Dump:
+		path, dumpErr := h.dump(num, data)
+		if dumpErr != nil {
+			slog.Error("failed to dump unparsable "+h.Kind, "block", num, "err", dumpErr)
+		} else {
+			args = append(args, "dump", path)
+		}
+	}
+	slog.Error(msg, args...)
+}
+
+// dump writes data to a new file in h.Dir and returns its path. The name
+// holds the message number and a timestamp so that reruns don't overwrite
+// earlier dumps.
+func (h *Handler) dump(num int, data []byte) (string, error) {
+	if err := os.MkdirAll(h.Dir, 0o755); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-%d-%s.raw", strings.ReplaceAll(h.Kind, " ", "-"), num, time.Now().UTC().Format("20060102T150405.000000000"))
+	path := filepath.Join(h.Dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,170 @@
+package parseerr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/client"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+	"github.com/dwellir/grpc-code-examples/go/internal/mockgateway"
+)
+
+func TestPolicySet(t *testing.T) {
+	var p Policy
+	for _, name := range []string{"skip", "dump", "FATAL"} {
+		if err := p.Set(name); err != nil {
+			t.Errorf("Set(%q) = %v", name, err)
+		}
+	}
+	if p != Fatal {
+		t.Errorf("policy = %q, want %q", p, Fatal)
+	}
+	if err := p.Set("retry"); err == nil {
+		t.Error("Set(\"retry\") succeeded, want an error")
+	}
+}
+
+func TestHandleDumpWritesPayload(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dumps")
+	h := &Handler{Policy: Dump, Kind: "block fills", Dir: dir}
+
+	data := []byte(`{"height": 12, "fills": [`)
+	h.Handle(7, data, errors.New("unexpected end of JSON input"))
+
+	matches, err := filepath.Glob(filepath.Join(dir, "block-fills-7-*.raw"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("dump files = %v (err %v), want exactly one", matches, err)
+	}
+	got, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("dumped %q, want %q", got, data)
+	}
+}
+
+func TestGuardRecoversAndStreamContinues(t *testing.T) {
+	server := &mockgateway.Server{
+		BlockFills: []*pb.BlockFills{
+			{Data: []byte(`{"height":1,"fills":[]}`)},
+			{Data: []byte(`{"height":"two","fills":{}}`)},
+			{Data: []byte(`{"height":3,"fills":[]}`)},
+		},
+	}
+	lis := mockgateway.Listen(server)
+	defer lis.Close()
+	conn, err := client.Connect(mockgateway.Target, "", client.WithTLS(false), client.WithDialOptions(lis.DialOption()))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	h := &Handler{Policy: Skip, Kind: "block fills"}
+	var heights []float64
+	num := 0
+	err = client.Stream(context.Background(), conn, pb.HyperLiquidL1GatewayClient.StreamBlockFills, &pb.Timestamp{}, func(response *pb.BlockFills) {
+		num++
+		h.Guard(num, response.Data, func() {
+			var payload map[string]any
+			if err := json.Unmarshal(response.Data, &payload); err != nil {
+				t.Fatalf("payload %d: %v", num, err)
+			}
+			// Panics on the second payload, whose height is a string
+			heights = append(heights, payload["height"].(float64))
+			_ = payload["fills"].([]any)
+		})
+	})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	if num != 3 {
+		t.Errorf("processed %d messages, want 3", num)
+	}
+	if len(heights) != 2 || heights[0] != 1 || heights[1] != 3 {
+		t.Errorf("heights = %v, want [1 3]", heights)
+	}
+	if h.Panics() != 1 {
+		t.Errorf("Panics() = %d, want 1", h.Panics())
+	}
+}
+
+func TestGuardReportsPanic(t *testing.T) {
+	h := &Handler{Kind: "block"}
+	if h.Guard(1, nil, func() {}) {
+		t.Error("Guard reported a panic for a function that returned normally")
+	}
+	if !h.Guard(2, []byte("{}"), func() { panic("boom") }) {
+		t.Error("Guard didn't report the panic")
+	}
+}
+
+// captureStdio runs fn with os.Stdout and os.Stderr redirected to pipes and
+// the logger set up the way the examples do, and returns what was written
+// to each
+func captureStdio(t *testing.T, fn func()) (stdout, stderr string) {
+	t.Helper()
+	origStdout, origStderr, origLogger := os.Stdout, os.Stderr, slog.Default()
+	defer func() {
+		os.Stdout, os.Stderr = origStdout, origStderr
+		slog.SetDefault(origLogger)
+	}()
+
+	read := func(f **os.File) func() string {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		*f = w
+		done := make(chan string)
+		go func() {
+			data, _ := io.ReadAll(r)
+			done <- string(data)
+		}()
+		return func() string {
+			w.Close()
+			return <-done
+		}
+	}
+	readStdout, readStderr := read(&os.Stdout), read(&os.Stderr)
+
+	if err := logging.Setup("info", "text"); err != nil {
+		t.Fatal(err)
+	}
+	fn()
+	return readStdout(), readStderr()
+}
+
+func TestParseErrorsStayOffStdout(t *testing.T) {
+	data := []byte(`{"height": 12, "fills": [`)
+	parseErr := errors.New("unexpected end of JSON input")
+
+	for _, policy := range []Policy{Skip, Dump} {
+		h := &Handler{Policy: policy, Kind: "block", Dir: t.TempDir()}
+		stdout, stderr := captureStdio(t, func() {
+			h.Handle(3, data, parseErr)
+			h.Guard(4, data, func() { panic("boom") })
+		})
+
+		// stdout carries only data, such as -output jsonl, so a parse error
+		// there would corrupt it
+		if stdout != "" {
+			t.Errorf("%s: stdout = %q, want nothing", policy, stdout)
+		}
+		for _, want := range []string{`msg="failed to parse block"`, "recovered from panic while processing block"} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("%s: stderr = %q, want it to contain %q", policy, stderr, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Package schema validates streamed payloads against a JSON schema given
+// with -schema, so upstream format changes are caught early instead of
+// surfacing as odd statistics.
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/capture"
+)
+
+// Validator checks payloads of one kind against a schema and counts the
+// violations. A nil *Validator accepts everything, so examples can call it
+// unconditionally when no schema was given.
+type Validator struct {
+	schema     *jsonschema.Schema
+	kind       string
+	dump       *capture.Writer
+	violations int
+}
+
+// Load compiles the JSON schema at path for payloads of kind ("block",
+// "block fills", ...). References to other local files are resolved
+// relative to it.
+func Load(path, kind string) (*Validator, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := jsonschema.NewCompiler().Compile(abs)
+	if err != nil {
+		return nil, fmt.Errorf("compile schema: %w", err)
+	}
+	return &Validator{schema: compiled, kind: kind}, nil
+}
+
+// DumpTo writes every violating payload into dir, which is created if it
+// doesn't exist.
+func (v *Validator) DumpTo(dir string) error {
+	dump, err := capture.New(dir, v.kind+" violation")
+	if err != nil {
+		return err
+	}
+	v.dump = dump
+	return nil
+}
+
+// Validate reports how data violates the schema, or nil when it conforms.
+// Data that isn't JSON is a violation too.
+func (v *Validator) Validate(data []byte) error {
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return v.schema.Validate(instance)
+}
+
+// Check validates message number num, holding data, and reports whether it
+// conforms. Violations are counted, logged and dumped when DumpTo was
+// called. height names the dump file when non-zero.
+func (v *Validator) Check(num int, height int64, data []byte) bool {
+	if v == nil {
+		return true
+	}
+	err := v.Validate(data)
+	if err == nil {
+		return true
+	}
+
+	v.violations++
+	slog.Warn("payload violates the schema", "kind", v.kind, "message", num, "err", err)
+	if v.dump != nil {
+		path, dumpErr := v.dump.Write(num, height, data)
+		if dumpErr != nil {
+			slog.Error("failed to dump schema violation", "message", num, "err", dumpErr)
+		} else {
+			slog.Info("schema violation dumped", "message", num, "path", path)
+		}
+	}
+	return false
+}
+
+// Violations returns the number of payloads that failed Check.
+func (v *Validator) Violations() int {
+	if v == nil {
+		return 0
+	}
+	return v.violations
+}
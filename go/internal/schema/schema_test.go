@@ -0,0 +1,66 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	v, err := Load(filepath.Join("testdata", "block_fills.schema.json"), "block fills")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		data  string
+		valid bool
+	}{
+		{"conforming", `{"height":1,"time":1700000000000,"fills":[{"symbol":"BTC","side":"B","price":"65000.5","size":"0.1"}]}`, true},
+		{"no fills", `{"height":1,"time":1700000000000,"fills":[]}`, true},
+		{"missing time", `{"height":1,"fills":[]}`, false},
+		{"numeric price", `{"height":1,"time":1,"fills":[{"symbol":"BTC","side":"B","price":65000.5,"size":"0.1"}]}`, false},
+		{"unknown side", `{"height":1,"time":1,"fills":[{"symbol":"BTC","side":"buy","price":"1","size":"1"}]}`, false},
+		{"not JSON", `{"height":`, false},
+	}
+	for _, tt := range tests {
+		if err := v.Validate([]byte(tt.data)); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestCheckCountsAndDumpsViolations(t *testing.T) {
+	v, err := Load(filepath.Join("testdata", "block_fills.schema.json"), "block fills")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	dir := t.TempDir()
+	if err := v.DumpTo(dir); err != nil {
+		t.Fatalf("DumpTo: %v", err)
+	}
+
+	if !v.Check(1, 10, []byte(`{"height":10,"time":1,"fills":[]}`)) {
+		t.Error("conforming payload rejected")
+	}
+	bad := []byte(`{"height":11,"fills":[]}`)
+	if v.Check(2, 11, bad) {
+		t.Error("violating payload accepted")
+	}
+	if got := v.Violations(); got != 1 {
+		t.Errorf("Violations() = %d, want 1", got)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "block-fills-violation-000002-h11.json"))
+	if err != nil || string(data) != string(bad) {
+		t.Errorf("dumped payload = %q, %v, want %q", data, err, bad)
+	}
+}
+
+func TestNilValidatorAcceptsEverything(t *testing.T) {
+	var v *Validator
+	if !v.Check(1, 0, []byte("not JSON")) || v.Violations() != 0 {
+		t.Error("nil Validator rejected a payload")
+	}
+}
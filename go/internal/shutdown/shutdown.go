@@ -0,0 +1,63 @@
+// Package shutdown implements the two-stage Ctrl+C handling used by the
+// streaming examples.
+package shutdown
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/client"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+)
+
+// Listen returns a context that is cancelled on the first SIGINT or SIGTERM so
+// the caller can finish the current message and print its summary. A second
+// signal exits the process immediately, and so does a shutdown that hasn't
+// called stop within timeout of the first signal, so a container is never
+// left hanging on termination. Both exits go through logging.Exit, so output
+// buffered by the OnExit hooks is flushed first. A zero timeout waits
+// indefinitely. Call stop once the summary is printed to release the handler.
+func Listen(parent context.Context, timeout time.Duration) (ctx context.Context, stop context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+
+	sigChan := make(chan os.Signal, 2)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigChan:
+		case <-done:
+			return
+		}
+		slog.Info("stopping stream after the current message (press Ctrl+C again to force quit)")
+		cancel()
+
+		// A nil channel never fires, so without a timeout only a signal forces the exit
+		var deadline <-chan time.Time
+		if timeout > 0 {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+
+		select {
+		case <-sigChan:
+			logging.Exit(client.ExitForcedQuit, "forced quit")
+		case <-deadline:
+			logging.Exit(client.ExitShutdownTimeout, "shutdown did not finish in time, forcing exit", "timeout", timeout)
+		case <-done:
+			return
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigChan)
+		close(done)
+		cancel()
+	}
+}
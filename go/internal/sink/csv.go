@@ -0,0 +1,71 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/output"
+)
+
+// csvHeader lists the columns written by the csv sink
+var csvHeader = []string{"kind", "num", "height", "time", "received_at", "size", "data"}
+
+func init() {
+	Register("csv", func(target string) (Sink, error) {
+		w, err := output.Open(target)
+		if err != nil {
+			return nil, err
+		}
+		s := &csvSink{w: w, csv: csv.NewWriter(w)}
+		if err := s.csv.Write(csvHeader); err != nil {
+			w.Close()
+			return nil, err
+		}
+		return s, nil
+	})
+}
+
+// csvSink writes one row per message: its metadata, its size and the
+// payload as compact JSON
+type csvSink struct {
+	w   io.WriteCloser
+	csv *csv.Writer
+}
+
+func (s *csvSink) Write(_ context.Context, raw []byte, meta Meta) error {
+	data := raw
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err == nil {
+		data = compact.Bytes()
+	}
+
+	row := []string{
+		meta.Kind,
+		strconv.Itoa(meta.Num),
+		strconv.FormatInt(meta.Height, 10),
+		strconv.FormatInt(meta.Time, 10),
+		meta.ReceivedAt.UTC().Format(time.RFC3339Nano),
+		strconv.Itoa(len(raw)),
+		string(data),
+	}
+	if err := s.csv.Write(row); err != nil {
+		return err
+	}
+	// Flushed per message so at most one message is lost on a crash
+	s.csv.Flush()
+	return s.csv.Error()
+}
+
+func (s *csvSink) Close() error {
+	s.csv.Flush()
+	if err := s.csv.Error(); err != nil {
+		s.w.Close()
+		return err
+	}
+	return s.w.Close()
+}
@@ -0,0 +1,49 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/output"
+)
+
+func init() {
+	Register("jsonl", func(target string) (Sink, error) {
+		w, err := output.Open(target)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonlSink{w: w, buf: bufio.NewWriter(w)}, nil
+	})
+}
+
+// jsonlSink writes every message as one compact JSON line, the format read
+// by replay_blocks.go
+type jsonlSink struct {
+	w   io.WriteCloser
+	buf *bufio.Writer
+}
+
+func (s *jsonlSink) Write(_ context.Context, raw []byte, _ Meta) error {
+	var line bytes.Buffer
+	if err := json.Compact(&line, raw); err != nil {
+		return err
+	}
+	line.WriteByte('\n')
+	if _, err := s.buf.Write(line.Bytes()); err != nil {
+		return err
+	}
+	// Flushed per message so the file can be followed with tail -f
+	return s.buf.Flush()
+}
+
+func (s *jsonlSink) Close() error {
+	if err := s.buf.Flush(); err != nil {
+		s.w.Close()
+		return err
+	}
+	return s.w.Close()
+}
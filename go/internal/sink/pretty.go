@@ -0,0 +1,49 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/output"
+)
+
+func init() {
+	Register("pretty", func(target string) (Sink, error) {
+		w, err := output.Open(target)
+		if err != nil {
+			return nil, err
+		}
+		return &prettySink{w: w}, nil
+	})
+}
+
+// prettySink writes every message as indented JSON under a header line, for
+// reading in a terminal
+type prettySink struct {
+	w io.WriteCloser
+}
+
+func (s *prettySink) Write(_ context.Context, raw []byte, meta Meta) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "── %s #%d", meta.Kind, meta.Num)
+	if meta.Height != 0 {
+		fmt.Fprintf(&buf, " (height %d)", meta.Height)
+	}
+	buf.WriteString(" ──\n")
+
+	// Payloads that aren't JSON are shown as received
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		buf.Write(raw)
+	}
+	buf.WriteByte('\n')
+
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+func (s *prettySink) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,80 @@
+// Package sink defines where streamed messages go. A Sink receives every
+// message as received together with its metadata; destinations are
+// registered by name and chosen with -sink, so adding one (Kafka, SQLite,
+// Parquet, ...) only takes implementing the interface.
+package sink
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Meta describes a streamed message.
+type Meta struct {
+	// Kind names the stream, e.g. "block" or "block fills"
+	Kind string
+	// Num is the message number in receive order, starting at 1
+	Num int
+	// Height is the block height, 0 when it couldn't be decoded
+	Height int64
+	// Time is the block time in Unix milliseconds, 0 when unknown
+	Time       int64
+	ReceivedAt time.Time
+}
+
+// Sink is a destination for streamed messages. Write is called from the
+// receive loop, one message at a time; Close flushes and releases the
+// destination and must be called before the program exits.
+type Sink interface {
+	Write(ctx context.Context, raw []byte, meta Meta) error
+	Close() error
+}
+
+// Factory opens a sink writing to target, whose meaning is up to the sink
+// (a file path for the built-in ones, stdout when empty).
+type Factory func(target string) (Sink, error)
+
+var (
+	mu        sync.Mutex
+	factories = make(map[string]Factory)
+)
+
+// Register makes a sink available to Open under name. It panics when name is
+// registered twice, like database/sql drivers.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := factories[name]; ok {
+		panic("sink: Register called twice for " + name)
+	}
+	factories[name] = factory
+}
+
+// Names returns the registered sink names, sorted.
+func Names() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Open opens the sink described by spec: a registered name optionally
+// followed by a colon and a target, e.g. "pretty" or "jsonl:blocks.jsonl".
+func Open(spec string) (Sink, error) {
+	name, target, _ := strings.Cut(spec, ":")
+	mu.Lock()
+	factory, ok := factories[name]
+	mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown sink %q (expected one of %s)", name, strings.Join(Names(), ", "))
+	}
+	return factory(target)
+}
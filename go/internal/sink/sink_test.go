@@ -0,0 +1,87 @@
+package sink
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenUnknownSink(t *testing.T) {
+	_, err := Open("carrier-pigeon")
+	if err == nil || !strings.Contains(err.Error(), "csv, jsonl, pretty") {
+		t.Errorf("Open error = %v, want the registered names listed", err)
+	}
+}
+
+// writeAll opens spec, writes the messages and closes the sink
+func writeAll(t *testing.T, spec string, messages ...string) {
+	t.Helper()
+	s, err := Open(spec)
+	if err != nil {
+		t.Fatalf("Open(%q): %v", spec, err)
+	}
+	received := time.Date(2025, 10, 14, 7, 22, 47, 0, time.UTC)
+	for i, message := range messages {
+		meta := Meta{Kind: "block", Num: i + 1, Height: int64(100 + i), Time: 1760426567000, ReceivedAt: received}
+		if err := s.Write(context.Background(), []byte(message), meta); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestJSONLSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocks.jsonl")
+	writeAll(t, "jsonl:"+path, "{\n  \"height\": 100\n}", `{"height":101}`)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"height\":100}\n{\"height\":101}\n"; string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+}
+
+func TestCSVSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocks.csv")
+	writeAll(t, "csv:"+path, `{ "height": 100 }`)
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		csvHeader,
+		{"block", "1", "100", "1760426567000", "2025-10-14T07:22:47Z", "17", `{"height":100}`},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestPrettySink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocks.txt")
+	writeAll(t, "pretty:"+path, `{"height":100}`, "not json")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "── block #1 (height 100) ──\n{\n  \"height\": 100\n}\n── block #2 (height 101) ──\nnot json\n"
+	if string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+}
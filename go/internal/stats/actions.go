@@ -0,0 +1,48 @@
+package stats
+
+import "sort"
+
+// ActionTypeCount is the number of actions of one type.
+type ActionTypeCount struct {
+	Type    string
+	Actions int
+}
+
+// ActionTypes counts actions per type across blocks. The zero value is ready
+// to use.
+type ActionTypes struct {
+	counts map[string]int
+	total  int
+}
+
+// Observe adds the per-type action counts of one block, such as
+// model.BlockSummary.ActionCounts.
+func (a *ActionTypes) Observe(counts map[string]int) {
+	if a.counts == nil {
+		a.counts = make(map[string]int)
+	}
+	for actionType, n := range counts {
+		a.counts[actionType] += n
+		a.total += n
+	}
+}
+
+// Total returns the number of actions observed.
+func (a *ActionTypes) Total() int {
+	return a.total
+}
+
+// Distribution returns the action count of every type, most actions first.
+func (a *ActionTypes) Distribution() []ActionTypeCount {
+	sorted := make([]ActionTypeCount, 0, len(a.counts))
+	for actionType, n := range a.counts {
+		sorted = append(sorted, ActionTypeCount{Type: actionType, Actions: n})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Actions != sorted[j].Actions {
+			return sorted[i].Actions > sorted[j].Actions
+		}
+		return sorted[i].Type < sorted[j].Type
+	})
+	return sorted
+}
@@ -0,0 +1,37 @@
+package stats
+
+import "time"
+
+// Cadence records when messages arrive to characterise the feed's rhythm.
+// The zero value is ready to use.
+type Cadence struct {
+	first      time.Time
+	last       time.Time
+	count      int
+	longestGap time.Duration
+}
+
+// Observe records a message received at t.
+func (c *Cadence) Observe(t time.Time) {
+	if c.count > 0 {
+		c.longestGap = max(c.longestGap, t.Sub(c.last))
+	} else {
+		c.first = t
+	}
+	c.last = t
+	c.count++
+}
+
+// MeanInterarrival returns the average time between consecutive messages,
+// or 0 before the second one.
+func (c *Cadence) MeanInterarrival() time.Duration {
+	if c.count < 2 {
+		return 0
+	}
+	return c.last.Sub(c.first) / time.Duration(c.count-1)
+}
+
+// LongestGap returns the longest time between two consecutive messages.
+func (c *Cadence) LongestGap() time.Duration {
+	return c.longestGap
+}
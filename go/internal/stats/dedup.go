@@ -0,0 +1,52 @@
+package stats
+
+import "container/list"
+
+// BlockKey identifies a block for deduplication. The time is included so a
+// height reused after a chain reset is not mistaken for a repeat.
+type BlockKey struct {
+	Height int64
+	Time   string
+}
+
+// Dedup remembers the most recently seen keys, such as BlockKeys or fill
+// hashes, evicting the least recently seen once it holds size keys. A stream
+// restarted from the latest timestamp after a reconnect may re-deliver the
+// last few blocks, so a small size is enough for blocks.
+type Dedup[K comparable] struct {
+	size       int
+	order      *list.List // of K, most recently seen at the front
+	seen       map[K]*list.Element
+	duplicates int
+}
+
+// NewDedup returns a Dedup remembering up to size keys.
+func NewDedup[K comparable](size int) *Dedup[K] {
+	return &Dedup[K]{
+		size:  size,
+		order: list.New(),
+		seen:  make(map[K]*list.Element, size),
+	}
+}
+
+// Seen records key and reports whether it had already been seen.
+func (d *Dedup[K]) Seen(key K) bool {
+	if elem, ok := d.seen[key]; ok {
+		d.order.MoveToFront(elem)
+		d.duplicates++
+		return true
+	}
+
+	d.seen[key] = d.order.PushFront(key)
+	if d.order.Len() > d.size {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.seen, oldest.Value.(K))
+	}
+	return false
+}
+
+// Duplicates returns how many repeated keys Seen has reported.
+func (d *Dedup[K]) Duplicates() int {
+	return d.duplicates
+}
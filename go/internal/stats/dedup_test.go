@@ -0,0 +1,49 @@
+package stats
+
+import "testing"
+
+func TestDedupEvictsLeastRecentlySeen(t *testing.T) {
+	d := NewDedup[string](2)
+	steps := []struct {
+		key  string
+		want bool
+	}{
+		{"a", false},
+		{"b", false},
+		{"a", true},  // a is now the most recently seen
+		{"c", false}, // evicts b, not a
+		{"a", true},
+		{"b", false}, // evicted, so new again; evicts c
+		{"c", false},
+		{"b", true},
+	}
+	for i, step := range steps {
+		if got := d.Seen(step.key); got != step.want {
+			t.Errorf("step %d: Seen(%q) = %v, want %v", i, step.key, got, step.want)
+		}
+	}
+	if got := d.Duplicates(); got != 3 {
+		t.Errorf("Duplicates() = %d, want 3", got)
+	}
+	if d.order.Len() != 2 || len(d.seen) != 2 {
+		t.Errorf("holds %d keys (%d indexed), want 2", d.order.Len(), len(d.seen))
+	}
+}
+
+func TestDedupBlockKeys(t *testing.T) {
+	d := NewDedup[BlockKey](8)
+	block := BlockKey{Height: 761244301, Time: "2025-10-14T07:22:45.123456"}
+	if d.Seen(block) {
+		t.Fatal("first block reported as seen")
+	}
+	if !d.Seen(block) {
+		t.Error("re-delivered block not reported as seen")
+	}
+	// A height reused after a chain reset has a different time
+	if d.Seen(BlockKey{Height: block.Height, Time: "2025-10-15T00:00:00"}) {
+		t.Error("reused height with a new time reported as seen")
+	}
+	if got := d.Duplicates(); got != 1 {
+		t.Errorf("Duplicates() = %d, want 1", got)
+	}
+}
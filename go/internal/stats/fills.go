@@ -0,0 +1,78 @@
+package stats
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/decimal"
+)
+
+// SymbolStats accumulates the fills of one symbol. Amounts are exact
+// rationals so large notionals do not suffer float rounding.
+type SymbolStats struct {
+	Symbol   string
+	Fills    int
+	Size     *big.Rat
+	Notional *big.Rat
+}
+
+// VWAP returns the volume-weighted average price, or nil when no size has
+// been traded.
+func (s *SymbolStats) VWAP() *big.Rat {
+	if s.Size.Sign() == 0 {
+		return nil
+	}
+	return new(big.Rat).Quo(s.Notional, s.Size)
+}
+
+// FillStats aggregates fills per symbol. The zero value is ready to use.
+type FillStats struct {
+	symbols map[string]*SymbolStats
+}
+
+// Add records a fill given the price and size strings from the feed.
+func (f *FillStats) Add(symbol, price, size string) error {
+	px, err := decimal.Parse(price)
+	if err != nil {
+		return fmt.Errorf("price for %s: %w", symbol, err)
+	}
+	sz, err := decimal.Parse(size)
+	if err != nil {
+		return fmt.Errorf("size for %s: %w", symbol, err)
+	}
+
+	if f.symbols == nil {
+		f.symbols = make(map[string]*SymbolStats)
+	}
+	s, ok := f.symbols[symbol]
+	if !ok {
+		s = &SymbolStats{Symbol: symbol, Size: new(big.Rat), Notional: new(big.Rat)}
+		f.symbols[symbol] = s
+	}
+
+	s.Fills++
+	s.Size.Add(s.Size, sz)
+	s.Notional.Add(s.Notional, new(big.Rat).Mul(px, sz))
+	return nil
+}
+
+// Len returns the number of symbols seen.
+func (f *FillStats) Len() int {
+	return len(f.symbols)
+}
+
+// ByNotional returns the per-symbol stats sorted by notional, largest first.
+func (f *FillStats) ByNotional() []*SymbolStats {
+	sorted := make([]*SymbolStats, 0, len(f.symbols))
+	for _, s := range f.symbols {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if c := sorted[i].Notional.Cmp(sorted[j].Notional); c != 0 {
+			return c > 0
+		}
+		return sorted[i].Symbol < sorted[j].Symbol
+	})
+	return sorted
+}
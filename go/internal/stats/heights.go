@@ -0,0 +1,76 @@
+// Package stats holds the running statistics the streaming examples keep
+// about a feed while it is being consumed.
+package stats
+
+import (
+	"fmt"
+	"time"
+)
+
+// HeightTracker detects gaps in block heights. The zero value is ready to use.
+type HeightTracker struct {
+	last    int64
+	started bool
+	missed  int64
+}
+
+// Observe records height and returns a warning when it is not exactly one
+// above the highest height seen so far, or "" when the block is in sequence.
+// Duplicate and out-of-order heights are reported but never count as missed
+// and do not move the tracker backwards.
+func (t *HeightTracker) Observe(height int64) string {
+	if !t.started {
+		t.last = height
+		t.started = true
+		return ""
+	}
+
+	expected := t.last + 1
+	switch {
+	case height == expected:
+		t.last = height
+		return ""
+	case height == t.last:
+		return fmt.Sprintf("duplicate block height %d", height)
+	case height < t.last:
+		return fmt.Sprintf("out-of-order block: got %d after %d", height, t.last)
+	}
+
+	missed := height - expected
+	t.missed += missed
+	t.last = height
+	return fmt.Sprintf("gap detected: expected %d, got %d (missed %d blocks)", expected, height, missed)
+}
+
+// Missed returns the total number of heights skipped so far.
+func (t *HeightTracker) Missed() int64 {
+	return t.missed
+}
+
+// BlockTimeTracker detects block times going backwards, which points at
+// reordered blocks or a server issue. The zero value is ready to use.
+type BlockTimeTracker struct {
+	last        time.Time
+	regressions int
+}
+
+// Observe records the block time t and returns a warning when it is earlier
+// than the latest block time seen so far, or "" otherwise. Equal times are
+// in order. An earlier time does not move the tracker backwards, so one
+// misplaced block is reported once.
+func (t *BlockTimeTracker) Observe(blockTime time.Time) string {
+	if t.last.IsZero() || !blockTime.Before(t.last) {
+		t.last = blockTime
+		return ""
+	}
+
+	t.regressions++
+	return fmt.Sprintf("block time went backwards: got %s after %s (%v earlier)",
+		blockTime.UTC().Format(time.RFC3339Nano), t.last.UTC().Format(time.RFC3339Nano), t.last.Sub(blockTime))
+}
+
+// Regressions returns the number of blocks whose time was earlier than the
+// block before.
+func (t *BlockTimeTracker) Regressions() int {
+	return t.regressions
+}
@@ -0,0 +1,67 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeightTrackerObserve(t *testing.T) {
+	var tracker HeightTracker
+	steps := []struct {
+		height int64
+		want   string // contained in the warning, "" for none
+	}{
+		{100, ""}, // the first height is never a gap
+		{101, ""},
+		{104, "missed 2 blocks"},
+		{104, "duplicate block height 104"},
+		{102, "out-of-order block: got 102 after 104"},
+		{105, ""}, // the tracker didn't move backwards
+		{106, ""},
+		{110, "expected 107, got 110 (missed 3 blocks)"},
+	}
+	for _, step := range steps {
+		got := tracker.Observe(step.height)
+		if step.want == "" && got != "" || step.want != "" && !strings.Contains(got, step.want) {
+			t.Errorf("Observe(%d) = %q, want %q", step.height, got, step.want)
+		}
+	}
+
+	// Duplicates and out-of-order heights don't count as missed
+	if got := tracker.Missed(); got != 5 {
+		t.Errorf("Missed() = %d, want 5", got)
+	}
+}
+
+func TestHeightTrackerStartsAtFirstHeight(t *testing.T) {
+	var tracker HeightTracker
+	if got := tracker.Observe(761244301); got != "" || tracker.Missed() != 0 {
+		t.Errorf("first Observe = %q with %d missed, want no gap", got, tracker.Missed())
+	}
+}
+
+func TestBlockTimeTrackerObserve(t *testing.T) {
+	var tracker BlockTimeTracker
+	start := time.Date(2025, 10, 14, 7, 22, 45, 0, time.UTC)
+	steps := []struct {
+		offset time.Duration
+		want   string
+	}{
+		{0, ""},
+		{time.Second, ""},
+		{time.Second, ""}, // equal times are in order
+		{500 * time.Millisecond, "went backwards"},
+		{2 * time.Second, ""},
+		{1500 * time.Millisecond, "(500ms earlier)"}, // compared with the latest time, not the misplaced one
+	}
+	for _, step := range steps {
+		got := tracker.Observe(start.Add(step.offset))
+		if step.want == "" && got != "" || step.want != "" && !strings.Contains(got, step.want) {
+			t.Errorf("Observe(+%v) = %q, want %q", step.offset, got, step.want)
+		}
+	}
+	if got := tracker.Regressions(); got != 2 {
+		t.Errorf("Regressions() = %d, want 2", got)
+	}
+}
@@ -0,0 +1,60 @@
+package stats
+
+import "time"
+
+// lagWindow is the number of recent messages the rolling feed lag averages
+const lagWindow = 100
+
+// FeedLag tracks how far behind real time the feed is: the delay between
+// when a block was produced and when it was received. The average covers
+// the last 100 messages, so it follows the current lag rather than the
+// whole run. The zero value is ready to use.
+type FeedLag struct {
+	window [lagWindow]time.Duration
+	next   int
+	count  int
+	sum    time.Duration
+	max    time.Duration
+}
+
+// Observe records a block produced at produced and received at received.
+func (l *FeedLag) Observe(produced, received time.Time) {
+	lag := received.Sub(produced)
+	if l.count == 0 || lag > l.max {
+		l.max = lag
+	}
+
+	// Replace the oldest lag once the window is full
+	if l.count >= lagWindow {
+		l.sum -= l.window[l.next]
+	}
+	l.window[l.next] = lag
+	l.sum += lag
+	l.next = (l.next + 1) % lagWindow
+	l.count++
+}
+
+// Count returns the number of observed messages.
+func (l *FeedLag) Count() int {
+	return l.count
+}
+
+// Window returns the number of messages Average covers: all of them up to
+// the last 100.
+func (l *FeedLag) Window() int {
+	return min(l.count, lagWindow)
+}
+
+// Average returns the mean lag of the last 100 messages, or 0 before the
+// first one. It is negative when the local clock is behind the producer's.
+func (l *FeedLag) Average() time.Duration {
+	if l.count == 0 {
+		return 0
+	}
+	return l.sum / time.Duration(l.Window())
+}
+
+// Max returns the largest lag observed.
+func (l *FeedLag) Max() time.Duration {
+	return l.max
+}
@@ -0,0 +1,51 @@
+package stats
+
+import "sort"
+
+// UnknownProposer is the bucket for blocks that carry no proposer.
+const UnknownProposer = "(unknown)"
+
+// ProposerCount is the number of blocks one proposer produced.
+type ProposerCount struct {
+	Proposer string
+	Blocks   int
+}
+
+// Proposers counts blocks per proposer. The zero value is ready to use.
+type Proposers struct {
+	counts map[string]int
+	total  int
+}
+
+// Observe records a block proposed by proposer. An empty proposer is counted
+// as UnknownProposer.
+func (p *Proposers) Observe(proposer string) {
+	if proposer == "" {
+		proposer = UnknownProposer
+	}
+	if p.counts == nil {
+		p.counts = make(map[string]int)
+	}
+	p.counts[proposer]++
+	p.total++
+}
+
+// Total returns the number of blocks observed.
+func (p *Proposers) Total() int {
+	return p.total
+}
+
+// Distribution returns the block count of every proposer, most blocks first.
+func (p *Proposers) Distribution() []ProposerCount {
+	sorted := make([]ProposerCount, 0, len(p.counts))
+	for proposer, blocks := range p.counts {
+		sorted = append(sorted, ProposerCount{Proposer: proposer, Blocks: blocks})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Blocks != sorted[j].Blocks {
+			return sorted[i].Blocks > sorted[j].Blocks
+		}
+		return sorted[i].Proposer < sorted[j].Proposer
+	})
+	return sorted
+}
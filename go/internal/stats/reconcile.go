@@ -0,0 +1,75 @@
+package stats
+
+import "github.com/dwellir/grpc-code-examples/go/internal/model"
+
+// maxMismatches bounds how many mismatching blocks are remembered, so a feed
+// that never matches can't grow the list without limit
+const maxMismatches = 100
+
+// Mismatch identifies a block whose action and order status counts differ.
+type Mismatch struct {
+	// Block is the block's number in the stream, as printed in its header
+	Block    int
+	Height   int64
+	Actions  int
+	Statuses int
+	// Types lists the action types whose counts differ
+	Types []model.TypeMismatch
+}
+
+// Reconciliation compares actions against order statuses across a whole run.
+// The zero value is ready to use.
+type Reconciliation struct {
+	blocks     int
+	matched    int
+	actions    int
+	statuses   int
+	mismatches []Mismatch
+	omitted    int
+}
+
+// Observe records the summary of block number blockNum and reports whether
+// its actions and statuses matched.
+func (r *Reconciliation) Observe(blockNum int, summary *model.BlockSummary) bool {
+	r.blocks++
+	r.actions += summary.TotalActions
+	r.statuses += summary.TotalStatuses()
+
+	if summary.Match {
+		r.matched++
+		return true
+	}
+
+	if len(r.mismatches) < maxMismatches {
+		r.mismatches = append(r.mismatches, Mismatch{
+			Block:    blockNum,
+			Height:   summary.Height,
+			Actions:  summary.TotalActions,
+			Statuses: summary.TotalStatuses(),
+			Types:    summary.TypeMismatches(),
+		})
+	} else {
+		r.omitted++
+	}
+	return false
+}
+
+// Totals returns the actions and order statuses counted over all blocks.
+func (r *Reconciliation) Totals() (actions, statuses int) {
+	return r.actions, r.statuses
+}
+
+// MatchRate returns the percentage of blocks whose counts matched, or 100
+// before any block was observed.
+func (r *Reconciliation) MatchRate() float64 {
+	if r.blocks == 0 {
+		return 100
+	}
+	return float64(r.matched) / float64(r.blocks) * 100
+}
+
+// Mismatches returns the first mismatching blocks in stream order, and how
+// many later ones were counted but not kept.
+func (r *Reconciliation) Mismatches() (kept []Mismatch, omitted int) {
+	return r.mismatches, r.omitted
+}
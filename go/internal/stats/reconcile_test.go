@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/model"
+)
+
+func TestReconciliation(t *testing.T) {
+	var r Reconciliation
+	if rate := r.MatchRate(); rate != 100 {
+		t.Errorf("MatchRate() before any block = %v, want 100", rate)
+	}
+
+	matching := model.BlockSummary{
+		Height:       761244301,
+		ActionCounts: map[string]int{"order": 3},
+		TotalActions: 3,
+		Success:      2,
+		Errors:       1,
+		StatusCounts: map[string]int{"order": 3},
+		Match:        true,
+	}
+	mismatching := model.BlockSummary{
+		Height:       761244302,
+		ActionCounts: map[string]int{"order": 2, "evmRawTx": 1},
+		TotalActions: 3,
+		Success:      2,
+		StatusCounts: map[string]int{"order": 2},
+		Match:        false,
+	}
+
+	if !r.Observe(1, &matching) {
+		t.Error("Observe of a matching block = false")
+	}
+	if r.Observe(2, &mismatching) {
+		t.Error("Observe of a mismatching block = true")
+	}
+
+	if actions, statuses := r.Totals(); actions != 6 || statuses != 5 {
+		t.Errorf("Totals() = %d, %d, want 6, 5", actions, statuses)
+	}
+	if rate := r.MatchRate(); rate != 50 {
+		t.Errorf("MatchRate() = %v, want 50", rate)
+	}
+	kept, omitted := r.Mismatches()
+	want := []Mismatch{{
+		Block:    2,
+		Height:   761244302,
+		Actions:  3,
+		Statuses: 2,
+		Types:    []model.TypeMismatch{{Type: "evmRawTx", Actions: 1, Statuses: 0}},
+	}}
+	if !reflect.DeepEqual(kept, want) || omitted != 0 {
+		t.Errorf("Mismatches() = %+v, %d, want %+v, 0", kept, omitted, want)
+	}
+}
+
+func TestReconciliationBoundsMismatches(t *testing.T) {
+	var r Reconciliation
+	mismatching := model.BlockSummary{ActionCounts: map[string]int{"order": 1}, TotalActions: 1}
+	for i := range maxMismatches + 5 {
+		r.Observe(i+1, &mismatching)
+	}
+
+	kept, omitted := r.Mismatches()
+	if len(kept) != maxMismatches || omitted != 5 {
+		t.Errorf("kept %d mismatches and omitted %d, want %d and 5", len(kept), omitted, maxMismatches)
+	}
+	if kept[0].Block != 1 {
+		t.Errorf("first kept mismatch is block %d, want 1", kept[0].Block)
+	}
+	if rate := r.MatchRate(); rate != 0 {
+		t.Errorf("MatchRate() = %v, want 0", rate)
+	}
+}
@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/decimal"
+)
+
+// Canonical fill sides.
+const (
+	Buy  = "buy"
+	Sell = "sell"
+)
+
+// NormalizeSide maps the feed's side encodings to Buy or Sell. Hyperliquid
+// sends "B" (bid, the buyer was the taker) and "A" (ask, the seller was),
+// but the spelled-out forms are accepted as well.
+func NormalizeSide(side string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(side)) {
+	case "b", "bid", "buy":
+		return Buy, nil
+	case "a", "ask", "sell":
+		return Sell, nil
+	}
+	return "", fmt.Errorf("unknown side %q", side)
+}
+
+// SideTotals is the number and total size of the fills on one side.
+type SideTotals struct {
+	Fills int
+	Size  *big.Rat
+}
+
+// SideStats aggregates fills per side. The zero value is ready to use.
+type SideStats struct {
+	buy  SideTotals
+	sell SideTotals
+}
+
+// Add records a fill given the side and size strings from the feed.
+func (s *SideStats) Add(side, size string) error {
+	canonical, err := NormalizeSide(side)
+	if err != nil {
+		return err
+	}
+	sz, err := decimal.Parse(size)
+	if err != nil {
+		return fmt.Errorf("size: %w", err)
+	}
+
+	totals := &s.buy
+	if canonical == Sell {
+		totals = &s.sell
+	}
+	if totals.Size == nil {
+		totals.Size = new(big.Rat)
+	}
+	totals.Fills++
+	totals.Size.Add(totals.Size, sz)
+	return nil
+}
+
+// Buys returns the totals of the buy side.
+func (s *SideStats) Buys() SideTotals {
+	return withSize(s.buy)
+}
+
+// Sells returns the totals of the sell side.
+func (s *SideStats) Sells() SideTotals {
+	return withSize(s.sell)
+}
+
+// Fills returns the number of fills recorded on both sides.
+func (s *SideStats) Fills() int {
+	return s.buy.Fills + s.sell.Fills
+}
+
+// Imbalance returns (buy size - sell size) / total size, from -1 (only
+// sells) to 1 (only buys), with ok false when no size has been traded.
+func (s *SideStats) Imbalance() (imbalance float64, ok bool) {
+	buy, sell := s.Buys().Size, s.Sells().Size
+	total := new(big.Rat).Add(buy, sell)
+	if total.Sign() == 0 {
+		return 0, false
+	}
+	imbalance, _ = new(big.Rat).Quo(new(big.Rat).Sub(buy, sell), total).Float64()
+	return imbalance, true
+}
+
+// withSize returns t with a zero size instead of nil
+func withSize(t SideTotals) SideTotals {
+	if t.Size == nil {
+		t.Size = new(big.Rat)
+	}
+	return t
+}
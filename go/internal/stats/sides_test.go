@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestNormalizeSide(t *testing.T) {
+	for side, want := range map[string]string{
+		"B":     Buy,
+		"b":     Buy,
+		"bid":   Buy,
+		"Buy":   Buy,
+		" BUY ": Buy,
+		"A":     Sell,
+		"ask":   Sell,
+		"sell":  Sell,
+		"SELL":  Sell,
+	} {
+		if got, err := NormalizeSide(side); err != nil || got != want {
+			t.Errorf("NormalizeSide(%q) = %q, %v, want %q", side, got, err, want)
+		}
+	}
+
+	for _, side := range []string{"", "X", "long", "bids"} {
+		if got, err := NormalizeSide(side); err == nil {
+			t.Errorf("NormalizeSide(%q) = %q, want an error", side, got)
+		}
+	}
+}
+
+func TestSideStatsImbalance(t *testing.T) {
+	tests := []struct {
+		name  string
+		fills [][2]string // side and size
+		want  float64
+		ok    bool
+	}{
+		{name: "no fills"},
+		{name: "zero sizes", fills: [][2]string{{"B", "0"}, {"A", "0.0"}}},
+		{name: "only buys", fills: [][2]string{{"B", "1.5"}, {"bid", "0.5"}}, want: 1, ok: true},
+		{name: "only sells", fills: [][2]string{{"A", "2"}}, want: -1, ok: true},
+		{name: "balanced", fills: [][2]string{{"B", "0.1"}, {"A", "0.1"}}, want: 0, ok: true},
+		{name: "more buys", fills: [][2]string{{"B", "3"}, {"A", "1"}}, want: 0.5, ok: true},
+		{name: "more sells", fills: [][2]string{{"B", "1"}, {"A", "2"}, {"sell", "1"}}, want: -0.5, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s SideStats
+			for _, fill := range tt.fills {
+				if err := s.Add(fill[0], fill[1]); err != nil {
+					t.Fatalf("Add(%q, %q): %v", fill[0], fill[1], err)
+				}
+			}
+			got, ok := s.Imbalance()
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Imbalance() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+			if got < -1 || got > 1 {
+				t.Errorf("Imbalance() = %v, outside [-1, 1]", got)
+			}
+		})
+	}
+}
+
+func TestSideStatsTotals(t *testing.T) {
+	var s SideStats
+	if s.Buys().Size.Sign() != 0 || s.Sells().Size.Sign() != 0 {
+		t.Fatal("zero SideStats has a size")
+	}
+
+	s.Add("B", "0.25")
+	s.Add("A", "1")
+	s.Add("B", "0.5")
+	if err := s.Add("X", "1"); err == nil {
+		t.Error("Add accepted an unknown side")
+	}
+	if err := s.Add("B", "lots"); err == nil {
+		t.Error("Add accepted an invalid size")
+	}
+
+	buys, sells := s.Buys(), s.Sells()
+	if buys.Fills != 2 || buys.Size.Cmp(big.NewRat(3, 4)) != 0 {
+		t.Errorf("Buys() = %d fills of %v, want 2 of 3/4", buys.Fills, buys.Size)
+	}
+	if sells.Fills != 1 || sells.Size.Cmp(big.NewRat(1, 1)) != 0 {
+		t.Errorf("Sells() = %d fills of %v, want 1 of 1", sells.Fills, sells.Size)
+	}
+	if s.Fills() != 3 {
+		t.Errorf("Fills() = %d, want 3", s.Fills())
+	}
+}
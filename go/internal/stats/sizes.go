@@ -0,0 +1,73 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// sizeSamples bounds the memory used for quantiles on long runs
+const sizeSamples = 10_000
+
+// SizeStats summarises message sizes. Count, min, max and mean are exact;
+// the median and p95 are estimated from a uniform reservoir sample of up to
+// 10,000 sizes, so memory stays constant however long the stream runs. The
+// zero value is ready to use.
+type SizeStats struct {
+	count    int64
+	total    int64
+	min, max int
+	samples  []int
+}
+
+// Add records a message of size bytes.
+func (s *SizeStats) Add(size int) {
+	if s.count == 0 || size < s.min {
+		s.min = size
+	}
+	if size > s.max {
+		s.max = size
+	}
+	s.count++
+	s.total += int64(size)
+
+	// Reservoir sampling keeps every size seen so far with equal probability
+	if len(s.samples) < sizeSamples {
+		s.samples = append(s.samples, size)
+	} else if i := rand.Int64N(s.count); i < sizeSamples {
+		s.samples[i] = size
+	}
+}
+
+// SizeSummary is the distribution of the sizes added to a SizeStats.
+type SizeSummary struct {
+	Count       int64
+	Min, Max    int
+	Mean        float64
+	Median, P95 int
+}
+
+// Summary returns the size distribution, or a zero summary when nothing was
+// added.
+func (s *SizeStats) Summary() SizeSummary {
+	if s.count == 0 {
+		return SizeSummary{}
+	}
+
+	sorted := slices.Clone(s.samples)
+	slices.Sort(sorted)
+	return SizeSummary{
+		Count:  s.count,
+		Min:    s.min,
+		Max:    s.max,
+		Mean:   float64(s.total) / float64(s.count),
+		Median: quantile(sorted, 0.5),
+		P95:    quantile(sorted, 0.95),
+	}
+}
+
+// quantile returns the q-quantile of sorted using the nearest-rank method
+func quantile(sorted []int, q float64) int {
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[max(0, rank)]
+}
@@ -0,0 +1,66 @@
+package stats
+
+import "testing"
+
+func TestSizeStatsEmpty(t *testing.T) {
+	var s SizeStats
+	if got := s.Summary(); got != (SizeSummary{}) {
+		t.Errorf("Summary() of nothing = %+v, want zeros", got)
+	}
+}
+
+func TestSizeStatsExactQuantiles(t *testing.T) {
+	var s SizeStats
+	// Added out of order, so the quantiles depend on sorting
+	for _, size := range []int{20, 3, 17, 1, 9, 12, 5, 19, 2, 14, 8, 16, 11, 4, 18, 7, 13, 6, 15, 10} {
+		s.Add(size)
+	}
+
+	want := SizeSummary{Count: 20, Min: 1, Max: 20, Mean: 10.5, Median: 10, P95: 19}
+	if got := s.Summary(); got != want {
+		t.Errorf("Summary() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSizeStatsSingleSize(t *testing.T) {
+	var s SizeStats
+	s.Add(512)
+	want := SizeSummary{Count: 1, Min: 512, Max: 512, Mean: 512, Median: 512, P95: 512}
+	if got := s.Summary(); got != want {
+		t.Errorf("Summary() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSizeStatsCapsSamples(t *testing.T) {
+	var s SizeStats
+	const n = 3 * sizeSamples
+	for i := 1; i <= n; i++ {
+		s.Add(i)
+	}
+
+	if len(s.samples) != sizeSamples {
+		t.Fatalf("kept %d samples, want %d", len(s.samples), sizeSamples)
+	}
+	// Later sizes replace some of the first ones
+	replaced := 0
+	for _, size := range s.samples {
+		if size < 1 || size > n {
+			t.Fatalf("sample %d was never added", size)
+		}
+		if size > sizeSamples {
+			replaced++
+		}
+	}
+	if replaced == 0 {
+		t.Error("no sample past the first 10,000 was kept")
+	}
+
+	// Count, min, max and mean stay exact
+	got := s.Summary()
+	if got.Count != n || got.Min != 1 || got.Max != n || got.Mean != float64(n+1)/2 {
+		t.Errorf("Summary() = %+v, want count %d, min 1, max %d, mean %v", got, n, n, float64(n+1)/2)
+	}
+	if got.Median < 1 || got.Median > n || got.P95 < got.Median {
+		t.Errorf("estimated median %d and p95 %d out of range", got.Median, got.P95)
+	}
+}
@@ -0,0 +1,67 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+)
+
+// ClockSkew detects a local clock that is off against the feed. A block
+// can't arrive before it was produced, so lags below -Threshold mean the
+// local clock is behind; lags that never drop below +Threshold on a live
+// feed suggest it is ahead. Only a skew sustained for Window is reported,
+// and only once per direction, so single outliers are ignored.
+type ClockSkew struct {
+	// Threshold is how far off the clock must be; 0 disables detection
+	Threshold time.Duration
+	// Window is how long every lag must stay beyond Threshold
+	Window time.Duration
+	// IgnoreAhead skips the ahead check, for historical replays whose lag
+	// is legitimately large until they catch up
+	IgnoreAhead bool
+
+	direction    int
+	since        time.Time
+	warnedBehind bool
+	warnedAhead  bool
+}
+
+// Observe records a block produced at produced and received at received,
+// and returns a warning the first time the clock has been off in one
+// direction for Window, or "" otherwise.
+func (c *ClockSkew) Observe(produced, received time.Time) string {
+	if c.Threshold <= 0 {
+		return ""
+	}
+
+	lag := received.Sub(produced)
+	direction := 0
+	switch {
+	case lag < -c.Threshold:
+		direction = -1
+	case lag > c.Threshold && !c.IgnoreAhead:
+		direction = 1
+	}
+
+	// Any lag within the threshold, or beyond it the other way, restarts
+	// the observation window
+	if direction == 0 || direction != c.direction {
+		c.direction = direction
+		c.since = received
+		return ""
+	}
+	if received.Sub(c.since) < c.Window {
+		return ""
+	}
+
+	switch {
+	case direction < 0 && !c.warnedBehind:
+		c.warnedBehind = true
+		return fmt.Sprintf("system clock may be wrong: blocks have been arriving before their block time for %v (latest by %v), the local clock appears to be behind; check NTP",
+			c.Window, (-lag).Round(time.Millisecond))
+	case direction > 0 && !c.warnedAhead:
+		c.warnedAhead = true
+		return fmt.Sprintf("system clock may be wrong: every block has arrived more than %v after its block time for %v (latest %v), the local clock may be ahead, or the feed is delayed; check NTP",
+			c.Threshold, c.Window, lag.Round(time.Millisecond))
+	}
+	return ""
+}
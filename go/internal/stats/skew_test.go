@@ -0,0 +1,108 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClockSkewObserve(t *testing.T) {
+	// lag is how long after its block time a block is received, at is when
+	// it is received; want is what the warning contains, "" for none
+	type block struct {
+		at, lag time.Duration
+		want    string
+	}
+	const s = time.Second
+	tests := []struct {
+		name   string
+		skew   ClockSkew
+		blocks []block
+	}{
+		{
+			name: "behind for a window",
+			skew: ClockSkew{Threshold: 2 * s, Window: 10 * s},
+			blocks: []block{
+				{at: 0, lag: -5 * s},
+				{at: 5 * s, lag: -5 * s},
+				{at: 10 * s, lag: -5 * s, want: "behind"},
+				{at: 15 * s, lag: -5 * s},
+			},
+		},
+		{
+			name: "ahead for a window",
+			skew: ClockSkew{Threshold: 2 * s, Window: 10 * s},
+			blocks: []block{
+				{at: 0, lag: 5 * s},
+				{at: 10 * s, lag: 5 * s, want: "ahead"},
+			},
+		},
+		{
+			name: "lag within the threshold resets the window",
+			skew: ClockSkew{Threshold: 2 * s, Window: 10 * s},
+			blocks: []block{
+				{at: 0, lag: -5 * s},
+				{at: 5 * s, lag: time.Second},
+				{at: 10 * s, lag: -5 * s},
+				{at: 15 * s, lag: -5 * s},
+				{at: 20 * s, lag: -5 * s, want: "behind"},
+			},
+		},
+		{
+			name: "direction flip resets the window",
+			skew: ClockSkew{Threshold: 2 * s, Window: 10 * s},
+			blocks: []block{
+				{at: 0, lag: -5 * s},
+				{at: 5 * s, lag: 5 * s},
+				{at: 10 * s, lag: 5 * s},
+				{at: 15 * s, lag: 5 * s, want: "ahead"},
+			},
+		},
+		{
+			name: "warns once per direction",
+			skew: ClockSkew{Threshold: 2 * s, Window: 10 * s},
+			blocks: []block{
+				{at: 0, lag: -5 * s},
+				{at: 10 * s, lag: -5 * s, want: "behind"},
+				{at: 20 * s, lag: -5 * s},
+				{at: 30 * s, lag: 5 * s},
+				{at: 40 * s, lag: 5 * s, want: "ahead"},
+				{at: 50 * s, lag: -5 * s},
+				{at: 60 * s, lag: -5 * s},
+			},
+		},
+		{
+			name: "IgnoreAhead skips a delayed feed",
+			skew: ClockSkew{Threshold: 2 * s, Window: 10 * s, IgnoreAhead: true},
+			blocks: []block{
+				{at: 0, lag: time.Hour},
+				{at: 10 * s, lag: time.Hour},
+				{at: 20 * s, lag: -5 * s},
+				{at: 30 * s, lag: -5 * s, want: "behind"},
+			},
+		},
+		{
+			name: "zero threshold disables detection",
+			skew: ClockSkew{Window: 10 * s},
+			blocks: []block{
+				{at: 0, lag: -time.Hour},
+				{at: 10 * s, lag: -time.Hour},
+				{at: 20 * s, lag: -time.Hour},
+			},
+		},
+	}
+
+	start := time.Date(2025, 10, 14, 10, 4, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skew := tt.skew
+			for _, b := range tt.blocks {
+				received := start.Add(b.at)
+				got := skew.Observe(received.Add(-b.lag), received)
+				if b.want == "" && got != "" || b.want != "" && !strings.Contains(got, b.want) {
+					t.Errorf("Observe at %v with lag %v = %q, want %q", b.at, b.lag, got, b.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+// Package util holds the small helpers shared by the dump and preview output
+// of the examples.
+package util
+
+import "unicode/utf8"
+
+// ClampBytes returns at most the first n bytes of b. The cut is moved back to
+// the start of a UTF-8 sequence so that text is never split mid-character.
+func ClampBytes(b []byte, n int) []byte {
+	if len(b) <= n {
+		return b
+	}
+	cut := max(n, 0)
+	for cut > 0 && !utf8.RuneStart(b[cut]) {
+		cut--
+	}
+	return b[:cut]
+}
+
+// TruncateString shortens s to at most n bytes followed by "..." when it is
+// longer than n, without splitting a UTF-8 character.
+func TruncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return string(ClampBytes([]byte(s), n)) + "..."
+}
@@ -0,0 +1,42 @@
+package util
+
+import "testing"
+
+func TestClampBytes(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"abcdef", 3, "abc"},
+		{"abc", 10, "abc"},
+		{"abc", 0, ""},
+		{"abc", -1, ""},
+		// "é" is two bytes, so cutting after its first byte backs up before it
+		{"café", 4, "caf"},
+		{"café", 5, "café"},
+	}
+	for _, tt := range tests {
+		if got := string(ClampBytes([]byte(tt.in), tt.n)); got != tt.want {
+			t.Errorf("ClampBytes(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"0x5ac99df645f3414876c8", 12, "0x5ac99df645..."},
+		{"short", 12, "short"},
+		{"exactly12chr", 12, "exactly12chr"},
+		{"🚀🚀", 5, "🚀..."},
+	}
+	for _, tt := range tests {
+		if got := TruncateString(tt.in, tt.n); got != tt.want {
+			t.Errorf("TruncateString(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
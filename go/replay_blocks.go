@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/dwellir/grpc-code-examples/go/internal/buildinfo"
+	"github.com/dwellir/grpc-code-examples/go/internal/capture"
+	"github.com/dwellir/grpc-code-examples/go/internal/color"
+	"github.com/dwellir/grpc-code-examples/go/internal/config"
+	"github.com/dwellir/grpc-code-examples/go/internal/display"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+	"github.com/dwellir/grpc-code-examples/go/internal/model"
+	"github.com/dwellir/grpc-code-examples/go/internal/output"
+	"github.com/dwellir/grpc-code-examples/go/internal/parseerr"
+	"github.com/dwellir/grpc-code-examples/go/internal/stats"
+)
+
+func main() {
+	file := flag.String("file", "", `file of newline-delimited block JSON, e.g. captured with stream_blocks.go -output jsonl ("-" reads stdin)`)
+	rawGzip := flag.String("raw-gzip", "", "replay a gzip capture written by -raw-gzip instead of -file")
+	outFile := flag.String("out-file", "", "write the human-readable output to this file instead of stdout")
+	outBuffer := config.ByteSize(output.DefaultBufferSize)
+	flag.Var(&outBuffer, "out-buffer", "buffer up to this much output before writing it to stdout or -out-file, e.g. 64KB or 1MB")
+	outFlush := flag.Duration("out-flush-interval", output.DefaultFlushInterval, "write buffered output at least this often, 0 disables buffering and writes every line as it's printed")
+	logLevel := flag.String("log-level", "info", "minimum level of log records on stderr: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "log record format on stderr: text or json")
+	parseErrors := parseerr.Handler{Policy: parseerr.Skip, Kind: "block"}
+	flag.Var(&parseErrors.Policy, "on-parse-error", "what to do with a block that can't be parsed: skip, dump (write its bytes to -dump-dir) or fatal (exit)")
+	flag.StringVar(&parseErrors.Dir, "dump-dir", "parse-errors", "directory for blocks dumped by -on-parse-error dump")
+	noColor := flag.Bool("no-color", false, "don't color the output; colors are also off when NO_COLOR is set or the output isn't a terminal")
+	showVersion := flag.Bool("version", false, "print the version, commit and build date and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(buildinfo.String())
+		return
+	}
+
+	if err := logging.Setup(*logLevel, *logFormat); err != nil {
+		logging.Fatal("invalid logging configuration", "err", err)
+	}
+	if *outFlush < 0 {
+		logging.Fatal("-out-flush-interval must not be negative", "out-flush-interval", *outFlush)
+	}
+	if (*file == "") == (*rawGzip == "") {
+		logging.Fatal("exactly one of -file and -raw-gzip is required")
+	}
+
+	dest, err := output.Open(*outFile)
+	if err != nil {
+		logging.Fatal("failed to open output file", "path", *outFile, "err", err)
+	}
+	// Output is written in large chunks rather than line by line. os.Exit
+	// skips deferred calls, so exits also flush it explicitly.
+	out := output.NewBuffered(dest, int(outBuffer), *outFlush)
+	defer out.Close()
+	logging.OnExit(func() { out.Flush() })
+	color.Setup(out, *noColor)
+
+	source := *file
+	var next func() ([]byte, error)
+	if *rawGzip != "" {
+		source = *rawGzip
+		f, err := os.Open(*rawGzip)
+		if err != nil {
+			logging.Fatal("failed to open -raw-gzip capture", "path", *rawGzip, "err", err)
+		}
+		defer f.Close()
+		frames, err := capture.NewGzipReader(f)
+		if err != nil {
+			logging.Fatal("failed to read -raw-gzip capture", "path", *rawGzip, "err", err)
+		}
+		next = frames.Next
+	} else {
+		var input io.Reader = os.Stdin
+		if *file != "-" {
+			f, err := os.Open(*file)
+			if err != nil {
+				logging.Fatal("failed to open replay file", "path", *file, "err", err)
+			}
+			defer f.Close()
+			input = f
+		}
+		next = lineReader(input)
+	}
+
+	fmt.Fprintln(out, "🚀 Hyperliquid Go gRPC Client - Replay Blocks")
+	fmt.Fprintln(out, "===============================================")
+	fmt.Fprintf(out, "📂 Source: %s\n\n", source)
+
+	blockCount := 0
+	parseErrorCount := 0
+	var heights stats.HeightTracker
+	var blockTimes stats.BlockTimeTracker
+
+	for {
+		data, readErr := next()
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		// A capture cut short still replays up to its last complete frame
+		if errors.Is(readErr, capture.ErrTruncated) {
+			slog.Warn("capture ends early, replayed the blocks before the cut", "path", source, "blocks", blockCount)
+			break
+		}
+		if readErr != nil {
+			logging.Fatal("failed to read replay source", "path", source, "err", readErr)
+		}
+		blockCount++
+
+		fmt.Fprintf(out, "\n===== BLOCK #%d =====\n", blockCount)
+		fmt.Fprintf(out, "📦 Response size: %d bytes\n", len(data))
+
+		// Same decoder and display as the live stream
+		block, err := model.DecodeBlock(data)
+		if err != nil {
+			parseErrorCount++
+			parseErrors.Handle(blockCount, data, err)
+		} else {
+			summary := block.Summary()
+			display.BlockSummary(out, &summary, blockCount)
+
+			// Check that heights follow on from each other
+			if summary.Height != 0 {
+				if warning := heights.Observe(summary.Height); warning != "" {
+					slog.Warn(warning, "height", summary.Height)
+				}
+			}
+			// Check that block times never go backwards
+			if produced, ok := block.ABCIBlock.Timestamp(); ok {
+				if warning := blockTimes.Observe(produced); warning != "" {
+					slog.Warn(warning, "height", summary.Height)
+				}
+			}
+		}
+
+		fmt.Fprintln(out, "\n"+"─────────────────────────────────────────────────")
+	}
+
+	fmt.Fprintf(out, "\n📊 Total blocks replayed: %d\n", blockCount)
+	fmt.Fprintf(out, "⚠️  Blocks that failed to parse: %s\n", color.If(parseErrorCount > 0, color.Red, parseErrorCount))
+	fmt.Fprintf(out, "🕳️  Total missed blocks: %s\n", color.If(heights.Missed() > 0, color.Yellow, heights.Missed()))
+	fmt.Fprintf(out, "⏪ Block time regressions: %s\n", color.If(blockTimes.Regressions() > 0, color.Yellow, blockTimes.Regressions()))
+}
+
+// lineReader returns a function yielding the non-empty lines of r, then
+// io.EOF. Blocks can be far larger than bufio.Scanner's token limit, so
+// lines are read whole.
+func lineReader(r io.Reader) func() ([]byte, error) {
+	reader := bufio.NewReader(r)
+	return func() ([]byte, error) {
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				return line, nil
+			}
+			if err != nil {
+				return nil, io.EOF
+			}
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/buildinfo"
+	"github.com/dwellir/grpc-code-examples/go/internal/client"
+	"github.com/dwellir/grpc-code-examples/go/internal/color"
+	"github.com/dwellir/grpc-code-examples/go/internal/config"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+	"github.com/dwellir/grpc-code-examples/go/internal/model"
+	"github.com/dwellir/grpc-code-examples/go/internal/shutdown"
+)
+
+// Values of -on-failure
+const (
+	// failureStop ends both streams as soon as one of them fails
+	failureStop = "stop"
+	// failureReconnect re-establishes each stream on its own
+	failureReconnect = "reconnect"
+)
+
+// feedStats is what one stream counted. Each stream goroutine owns its own
+// feedStats, which are only read after both goroutines have returned.
+type feedStats struct {
+	messages    int
+	parseErrors int
+	empty       int
+	lastHeight  int64
+	// items counts the fills of block fills; blocks don't use it
+	items int
+}
+
+func main() {
+	cfg := config.Register(flag.CommandLine)
+	onFailure := flag.String("on-failure", failureStop, "when a stream fails: stop (end both streams and print the summary) or reconnect (re-establish each stream independently)")
+	keepaliveTime := flag.Duration("keepalive-time", 30*time.Second, "interval between keepalive pings on an idle connection, 0 disables keepalive")
+	keepaliveTimeout := flag.Duration("keepalive-timeout", 10*time.Second, "how long to wait for a keepalive ping to be acknowledged")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 8*time.Second, "after Ctrl+C or SIGTERM, force exit if the summary isn't printed within this long, 0 waits indefinitely")
+	idleTimeout := flag.Duration("idle-timeout", 60*time.Second, "treat a stream as failed when no message arrives for this long, 0 disables")
+	breakerThreshold := flag.Int("breaker-threshold", 5, "open the circuit breaker after this many consecutive reconnect failures within -breaker-window, 0 disables it")
+	breakerWindow := flag.Duration("breaker-window", 5*time.Minute, "time window in which -breaker-threshold failures open the circuit breaker")
+	breakerCooldown := flag.Duration("breaker-cooldown", 5*time.Minute, "how long an open circuit breaker waits before trying again; with a single endpoint the example exits instead")
+	maxMsgSize := config.ByteSize(client.DefaultMaxMessageSize)
+	flag.Var(&maxMsgSize, "max-msg-size", "maximum message size to receive, e.g. 256MB or 1GB")
+	showVersion := flag.Bool("version", false, "print the version, commit and build date and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(buildinfo.String())
+		return
+	}
+
+	if err := cfg.LoadFile(); err != nil {
+		logging.Fatal("invalid -config file", "err", err)
+	}
+	if err := cfg.SetupLogging(); err != nil {
+		logging.Fatal("invalid logging configuration", "err", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	if warning := cfg.SecurityWarning(); warning != "" {
+		slog.Warn(warning)
+	}
+	if *onFailure != failureStop && *onFailure != failureReconnect {
+		logging.Fatal("unknown -on-failure (expected stop or reconnect)", "on-failure", *onFailure)
+	}
+
+	// -dry-run stops here, before any file is written or stream opened
+	if cfg.DryRun {
+		color.Setup(os.Stdout, false)
+		os.Exit(cfg.RunDryRun(os.Stdout))
+	}
+
+	// API key is optional - some endpoints are public and don't require authentication
+	if cfg.APIKey == "" {
+		slog.Info("no API key provided, connecting to a public endpoint")
+	}
+
+	fmt.Println("🚀 Hyperliquid Go gRPC Client - Stream Blocks and Block Fills")
+	fmt.Println("==============================================================")
+	fmt.Printf("📡 Endpoints: %s\n", strings.Join(cfg.Endpoints(), ", "))
+	fmt.Printf("🔒 Transport: %s\n", cfg.TransportDescription())
+	fmt.Printf("⏱️  Start: %s\n", cfg.StartDescription())
+	fmt.Printf("🎬 Mode: %s\n", cfg.StreamDescription())
+	fmt.Printf("🧯 On failure: %s\n", *onFailure)
+	fmt.Printf("⚙️  Config precedence: %s\n\n", config.Precedence)
+
+	slog.Info("connecting to gRPC server", "endpoints", cfg.Endpoints())
+	// Keepalive pings detect connections silently dropped by intermediaries
+	connectOpts := append(cfg.ConnectOptions(),
+		client.WithKeepalive(*keepaliveTime, *keepaliveTimeout),
+		client.WithMaxMessageSize(int(maxMsgSize)),
+	)
+	dial := func(endpoint string) (*grpc.ClientConn, error) {
+		return client.Connect(endpoint, cfg.APIKey, connectOpts...)
+	}
+
+	// Endpoints are tried in priority order
+	failover := client.NewFailover(cfg.Endpoints(), dial)
+	ctx := context.Background()
+
+	// The client connects lazily, so wait until an endpoint is actually ready
+	conn, err := failover.Connect(ctx, cfg.ConnectTimeout)
+	if err != nil {
+		logging.Exit(client.ExitConnectionFailure, "failed to connect", "err", err)
+	}
+	defer conn.Close()
+
+	slog.Info("connected", "endpoint", failover.Active())
+
+	// Without another endpoint to fail over to, an open breaker ends the run
+	breaker := client.WithCircuitBreaker(client.Breaker{
+		Threshold:    *breakerThreshold,
+		Window:       *breakerWindow,
+		Cooldown:     *breakerCooldown,
+		ExitWhenOpen: len(cfg.Endpoints()) == 1,
+	})
+
+	// First Ctrl+C (or SIGTERM) drains both streams, a second one or an overrun
+	// of -shutdown-timeout forces an immediate exit
+	ctx, stop := shutdown.Listen(ctx, *shutdownTimeout)
+	defer stop()
+
+	// Create request - 0 means latest, otherwise replay from the start time (see -from)
+	request := &pb.Timestamp{Timestamp: cfg.RequestTimestamp()}
+
+	fmt.Println("📥 Streaming blocks and block fills over one connection...")
+	fmt.Print("Press Ctrl+C to stop streaming (twice to force quit)\n\n")
+
+	var blocks, fills feedStats
+	streamStart := time.Now()
+
+	// The group context is cancelled as soon as either stream returns an
+	// error, which makes the other one drain and return too. Each stream
+	// gets a Failover of its own, since they reconnect independently.
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		err := runStream(groupCtx, conn, *onFailure, client.NewFailover(cfg.Endpoints(), dial), pb.HyperLiquidL1GatewayClient.StreamBlocks, request, func(response *pb.Block) {
+			handleBlock(&blocks, response.Data)
+		}, client.WithIdleTimeout(*idleTimeout), breaker)
+		return streamError("blocks", err)
+	})
+	group.Go(func() error {
+		err := runStream(groupCtx, conn, *onFailure, client.NewFailover(cfg.Endpoints(), dial), pb.HyperLiquidL1GatewayClient.StreamBlockFills, request, func(response *pb.BlockFills) {
+			handleBlockFills(&fills, response.Data)
+		}, client.WithIdleTimeout(*idleTimeout), breaker)
+		return streamError("block fills", err)
+	})
+
+	err = group.Wait()
+	exitCode := client.ExitOK
+	if err != nil {
+		message, auth := client.ClassifyError(err)
+		if auth {
+			logging.Exit(client.ExitAuthFailure, message, "err", err)
+		}
+		slog.Error("stream ended with an error, stopped both streams", "err", err)
+		exitCode = client.ExitStreamError
+	}
+
+	fmt.Println("\n📊 Combined summary")
+	fmt.Printf("⏲️  Run duration: %v\n", time.Since(streamStart).Round(time.Millisecond))
+	fmt.Printf("📦 Blocks: %d received, %d parse errors, %d empty, last height %d\n",
+		blocks.messages, blocks.parseErrors, blocks.empty, blocks.lastHeight)
+	fmt.Printf("💰 Block fills: %d received (%d fills), %d parse errors, %d empty, last height %d\n",
+		fills.messages, fills.items, fills.parseErrors, fills.empty, fills.lastHeight)
+	if blocks.lastHeight != 0 && fills.lastHeight != 0 {
+		fmt.Printf("📏 Fills lag blocks by %d heights\n", blocks.lastHeight-fills.lastHeight)
+	}
+
+	// The summary is printed and all output written, so skipping deferred
+	// cleanup is safe
+	if exitCode != client.ExitOK {
+		os.Exit(exitCode)
+	}
+}
+
+// runStream runs one stream on conn until ctx is cancelled or, with
+// -on-failure stop, until the stream fails
+func runStream[T any](ctx context.Context, conn *grpc.ClientConn, onFailure string, failover *client.Failover, open client.StreamFunc[T], request *pb.Timestamp, handle func(*T), opts ...client.StreamOption) error {
+	if onFailure == failureReconnect {
+		return client.StreamWithReconnect(ctx, conn, failover.Redial, open, request, handle, opts...)
+	}
+	return client.Stream(ctx, conn, open, request, handle, opts...)
+}
+
+// streamError names the stream an error came from
+func streamError(name string, err error) error {
+	if err == nil {
+		slog.Info("stream ended", "stream", name)
+		return nil
+	}
+	return fmt.Errorf("%s stream: %w", name, err)
+}
+
+// handleBlock prints a one-line summary of a block and records it in s
+func handleBlock(s *feedStats, data []byte) {
+	// Some endpoints send empty heartbeat-like frames, which aren't blocks
+	if len(data) == 0 {
+		s.empty++
+		slog.Debug("skipping empty message", "stream", "blocks")
+		return
+	}
+	s.messages++
+
+	block, err := model.DecodeBlock(data)
+	if err != nil {
+		s.parseErrors++
+		slog.Error("failed to parse block", "block", s.messages, "err", err)
+		return
+	}
+	summary := block.Summary()
+	s.lastHeight = summary.Height
+
+	fmt.Printf("📦 Block %d: %d actions, %d statuses (%d bytes)\n", summary.Height, summary.TotalActions, summary.TotalStatuses(), len(data))
+}
+
+// handleBlockFills prints a one-line summary of block fills and records them in s
+func handleBlockFills(s *feedStats, data []byte) {
+	// Some endpoints send empty heartbeat-like frames, which aren't block fills
+	if len(data) == 0 {
+		s.empty++
+		slog.Debug("skipping empty message", "stream", "block fills")
+		return
+	}
+	s.messages++
+
+	blockFills, err := model.DecodeBlockFills(data)
+	if err != nil {
+		s.parseErrors++
+		slog.Error("failed to parse block fills", "block", s.messages, "err", err)
+		return
+	}
+	s.items += len(blockFills.Fills)
+	s.lastHeight = blockFills.Height
+
+	fmt.Printf("💰 Fills %d: %d fills (%d bytes)\n", blockFills.Height, len(blockFills.Fills), len(data))
+}
@@ -2,267 +2,1169 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"encoding/csv"
+	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math/big"
+	"math/rand/v2"
 	"os"
 	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/parquet-go/parquet-go"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/metadata"
 
 	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
-	"github.com/joho/godotenv"
+	"github.com/dwellir/grpc-code-examples/go/internal/buildinfo"
+	"github.com/dwellir/grpc-code-examples/go/internal/capture"
+	"github.com/dwellir/grpc-code-examples/go/internal/client"
+	"github.com/dwellir/grpc-code-examples/go/internal/color"
+	"github.com/dwellir/grpc-code-examples/go/internal/config"
+	"github.com/dwellir/grpc-code-examples/go/internal/decimal"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+	"github.com/dwellir/grpc-code-examples/go/internal/metrics"
+	"github.com/dwellir/grpc-code-examples/go/internal/model"
+	"github.com/dwellir/grpc-code-examples/go/internal/output"
+	"github.com/dwellir/grpc-code-examples/go/internal/parseerr"
+	"github.com/dwellir/grpc-code-examples/go/internal/schema"
+	"github.com/dwellir/grpc-code-examples/go/internal/shutdown"
+	"github.com/dwellir/grpc-code-examples/go/internal/sink"
+	"github.com/dwellir/grpc-code-examples/go/internal/stats"
+	"github.com/dwellir/grpc-code-examples/go/internal/util"
 )
 
-// BlockFills represents the structure of block fills
-type BlockFills struct {
-	Height int64       `json:"height"`
-	Time   int64       `json:"time"`
-	Fills  []Fill      `json:"fills"`
-	Data   interface{} `json:"-"` // For additional fields
-}
+// dedupSize is how many recent blocks are remembered to skip re-deliveries
+const dedupSize = 64
 
-// Fill represents a single fill
-type Fill struct {
-	Symbol string  `json:"symbol"`
-	Side   string  `json:"side"`
-	Price  string  `json:"price"`
-	Size   string  `json:"size"`
-	Hash   string  `json:"hash"`
-}
+// fillHashDedupSize is how many recent fill hashes are remembered to detect
+// fills repeated across blocks
+const fillHashDedupSize = 10000
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not found")
+	cfg := config.Register(flag.CommandLine)
+	outFile := flag.String("out-file", "", "write the human-readable output to this file instead of stdout")
+	outBuffer := config.ByteSize(output.DefaultBufferSize)
+	flag.Var(&outBuffer, "out-buffer", "buffer up to this much output before writing it to stdout or -out-file, e.g. 64KB or 1MB")
+	outFlush := flag.Duration("out-flush-interval", output.DefaultFlushInterval, "write buffered output at least this often, 0 disables buffering and writes every line as it's printed")
+	rawDir := flag.String("raw-dir", "", "write every received block fills message, as received, to its own numbered file in this directory")
+	rawGzip := flag.String("raw-gzip", "", "write every received block fills message, as received, into this single gzip-compressed file of length-prefixed frames (replay with replay_blocks.go -raw-gzip)")
+	schemaPath := flag.String("schema", "", "validate every block fills against this JSON schema file and count the violations, disabled when empty")
+	sinkSpec := flag.String("sink", "", "also write every block fills message to a sink: "+strings.Join(sink.Names(), ", ")+", optionally followed by :path (stdout when omitted), disabled when empty")
+	schemaDumpDir := flag.String("schema-dump-dir", "", "write block fills payloads violating -schema to this directory, disabled when empty")
+	csvPath := flag.String("csv", "", "append every fill to this CSV file")
+	parquetPath := flag.String("parquet", "", "write every fill to this Apache Parquet file, replacing it if it exists")
+	statsEvery := flag.Int("stats-every", 10, "print per-symbol volume/VWAP and the buy/sell order flow every N blocks, 0 disables")
+	symbols := flag.String("symbols", "", "comma-separated symbols to show (case-insensitive), empty shows all")
+	precision := flag.Int("precision", decimal.Auto, "decimals shown for prices and sizes, -1 shows them as sent (computed values with up to 8 decimals)")
+	skewThreshold := flag.Duration("clock-skew-threshold", 2*time.Second, "warn when the local clock seems off against block times by more than this, 0 disables the check")
+	skewWindow := flag.Duration("clock-skew-window", time.Minute, "how long the skew must persist before -clock-skew-threshold warns")
+	printEvery := flag.Int("print-every", 1, "print the full summary of every Nth block only; all blocks are still counted, and alerts and -stats-every are printed as usual")
+	progress := flag.Bool("progress", true, "with -print-every, show a one-line progress indicator for the blocks in between")
+	topFillsN := flag.Int("top-fills", 3, "show the N largest fills of each block by size, 0 shows none")
+	sampleRate := flag.Float64("sample-rate", 1, "share of fills shown and added to the statistics, e.g. 0.1 for a random 10%; alerts, exports and the total count see every fill")
+	sampleSeed := flag.Uint64("sample-seed", 0, "seed for -sample-rate, so a replay of the same data samples the same fills; 0 picks a random one")
+	keepaliveTime := flag.Duration("keepalive-time", 30*time.Second, "interval between keepalive pings on an idle connection, 0 disables keepalive")
+	keepaliveTimeout := flag.Duration("keepalive-timeout", 10*time.Second, "how long to wait for a keepalive ping to be acknowledged")
+	limit := flag.Int("limit", 0, "stop after receiving this many block fills and print the summary, 0 streams until stopped")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 8*time.Second, "after Ctrl+C or SIGTERM, force exit if the summary isn't printed within this long, 0 waits indefinitely")
+	idleTimeout := flag.Duration("idle-timeout", 60*time.Second, "restart the stream when no message arrives for this long, 0 disables")
+	breakerThreshold := flag.Int("breaker-threshold", 5, "open the circuit breaker after this many consecutive reconnect failures within -breaker-window, 0 disables it")
+	breakerWindow := flag.Duration("breaker-window", 5*time.Minute, "time window in which -breaker-threshold failures open the circuit breaker")
+	breakerCooldown := flag.Duration("breaker-cooldown", 5*time.Minute, "how long an open circuit breaker waits before trying again; with a single endpoint the example exits instead")
+	maxMsgSize := config.ByteSize(client.DefaultMaxMessageSize)
+	flag.Var(&maxMsgSize, "max-msg-size", "maximum message size to receive, e.g. 256MB or 1GB")
+	watchConn := flag.Bool("watch-conn", false, "log every connection state transition (IDLE, CONNECTING, READY, TRANSIENT_FAILURE, ...)")
+	metricsAddr := flag.String("metrics-addr", "", "serve Prometheus metrics on this address (e.g. :9090), disabled when empty")
+	statsdAddr := flag.String("statsd-addr", "", "send metrics to the StatsD server at this UDP address (e.g. localhost:8125), disabled when empty")
+	parseErrors := parseerr.Handler{Policy: parseerr.Skip, Kind: "block fills"}
+	flag.Var(&parseErrors.Policy, "on-parse-error", "what to do with block fills that can't be parsed: skip, dump (write their bytes to -dump-dir) or fatal (exit)")
+	flag.StringVar(&parseErrors.Dir, "dump-dir", "parse-errors", "directory for block fills dumped by -on-parse-error dump")
+	var alerts priceAlerts
+	flag.Var(&alerts, "alert", `alert when a fill trades beyond a price, e.g. "BTC>65000" (repeatable or comma-separated; operators >, <, >=, <=)`)
+	noColor := flag.Bool("no-color", false, "don't color the output; colors are also off when NO_COLOR is set or the output isn't a terminal")
+	showVersion := flag.Bool("version", false, "print the version, commit and build date and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(buildinfo.String())
+		return
 	}
 
-	endpoint := os.Getenv("HYPERLIQUID_ENDPOINT")
-	apiKey := os.Getenv("API_KEY")
+	if err := cfg.LoadFile(); err != nil {
+		logging.Fatal("invalid -config file", "err", err)
+	}
+	if err := cfg.SetupLogging(); err != nil {
+		logging.Fatal("invalid logging configuration", "err", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	if warning := cfg.SecurityWarning(); warning != "" {
+		slog.Warn(warning)
+	}
+	if *limit < 0 {
+		logging.Fatal("-limit must not be negative", "limit", *limit)
+	}
+	if *skewThreshold < 0 || *skewWindow < 0 {
+		logging.Fatal("-clock-skew-threshold and -clock-skew-window must not be negative")
+	}
+	if *printEvery < 1 {
+		logging.Fatal("-print-every must be at least 1", "print-every", *printEvery)
+	}
+	if *topFillsN < 0 {
+		logging.Fatal("-top-fills must not be negative", "top-fills", *topFillsN)
+	}
+	if *precision < decimal.Auto {
+		logging.Fatal("-precision must be -1 or more", "precision", *precision)
+	}
+	if !(*sampleRate > 0 && *sampleRate <= 1) {
+		logging.Fatal("-sample-rate must be more than 0 and at most 1", "sample-rate", *sampleRate)
+	}
+	if *outFlush < 0 {
+		logging.Fatal("-out-flush-interval must not be negative", "out-flush-interval", *outFlush)
+	}
 
-	if endpoint == "" {
-		log.Fatal("Error: HYPERLIQUID_ENDPOINT environment variable is required.\n" +
-			"Please create a .env file from .env.example and set your endpoint.")
+	// -dry-run stops here, before any file is written or stream opened
+	if cfg.DryRun {
+		color.Setup(os.Stdout, *noColor)
+		os.Exit(cfg.RunDryRun(os.Stdout))
 	}
 
-	// API key is optional - some endpoints are public and don't require authentication
-	if apiKey == "" {
-		fmt.Println("ℹ️  No API key provided - connecting to public endpoint")
+	dest, err := output.Open(*outFile)
+	if err != nil {
+		logging.Fatal("failed to open output file", "path", *outFile, "err", err)
 	}
+	// Output is written in large chunks rather than line by line. os.Exit
+	// skips deferred calls, so exits also flush it explicitly.
+	out := output.NewBuffered(dest, int(outBuffer), *outFlush)
+	defer out.Close()
+	logging.OnExit(func() { out.Flush() })
+	color.Setup(out, *noColor)
 
-	fmt.Println("🚀 Hyperliquid Go gRPC Client - Stream Block Fills")
-	fmt.Println("===================================================")
-	fmt.Printf("📡 Endpoint: %s\n\n", endpoint)
+	var rawCapture *capture.Writer
+	if *rawDir != "" {
+		if rawCapture, err = capture.New(*rawDir, "block fills"); err != nil {
+			logging.Fatal("failed to create -raw-dir", "path", *rawDir, "err", err)
+		}
+	}
 
-	// Create TLS credentials
-	creds := credentials.NewTLS(nil)
+	// Closed explicitly once the stream ends, since os.Exit skips deferred
+	// calls and an unclosed capture lacks the gzip trailer
+	var rawGzipCapture *capture.GzipWriter
+	if *rawGzip != "" {
+		if rawGzipCapture, err = capture.NewGzip(*rawGzip); err != nil {
+			logging.Fatal("failed to create -raw-gzip", "path", *rawGzip, "err", err)
+		}
+	}
 
-	// Set up connection options
-	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(creds),
-		grpc.WithDefaultCallOptions(
-			grpc.MaxCallRecvMsgSize(150 * 1024 * 1024), // 150MB
-		),
+	// A nil validator skips validation entirely
+	var validator *schema.Validator
+	if *schemaPath != "" {
+		if validator, err = schema.Load(*schemaPath, "block fills"); err != nil {
+			logging.Fatal("failed to load -schema", "path", *schemaPath, "err", err)
+		}
+		if *schemaDumpDir != "" {
+			if err := validator.DumpTo(*schemaDumpDir); err != nil {
+				logging.Fatal("failed to create -schema-dump-dir", "path", *schemaDumpDir, "err", err)
+			}
+		}
+	} else if *schemaDumpDir != "" {
+		logging.Fatal("-schema-dump-dir needs -schema")
 	}
 
-	fmt.Println("🔌 Connecting to gRPC server...")
-	conn, err := grpc.Dial(endpoint, opts...)
-	if err != nil {
-		log.Fatalf("Failed to connect: %v", err)
+	// A nil sink means -sink is disabled. It is closed explicitly once the
+	// stream ends, since os.Exit skips deferred calls.
+	var fillsSink sink.Sink
+	if *sinkSpec != "" {
+		var err error
+		if fillsSink, err = sink.Open(*sinkSpec); err != nil {
+			logging.Fatal("failed to open -sink", "sink", *sinkSpec, "err", err)
+		}
 	}
-	defer conn.Close()
 
-	client := pb.NewHyperLiquidL1GatewayClient(conn)
-	fmt.Println("✅ Connected successfully!\n")
+	var fillsCSV *csvWriter
+	if *csvPath != "" {
+		var err error
+		fillsCSV, err = openCSV(*csvPath)
+		if err != nil {
+			logging.Fatal("failed to open CSV file", "path", *csvPath, "err", err)
+		}
+		defer fillsCSV.Close()
+	}
 
-	// Create context with metadata (API key) only if provided
-	ctx := context.Background()
-	if apiKey != "" {
-		ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", apiKey)
+	// The Parquet file is only valid once closed, which happens right after
+	// the stream ends rather than in a defer skipped by os.Exit
+	var fillsParquet *parquetWriter
+	if *parquetPath != "" {
+		if fillsParquet, err = createParquet(*parquetPath); err != nil {
+			logging.Fatal("failed to create Parquet file", "path", *parquetPath, "err", err)
+		}
 	}
 
-	// Create cancellable context for graceful shutdown
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+	filter := parseSymbolFilter(*symbols)
+	sampler := newFillSampler(*sampleRate, *sampleSeed)
+	// The handler reads the filter and alerts from here, so that a SIGHUP
+	// reload can swap them between two blocks
+	var live atomic.Pointer[liveFilters]
+	live.Store(&liveFilters{symbols: filter, alerts: slices.Clone(alerts)})
 
-	// Set up signal handling for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	// API key is optional - some endpoints are public and don't require authentication
+	if cfg.APIKey == "" {
+		slog.Info("no API key provided, connecting to a public endpoint")
+	}
+
+	fmt.Fprintln(out, "🚀 Hyperliquid Go gRPC Client - Stream Block Fills")
+	fmt.Fprintln(out, "===================================================")
+	fmt.Fprintf(out, "📡 Endpoints: %s\n", strings.Join(cfg.Endpoints(), ", "))
+	fmt.Fprintf(out, "🔒 Transport: %s\n", cfg.TransportDescription())
+	fmt.Fprintf(out, "⏱️  Start: %s\n", cfg.StartDescription())
+	fmt.Fprintf(out, "🎬 Mode: %s\n", cfg.StreamDescription())
+	if filter != nil {
+		fmt.Fprintf(out, "🔎 Symbols: %s\n", *symbols)
+	}
+	for _, alert := range alerts {
+		fmt.Fprintf(out, "🚨 Alert: %s\n", alert)
+	}
+	if sampler != nil {
+		fmt.Fprintf(out, "🎲 Sampling: %s of fills (seed %d)\n", sampleRateLabel(sampler.rate), sampler.seed)
+	}
+	fmt.Fprintf(out, "⚙️  Config precedence: %s\n\n", config.Precedence)
 
-	go func() {
-		<-sigChan
-		fmt.Println("\n🛑 Stopping stream...")
-		cancel()
-	}()
+	slog.Info("connecting to gRPC server", "endpoints", cfg.Endpoints())
+	// Keepalive pings detect connections silently dropped by intermediaries.
+	// The transport options from cfg are added on every dial.
+	connectOpts := []client.Option{
+		client.WithKeepalive(*keepaliveTime, *keepaliveTimeout),
+		client.WithMaxMessageSize(int(maxMsgSize)),
+	}
+	if *watchConn {
+		// Every connection, including ones made on reconnect, is watched until closed
+		watchCtx, stopWatching := context.WithCancel(context.Background())
+		defer stopWatching()
+		connectOpts = append(connectOpts, client.WithStateLogging(watchCtx))
+	}
 
-	// Create request - 0 means latest/current block fills
-	request := &pb.Timestamp{Timestamp: 0}
+	// cfgMu guards cfg and the flags against SIGHUP reloads
+	var cfgMu sync.Mutex
 
-	fmt.Println("📥 Starting block fills stream...")
-	fmt.Println("Press Ctrl+C to stop streaming\n")
+	// Endpoints are tried in priority order, and each reconnect fails over to
+	// the next one. A reconnect picks up a reloaded API key and TLS settings.
+	failover := client.NewFailover(cfg.Endpoints(), func(endpoint string) (*grpc.ClientConn, error) {
+		cfgMu.Lock()
+		apiKey, opts := cfg.APIKey, append(cfg.ConnectOptions(), connectOpts...)
+		cfgMu.Unlock()
+		return client.Connect(endpoint, apiKey, opts...)
+	})
+	ctx := context.Background()
 
-	// Start streaming block fills
-	stream, err := client.StreamBlockFills(ctx, request)
+	// The client connects lazily, so wait until an endpoint is actually ready
+	conn, err := failover.Connect(ctx, cfg.ConnectTimeout)
 	if err != nil {
-		log.Fatalf("Failed to start stream: %v", err)
+		logging.Exit(client.ExitConnectionFailure, "failed to connect", "err", err)
 	}
+	defer conn.Close()
+
+	slog.Info("connected", "endpoint", failover.Active())
+
+	// Without another endpoint to fail over to, an open breaker ends the run
+	breaker := client.WithCircuitBreaker(client.Breaker{
+		Threshold:    *breakerThreshold,
+		Window:       *breakerWindow,
+		Cooldown:     *breakerCooldown,
+		ExitWhenOpen: len(cfg.Endpoints()) == 1,
+	})
+
+	// First Ctrl+C (or SIGTERM) drains the stream, a second one or an overrun
+	// of -shutdown-timeout forces an immediate exit
+	ctx, stop := shutdown.Listen(ctx, *shutdownTimeout)
+	defer stop()
+
+	// Reaching -limit stops the stream the same way Ctrl+C does
+	ctx, stopAtLimit := context.WithCancel(ctx)
+	defer stopAtLimit()
+
+	// Create request - 0 means latest, otherwise replay from the start time (see -from)
+	request := &pb.Timestamp{Timestamp: cfg.RequestTimestamp()}
+
+	// With a -config file, SIGHUP reloads it: the symbol filter and alerts
+	// apply from the next block and connection settings through a
+	// controlled reconnect. Without one SIGHUP keeps its default action.
+	restart := make(chan struct{}, 1)
+	if cfg.ConfigFile != "" {
+		hangup := make(chan os.Signal, 1)
+		signal.Notify(hangup, syscall.SIGHUP)
+		defer signal.Stop(hangup)
+		go func() {
+			for range hangup {
+				cfgMu.Lock()
+				reloadConfig(cfg, failover, restart, &live, symbols, &alerts)
+				cfgMu.Unlock()
+			}
+		}()
+	}
+
+	fmt.Fprintln(out, "📥 Starting block fills stream...")
+	fmt.Fprint(out, "Press Ctrl+C to stop streaming (twice to force quit)\n\n")
+
+	// Metrics are only collected when an address to serve or send them to
+	// is given
+	var metricsOpts []metrics.Option
+	if *metricsAddr != "" {
+		metricsOpts = append(metricsOpts, metrics.WithPrometheus())
+		metrics.Serve(*metricsAddr)
+		fmt.Fprintf(out, "📈 Metrics: http://%s/metrics\n\n", *metricsAddr)
+	}
+	if *statsdAddr != "" {
+		statsd, err := metrics.DialStatsD(*statsdAddr)
+		if err != nil {
+			logging.Fatal("invalid -statsd-addr", "addr", *statsdAddr, "err", err)
+		}
+		defer statsd.Close()
+		metricsOpts = append(metricsOpts, metrics.WithStatsD(statsd))
+		fmt.Fprintf(out, "📈 StatsD: %s (udp)\n\n", *statsdAddr)
+	}
+	streamMetrics := metrics.NewStream("block_fills", metricsOpts...)
 
 	blockFillsCount := 0
+	emptyMessages := 0
+	var fillStats stats.FillStats
+	var sideStats stats.SideStats
+	// Remembers recent blocks so ones re-delivered after a reconnect are skipped
+	dedup := stats.NewDedup[stats.BlockKey](dedupSize)
+	// Remembers recent fill hashes to spot fills replayed in later blocks
+	fillHashes := stats.NewDedup[string](fillHashDedupSize)
+	var messageSizes stats.SizeStats
 
-	for {
-		response, err := stream.Recv()
-		if err == io.EOF {
-			break
+	// Arrival times characterise the feed's cadence in the final summary, and
+	// compared with block times show how far behind real time the feed is
+	var cadence stats.Cadence
+	var feedLag stats.FeedLag
+	// Historical replays lag legitimately until they catch up, so only a
+	// clock that is behind is detected for them
+	clockSkew := stats.ClockSkew{
+		Threshold:   *skewThreshold,
+		Window:      *skewWindow,
+		IgnoreAhead: cfg.StartMode() == config.FromTimestamp,
+	}
+	streamStart := time.Now()
+
+	// Block fills drained after Ctrl+C still go to the sink
+	sinkCtx := context.WithoutCancel(ctx)
+
+	err = client.StreamWithReconnect(ctx, conn, failover.Redial, pb.HyperLiquidL1GatewayClient.StreamBlockFills, request, func(response *pb.BlockFills) {
+		// Some endpoints send empty heartbeat-like frames, which aren't block fills
+		if len(response.Data) == 0 {
+			emptyMessages++
+			slog.Debug("skipping empty message")
+			return
 		}
-		if err != nil {
-			if ctx.Err() == context.Canceled {
-				break
+
+		// Decode the typed fills once for deduplication, CSV export and statistics
+		blockFills, decodeErr := model.DecodeBlockFills(response.Data)
+
+		// Re-delivered blocks are skipped before anything (counters,
+		// captures, validation, the sink) sees them
+		if decodeErr == nil && blockFills.Height != 0 {
+			key := stats.BlockKey{Height: blockFills.Height, Time: strconv.FormatInt(blockFills.Time, 10)}
+			if dedup.Seen(key) {
+				slog.Debug("skipping duplicate block fills", "height", key.Height, "time", key.Time)
+				return
 			}
-			log.Printf("❌ Stream error: %v", err)
-			break
 		}
 
 		blockFillsCount++
-		fmt.Printf("\n===== BLOCK FILLS #%d =====\n", blockFillsCount)
-		fmt.Printf("📦 Response size: %d bytes\n", len(response.Data))
+		if blockFillsCount == *limit {
+			stopAtLimit()
+		}
+		receivedAt := time.Now()
+		cadence.Observe(receivedAt)
+		streamMetrics.Received(len(response.Data))
+		messageSizes.Add(len(response.Data))
 
-		// Process block fills
-		processBlockFills(response.Data, blockFillsCount)
+		if decodeErr == nil && blockFills.Time > 0 {
+			// Handles both seconds and milliseconds
+			produced := model.UnixTime(blockFills.Time)
+			feedLag.Observe(produced, receivedAt)
+			streamMetrics.Lag(receivedAt.Sub(produced))
+			if warning := clockSkew.Observe(produced, receivedAt); warning != "" {
+				slog.Warn(warning, "height", blockFills.Height)
+			}
+		}
 
-		fmt.Println("\n" + "─────────────────────────────────────────────────")
+		var height int64
+		if decodeErr == nil {
+			height = blockFills.Height
+		}
+		if rawCapture != nil {
+			if _, err := rawCapture.Write(blockFillsCount, height, response.Data); err != nil {
+				slog.Error("failed to capture block fills", "block", blockFillsCount, "err", err)
+			}
+		}
+		if rawGzipCapture != nil {
+			if err := rawGzipCapture.Write(response.Data); err != nil {
+				slog.Error("failed to capture block fills", "path", *rawGzip, "block", blockFillsCount, "err", err)
+			}
+		}
+		validator.Check(blockFillsCount, height, response.Data)
+
+		if fillsSink != nil {
+			meta := sink.Meta{Kind: "block fills", Num: blockFillsCount, Height: height, ReceivedAt: receivedAt}
+			if decodeErr == nil && blockFills.Time > 0 {
+				meta.Time = model.UnixTime(blockFills.Time).UnixMilli()
+			}
+			if err := fillsSink.Write(sinkCtx, response.Data, meta); err != nil {
+				slog.Error("failed to write block fills to sink", "block", blockFillsCount, "err", err)
+			}
+		}
+
+		// A panic on an unexpected payload is logged and counted instead of
+		// ending the stream
+		parseErrors.Guard(blockFillsCount, response.Data, func() {
+			filters := live.Load()
+
+			// With -print-every, the blocks in between are processed the same
+			// way but their summary is discarded
+			var summaryOut io.Writer = out
+			if blockFillsCount%*printEvery != 0 {
+				summaryOut = io.Discard
+			}
+
+			fmt.Fprintf(summaryOut, "\n===== BLOCK FILLS #%d =====\n", blockFillsCount)
+			fmt.Fprintf(summaryOut, "📦 Response size: %d bytes\n", len(response.Data))
+
+			// The same sample of fills is shown and added to the statistics
+			var keep []bool
+			if decodeErr == nil {
+				keep = sampler.sample(len(blockFills.Fills))
+			}
+
+			// Process block fills, decoded once above
+			if decodeErr != nil {
+				parseErrors.Handle(blockFillsCount, response.Data, decodeErr)
+				streamMetrics.ParseError()
+			} else {
+				processBlockFills(summaryOut, blockFills, blockFillsCount, filters.symbols, keep, *topFillsN, *precision)
+			}
+			if summaryOut == io.Discard && *progress {
+				// Overwritten in place until the next full summary
+				next := blockFillsCount + *printEvery - blockFillsCount%*printEvery
+				fmt.Fprintf(out, "\r⏩ Block fills #%d (height %d), next summary at #%d", blockFillsCount, height, next)
+			}
+
+			if decodeErr == nil {
+				filters.alerts.Check(out, blockFills, *precision)
+
+				if duplicates := countDuplicateHashes(fillHashes, blockFills); duplicates > 0 {
+					slog.Warn("fill hashes already seen in an earlier block", "height", blockFills.Height, "count", duplicates)
+				}
+			}
+
+			// Undecodable fills were reported to parseErrors above
+			if decodeErr == nil {
+				if fillsCSV != nil {
+					if err := fillsCSV.Write(blockFills); err != nil {
+						slog.Error("failed to write CSV", "path", *csvPath, "err", err)
+					}
+				}
+				if fillsParquet != nil {
+					if err := fillsParquet.Write(blockFills); err != nil {
+						slog.Error("failed to write Parquet", "path", *parquetPath, "err", err)
+					}
+				}
+				if *statsEvery > 0 {
+					addFillStats(&fillStats, &sideStats, blockFills, filters.symbols, keep)
+				}
+			}
+
+			if *statsEvery > 0 && blockFillsCount%*statsEvery == 0 {
+				printFillStats(out, &fillStats, *precision)
+				printSideStats(out, &sideStats, *precision)
+				printSampling(out, sampler)
+				printFeedLag(out, &feedLag)
+			}
+
+			fmt.Fprintln(summaryOut, "\n"+"─────────────────────────────────────────────────")
+		})
+	}, client.WithIdleTimeout(*idleTimeout), client.WithRestart(restart), breaker)
+	if fillsParquet != nil {
+		if closeErr := fillsParquet.Close(); closeErr != nil {
+			slog.Error("failed to close Parquet file", "path", *parquetPath, "err", closeErr)
+		}
+	}
+	if rawGzipCapture != nil {
+		if closeErr := rawGzipCapture.Close(); closeErr != nil {
+			slog.Error("failed to close -raw-gzip", "path", *rawGzip, "err", closeErr)
+		}
+	}
+	if fillsSink != nil {
+		if closeErr := fillsSink.Close(); closeErr != nil {
+			slog.Error("failed to close -sink", "sink", *sinkSpec, "err", closeErr)
+		}
+	}
+	exitCode := client.ExitOK
+	if err != nil {
+		message, auth := client.ClassifyError(err)
+		if auth {
+			logging.Exit(client.ExitAuthFailure, message, "err", err)
+		}
+		slog.Error("stream ended with an error", "err", err)
+		exitCode = client.ExitStreamError
 	}
 
-	fmt.Printf("\n📊 Total block fills received: %d\n", blockFillsCount)
+	fmt.Fprintf(out, "\n📊 Total block fills received: %d\n", blockFillsCount)
+	fmt.Fprintf(out, "⏲️  Run duration: %v\n", time.Since(streamStart).Round(time.Millisecond))
+	if blockFillsCount > 1 {
+		fmt.Fprintf(out, "📶 Mean interarrival: %v, longest gap: %v\n",
+			cadence.MeanInterarrival().Round(time.Millisecond), cadence.LongestGap().Round(time.Millisecond))
+	}
+	printFeedLag(out, &feedLag)
+	fmt.Fprintf(out, "🔁 Duplicate blocks skipped: %d\n", dedup.Duplicates())
+	fmt.Fprintf(out, "📭 Empty messages skipped: %d\n", emptyMessages)
+	fmt.Fprintf(out, "💥 Panics recovered: %d\n", parseErrors.Panics())
+	if validator != nil {
+		fmt.Fprintf(out, "🧩 Schema violations: %d\n", validator.Violations())
+	}
+	if rawGzipCapture != nil {
+		fmt.Fprintf(out, "🗜️  Messages captured to %s: %d\n", *rawGzip, rawGzipCapture.Frames())
+	}
+	fmt.Fprintf(out, "🔂 Duplicate fill hashes: %s\n", color.If(fillHashes.Duplicates() > 0, color.Yellow, fillHashes.Duplicates()))
+	if sizes := messageSizes.Summary(); sizes.Count > 0 {
+		fmt.Fprintf(out, "📐 Message sizes (bytes): min %d, max %d, mean %.0f, median %d, p95 %d\n",
+			sizes.Min, sizes.Max, sizes.Mean, sizes.Median, sizes.P95)
+	}
+	if *statsEvery > 0 {
+		printFillStats(out, &fillStats, *precision)
+		printSideStats(out, &sideStats, *precision)
+	}
+	printSampling(out, sampler)
+	if fillsCSV != nil {
+		fmt.Fprintf(out, "💾 Fills written to %s\n", *csvPath)
+	}
+	if fillsParquet != nil {
+		fmt.Fprintf(out, "🧱 Fills written to %s: %d rows in %d row groups\n", *parquetPath, fillsParquet.Rows(), fillsParquet.RowGroups())
+	}
+
+	// The summary is printed and all output flushed, so skipping deferred
+	// cleanup is safe
+	if exitCode != client.ExitOK {
+		out.Flush()
+		os.Exit(exitCode)
+	}
 }
 
-func processBlockFills(data []byte, blockFillsNum int) {
-	// First unmarshal into a generic map to handle flexible structure
-	var rawData map[string]interface{}
-	if err := json.Unmarshal(data, &rawData); err != nil {
-		// Try as list
-		var listData []interface{}
-		if err := json.Unmarshal(data, &listData); err != nil {
-			log.Printf("❌ Failed to parse JSON: %v", err)
-			log.Printf("Raw data (first 200 bytes): %s", data[:min(200, len(data))])
-			return
+// addFillStats adds the fills of a block that pass filter and are marked in
+// keep, when not nil, to the per-symbol and per-side stats
+func addFillStats(fillStats *stats.FillStats, sideStats *stats.SideStats, blockFills *model.BlockFills, filter symbolFilter, keep []bool) {
+	for i, fill := range blockFills.Fills {
+		if !filter.Matches(fill.Symbol) || (keep != nil && !keep[i]) {
+			continue
+		}
+		if err := fillStats.Add(fill.Symbol, fill.Price, fill.Size); err != nil {
+			slog.Warn("skipping fill in stats", "err", err)
 		}
-		// Handle list case
-		fmt.Printf("💰 BLOCK FILLS #%d DETAILS\n", blockFillsNum)
-		fmt.Println("========================")
-		fmt.Println("\n📊 Block Fills Summary:")
-		fmt.Printf("• Block fills is a list with %d items\n", len(listData))
-		if len(listData) > 0 {
-			fmt.Printf("• First item type: %T\n", listData[0])
+		if err := sideStats.Add(fill.Side, fill.Size); err != nil {
+			slog.Warn("skipping fill in side stats", "symbol", fill.Symbol, "err", err)
 		}
+	}
+}
+
+// fillSampler picks a random share of the fills for display and statistics.
+// Every fill gets a draw in receive order, so the same seed over the same
+// data picks the same fills. A nil sampler keeps every fill.
+type fillSampler struct {
+	rate float64
+	seed uint64
+	rng  *rand.Rand
+
+	seen int64
+	kept int64
+}
+
+// newFillSampler returns a sampler keeping rate of the fills, or nil for a
+// rate of 1. A zero seed is replaced by a random one.
+func newFillSampler(rate float64, seed uint64) *fillSampler {
+	if rate >= 1 {
+		return nil
+	}
+	for seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &fillSampler{rate: rate, seed: seed, rng: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// sample returns which of a block's n fills to keep, or nil to keep all
+func (s *fillSampler) sample(n int) []bool {
+	if s == nil {
+		return nil
+	}
+
+	keep := make([]bool, n)
+	for i := range keep {
+		if s.rng.Float64() < s.rate {
+			keep[i] = true
+			s.kept++
+		}
+	}
+	s.seen += int64(n)
+	return keep
+}
+
+// sampleRateLabel formats a sample rate as a percentage
+func sampleRateLabel(rate float64) string {
+	return strconv.FormatFloat(rate*100, 'f', -1, 64) + "%"
+}
+
+// printSampling prints how many fills -sample-rate kept, if it is set
+func printSampling(w io.Writer, s *fillSampler) {
+	if s == nil {
 		return
 	}
+	fmt.Fprintf(w, "🎲 Sampled fills: %d of %d (rate %s); counts, sizes and notional cover the sample only\n", s.kept, s.seen, sampleRateLabel(s.rate))
+}
 
-	fmt.Printf("💰 BLOCK FILLS #%d DETAILS\n", blockFillsNum)
-	fmt.Println("========================")
+// printFeedLag prints the rolling average and the largest delay between
+// block time and receive time
+func printFeedLag(w io.Writer, l *stats.FeedLag) {
+	if l.Count() == 0 {
+		return
+	}
+	fmt.Fprintf(w, "🐢 Feed lag: %v (average of the last %d blocks), max %v\n",
+		l.Average().Round(time.Millisecond), l.Window(), l.Max().Round(time.Millisecond))
+}
 
-	// Display block height if available
-	if height, ok := rawData["height"].(float64); ok {
-		fmt.Printf("📏 Block Height: %.0f\n", height)
+// printSideStats prints buy and sell fill counts and sizes, with precision
+// decimals, and the imbalance between them
+func printSideStats(w io.Writer, sideStats *stats.SideStats, precision int) {
+	if sideStats.Fills() == 0 {
+		return
 	}
 
-	// Display timestamp
-	if timeVal, ok := rawData["time"]; ok {
-		var timestamp int64
-		switch v := timeVal.(type) {
-		case float64:
-			timestamp = int64(v)
-		case int64:
-			timestamp = v
-		}
-
-		if timestamp > 0 {
-			// Convert from milliseconds to seconds if needed
-			if timestamp > 10000000000 { // Likely milliseconds
-				timestamp = timestamp / 1000
-			}
-			t := time.Unix(timestamp, 0)
-			fmt.Printf("⏰ Time: %s\n", t.UTC().Format("2006-01-02 15:04:05 UTC"))
+	buys, sells := sideStats.Buys(), sideStats.Sells()
+	fmt.Fprintf(w, "\n⚖️  Order flow: %d buys (size %s), %d sells (size %s)", buys.Fills, decimal.Format(buys.Size, precision), sells.Fills, decimal.Format(sells.Size, precision))
+	if imbalance, ok := sideStats.Imbalance(); ok {
+		direction := "buy"
+		if imbalance < 0 {
+			direction = "sell"
+		}
+		fmt.Fprintf(w, ", size imbalance %+.1f%% (%s)", imbalance*100, direction)
+	}
+	fmt.Fprintln(w)
+}
+
+// printFillStats prints the per-symbol volume table sorted by notional, with
+// precision decimals
+func printFillStats(w io.Writer, fillStats *stats.FillStats, precision int) {
+	if fillStats.Len() == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\n📈 Volume by symbol (sorted by notional):")
+	fmt.Fprintf(w, "  %-12s %8s %20s %22s %16s\n", "SYMBOL", "FILLS", "SIZE", "NOTIONAL", "VWAP")
+	for _, s := range fillStats.ByNotional() {
+		vwap := "-"
+		if v := s.VWAP(); v != nil {
+			vwap = decimal.Format(v, precision)
 		}
+		fmt.Fprintf(w, "  %-12s %8d %20s %22s %16s\n", s.Symbol, s.Fills, decimal.Format(s.Size, precision), decimal.Format(s.Notional, precision), vwap)
 	}
+}
 
-	// Display fills data
-	if fillsData, ok := rawData["fills"].([]interface{}); ok {
-		fmt.Printf("📋 Total Fills: %d\n", len(fillsData))
+// csvHeader lists the columns written by csvWriter
+var csvHeader = []string{"height", "time", "symbol", "side", "price", "size", "hash"}
 
-		// Show first few fill details
-		maxFills := min(3, len(fillsData))
+// csvWriter appends fills to a CSV file, one row per fill
+type csvWriter struct {
+	file *os.File
+	w    *csv.Writer
+}
 
-		for i := 0; i < maxFills; i++ {
-			fillInfo := fmt.Sprintf("  • FILL %d: ", i+1)
+// openCSV opens path for appending, writing the header only when the file is new
+func openCSV(path string) (*csvWriter, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
 
-			if fillMap, ok := fillsData[i].(map[string]interface{}); ok {
-				if symbol, ok := fillMap["symbol"].(string); ok {
-					fillInfo += fmt.Sprintf("Symbol: %s", symbol)
-				}
-				if side, ok := fillMap["side"].(string); ok {
-					fillInfo += fmt.Sprintf(", Side: %s", side)
-				}
-				if price, ok := fillMap["price"].(string); ok {
-					fillInfo += fmt.Sprintf(", Price: %s", price)
-				} else if price, ok := fillMap["price"].(float64); ok {
-					fillInfo += fmt.Sprintf(", Price: %.2f", price)
-				}
-				if size, ok := fillMap["size"].(string); ok {
-					fillInfo += fmt.Sprintf(", Size: %s", size)
-				} else if size, ok := fillMap["size"].(float64); ok {
-					fillInfo += fmt.Sprintf(", Size: %.2f", size)
-				}
-				if hash, ok := fillMap["hash"].(string); ok {
-					if len(hash) > 12 {
-						fillInfo += fmt.Sprintf(", Hash: %s...", hash[:12])
-					} else {
-						fillInfo += fmt.Sprintf(", Hash: %s", hash)
-					}
-				}
-			} else {
-				fillInfo += fmt.Sprintf("%v", fillsData[i])
-			}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
 
-			fmt.Println(fillInfo)
+	c := &csvWriter{file: file, w: csv.NewWriter(file)}
+	if info.Size() == 0 {
+		if err := c.w.Write(csvHeader); err != nil {
+			file.Close()
+			return nil, err
 		}
+	}
+	return c, nil
+}
 
-		if len(fillsData) > maxFills {
-			fmt.Printf("  ... and %d more fills\n", len(fillsData)-maxFills)
+// Write writes one row per fill and flushes after every block so that at
+// most one block is lost on a crash
+func (c *csvWriter) Write(blockFills *model.BlockFills) error {
+	height := strconv.FormatInt(blockFills.Height, 10)
+	timestamp := strconv.FormatInt(blockFills.Time, 10)
+	for _, fill := range blockFills.Fills {
+		row := []string{height, timestamp, fill.Symbol, fill.Side, fill.Price, fill.Size, fill.Hash}
+		if err := c.w.Write(row); err != nil {
+			return err
 		}
 	}
 
-	// Display any other interesting fields
-	fmt.Println("\n📊 Block Fills Summary:")
-	for key, value := range rawData {
-		if key == "height" || key == "time" || key == "fills" {
-			// Already displayed above
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Close flushes pending rows and closes the file
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		c.file.Close()
+		return err
+	}
+	return c.file.Close()
+}
+
+// Row groups of the -parquet file are flushed after this many fills or this
+// long, whichever comes first
+const (
+	parquetRowGroupFills = 100_000
+	parquetFlushInterval = time.Minute
+)
+
+// parquetFill is one row of the -parquet file: a fill with its block's
+// height and time. Prices and sizes are kept as strings to keep the exact
+// decimals.
+type parquetFill struct {
+	Height int64  `parquet:"height"`
+	Time   int64  `parquet:"time"`
+	Symbol string `parquet:"symbol,dict"`
+	Side   string `parquet:"side,dict"`
+	Price  string `parquet:"price"`
+	Size   string `parquet:"size"`
+	Hash   string `parquet:"hash"`
+}
+
+// parquetWriter writes fills to a Parquet file. Rows are buffered by the
+// Parquet writer and flushed as a row group every parquetRowGroupFills
+// fills or parquetFlushInterval.
+type parquetWriter struct {
+	file      *os.File
+	w         *parquet.GenericWriter[parquetFill]
+	rows      []parquetFill
+	pending   int
+	written   int
+	rowGroups int
+	lastFlush time.Time
+}
+
+// createParquet creates the Parquet file at path, replacing an existing one
+func createParquet(path string) (*parquetWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &parquetWriter{
+		file:      file,
+		w:         parquet.NewGenericWriter[parquetFill](file, parquet.Compression(&parquet.Zstd)),
+		lastFlush: time.Now(),
+	}, nil
+}
+
+// Write buffers one row per fill and flushes a row group when enough fills
+// are pending or the last flush is old enough
+func (p *parquetWriter) Write(blockFills *model.BlockFills) error {
+	p.rows = p.rows[:0]
+	for _, fill := range blockFills.Fills {
+		p.rows = append(p.rows, parquetFill{
+			Height: blockFills.Height,
+			Time:   blockFills.Time,
+			Symbol: fill.Symbol,
+			Side:   fill.Side,
+			Price:  fill.Price,
+			Size:   fill.Size,
+			Hash:   fill.Hash,
+		})
+	}
+	if _, err := p.w.Write(p.rows); err != nil {
+		return err
+	}
+	p.pending += len(p.rows)
+
+	if p.pending >= parquetRowGroupFills || time.Since(p.lastFlush) >= parquetFlushInterval {
+		return p.flush()
+	}
+	return nil
+}
+
+// flush writes the pending rows as a row group
+func (p *parquetWriter) flush() error {
+	p.lastFlush = time.Now()
+	if p.pending == 0 {
+		return nil
+	}
+	if err := p.w.Flush(); err != nil {
+		return err
+	}
+	p.written += p.pending
+	p.pending = 0
+	p.rowGroups++
+	return nil
+}
+
+// Rows returns the number of fills written to the file.
+func (p *parquetWriter) Rows() int {
+	return p.written
+}
+
+// RowGroups returns the number of row groups written to the file.
+func (p *parquetWriter) RowGroups() int {
+	return p.rowGroups
+}
+
+// Close flushes the pending rows and writes the footer, which makes the file
+// readable, then closes it
+func (p *parquetWriter) Close() error {
+	if err := p.flush(); err != nil {
+		p.file.Close()
+		return err
+	}
+	if err := p.w.Close(); err != nil {
+		p.file.Close()
+		return err
+	}
+	return p.file.Close()
+}
+
+// priceAlert fires when a fill of Symbol trades at a price satisfying Op
+// against Threshold
+type priceAlert struct {
+	Symbol    string
+	Op        string
+	Threshold *big.Rat
+	// Price is the threshold as given on the command line
+	Price string
+}
+
+// String formats the alert the way it is given on the command line
+func (a priceAlert) String() string {
+	return a.Symbol + a.Op + a.Price
+}
+
+// Matches reports whether price satisfies the alert condition
+func (a priceAlert) Matches(price *big.Rat) bool {
+	cmp := price.Cmp(a.Threshold)
+	switch a.Op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// alertOperators are tried in order, so two-character operators come first
+var alertOperators = []string{">=", "<=", ">", "<"}
+
+// parsePriceAlert parses an alert such as "BTC>65000"
+func parsePriceAlert(spec string) (priceAlert, error) {
+	for _, op := range alertOperators {
+		i := strings.Index(spec, op)
+		if i < 0 {
 			continue
 		}
 
-		// Display other fields
-		switch v := value.(type) {
-		case map[string]interface{}, []interface{}:
-			jsonBytes, _ := json.Marshal(v)
-			jsonStr := string(jsonBytes)
-			if len(jsonStr) > 100 {
-				fmt.Printf("• %s: %s...\n", key, jsonStr[:100])
-			} else {
-				fmt.Printf("• %s: %s\n", key, jsonStr)
+		symbol := strings.ToUpper(strings.TrimSpace(spec[:i]))
+		price := strings.TrimSpace(spec[i+len(op):])
+		threshold, err := decimal.Parse(price)
+		if symbol == "" || err != nil {
+			break
+		}
+		return priceAlert{Symbol: symbol, Op: op, Threshold: threshold, Price: price}, nil
+	}
+	return priceAlert{}, fmt.Errorf("invalid alert %q (expected SYMBOL>PRICE, with >, <, >= or <=)", spec)
+}
+
+// priceAlerts collects the repeatable -alert flag
+type priceAlerts []priceAlert
+
+// String implements flag.Value
+func (a *priceAlerts) String() string {
+	specs := make([]string, len(*a))
+	for i, alert := range *a {
+		specs[i] = alert.String()
+	}
+	return strings.Join(specs, ",")
+}
+
+// Set implements flag.Value. spec may hold several comma-separated alerts.
+func (a *priceAlerts) Set(spec string) error {
+	for _, one := range strings.Split(spec, ",") {
+		alert, err := parsePriceAlert(one)
+		if err != nil {
+			return err
+		}
+		*a = append(*a, alert)
+	}
+	return nil
+}
+
+// Reset removes all alerts, so that reloading the config file replaces them
+func (a *priceAlerts) Reset() {
+	*a = nil
+}
+
+// Check prints an alert line, with precision decimals, for every fill of
+// blockFills that matches an alert
+func (a priceAlerts) Check(w io.Writer, blockFills *model.BlockFills, precision int) {
+	for _, fill := range blockFills.Fills {
+		for _, alert := range a {
+			if !strings.EqualFold(fill.Symbol, alert.Symbol) {
+				continue
+			}
+			price, err := decimal.Parse(fill.Price)
+			if err != nil {
+				slog.Warn("skipping fill with invalid price in alerts", "symbol", fill.Symbol, "price", fill.Price)
+				break
+			}
+			if alert.Matches(price) {
+				fmt.Fprintln(w, "\n"+color.Red(fmt.Sprintf("🚨🚨 ALERT %s: %s %s %s @ %s (height %d)", alert, fill.Symbol, fill.Side,
+					decimal.FormatString(fill.Size, precision), decimal.FormatString(fill.Price, precision), blockFills.Height)))
 			}
+		}
+	}
+}
+
+// liveFilters are the settings applied to each block that a SIGHUP reload
+// can change
+type liveFilters struct {
+	symbols symbolFilter
+	alerts  priceAlerts
+}
+
+// reloadConfig re-reads the -config file and applies what changed: the
+// symbol filter and alerts for the next block, and connection settings by
+// restarting the stream on a new connection. symbolList and alerts are the
+// flag values the reload updates. The caller holds the lock guarding cfg.
+func reloadConfig(cfg *config.Config, failover *client.Failover, restart chan<- struct{}, live *atomic.Pointer[liveFilters], symbolList *string, alerts *priceAlerts) {
+	changed, err := cfg.Reload()
+	if err != nil {
+		slog.Error("failed to reload configuration, keeping the current one", "path", cfg.ConfigFile, "err", err)
+		return
+	}
+	if len(changed) == 0 {
+		slog.Info("configuration reloaded, nothing changed", "path", cfg.ConfigFile)
+		return
+	}
+
+	reconnect := false
+	var needRestart []string
+	for _, name := range changed {
+		switch {
+		case config.NeedsReconnect(name):
+			reconnect = true
+		case name == "symbols" || name == "alert" || name == "log-level" || name == "log-format":
+		default:
+			needRestart = append(needRestart, name)
+		}
+	}
+	slog.Info("configuration reloaded", "path", cfg.ConfigFile, "changed", changed)
+
+	live.Store(&liveFilters{symbols: parseSymbolFilter(*symbolList), alerts: slices.Clone(*alerts)})
+	if slices.Contains(changed, "symbols") {
+		slog.Info("symbol filter reloaded", "symbols", *symbolList)
+	}
+	if slices.Contains(changed, "alert") {
+		slog.Info("alerts reloaded", "alerts", alerts.String())
+	}
+	if len(needRestart) > 0 {
+		slog.Warn("reloaded settings take effect on the next start", "settings", needRestart)
+	}
+	if reconnect {
+		failover.SetEndpoints(cfg.Endpoints())
+		slog.Info("reconnecting to apply the reloaded connection settings", "endpoints", cfg.Endpoints())
+		// A restart already pending covers this one too
+		select {
+		case restart <- struct{}{}:
 		default:
-			fmt.Printf("• %s: %v\n", key, value)
 		}
 	}
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
+// symbolFilter is a set of upper-cased symbols. A nil filter matches every
+// symbol.
+type symbolFilter map[string]bool
+
+// parseSymbolFilter parses a comma-separated symbol list
+func parseSymbolFilter(list string) symbolFilter {
+	var filter symbolFilter
+	for _, symbol := range strings.Split(list, ",") {
+		symbol = strings.TrimSpace(symbol)
+		if symbol == "" {
+			continue
+		}
+		if filter == nil {
+			filter = make(symbolFilter)
+		}
+		filter[strings.ToUpper(symbol)] = true
+	}
+	return filter
+}
+
+// Matches reports whether symbol passes the filter
+func (f symbolFilter) Matches(symbol string) bool {
+	return f == nil || f[strings.ToUpper(symbol)]
+}
+
+// apply returns the fills whose symbol passes the filter
+func (f symbolFilter) apply(fills []model.Fill) []model.Fill {
+	if f == nil {
+		return fills
+	}
+
+	matched := make([]model.Fill, 0, len(fills))
+	for _, fill := range fills {
+		if f.Matches(fill.Symbol) {
+			matched = append(matched, fill)
+		}
+	}
+	return matched
+}
+
+// sampleFills returns the fills marked in keep, or all of them when keep is
+// nil
+func sampleFills(fills []model.Fill, keep []bool) []model.Fill {
+	if keep == nil {
+		return fills
+	}
+
+	sampled := make([]model.Fill, 0, len(fills))
+	for i, fill := range fills {
+		if keep[i] {
+			sampled = append(sampled, fill)
+		}
+	}
+	return sampled
+}
+
+// countDuplicateHashes records the fill hashes of a block and returns how
+// many of them were already seen in earlier blocks. Fills of one transaction
+// share its hash, so repeats within the block are not counted.
+func countDuplicateHashes(seen *stats.Dedup[string], blockFills *model.BlockFills) int {
+	inBlock := make(map[string]bool, len(blockFills.Fills))
+	duplicates := 0
+	for _, fill := range blockFills.Fills {
+		if fill.Hash == "" || inBlock[fill.Hash] {
+			continue
+		}
+		inBlock[fill.Hash] = true
+		if seen.Seen(fill.Hash) {
+			duplicates++
+		}
+	}
+	return duplicates
+}
+
+// processBlockFills prints the summary of decoded block fills, showing the
+// topN largest fills that pass filter with precision decimals
+func processBlockFills(w io.Writer, blockFills *model.BlockFills, blockFillsNum int, filter symbolFilter, keep []bool, topN, precision int) {
+	fmt.Fprintf(w, "💰 BLOCK FILLS #%d DETAILS\n", blockFillsNum)
+	fmt.Fprintln(w, "========================")
+
+	// Display block height if available
+	if blockFills.Height != 0 {
+		fmt.Fprintf(w, "📏 Block Height: %d\n", blockFills.Height)
+	}
+
+	// Display timestamp
+	if blockFills.Time > 0 {
+		// Handles both seconds and milliseconds
+		t := model.UnixTime(blockFills.Time)
+		fmt.Fprintf(w, "⏰ Time: %s\n", t.UTC().Format("2006-01-02 15:04:05 UTC"))
+	}
+
+	// A sample decoded from a different fills list doesn't apply
+	allFills := blockFills.Fills
+	if len(keep) != len(allFills) {
+		keep = nil
+	}
+	fillsData := filter.apply(sampleFills(allFills, keep))
+	switch {
+	case keep != nil && filter != nil:
+		fmt.Fprintf(w, "📋 Total Fills: %d sampled and matched of %d\n", len(fillsData), len(allFills))
+	case keep != nil:
+		fmt.Fprintf(w, "📋 Total Fills: %d sampled of %d\n", len(fillsData), len(allFills))
+	case filter != nil:
+		fmt.Fprintf(w, "📋 Total Fills: %d matched of %d\n", len(fillsData), len(allFills))
+	default:
+		fmt.Fprintf(w, "📋 Total Fills: %d\n", len(fillsData))
+	}
+
+	// Show the largest fills, which say more about the block than the first ones
+	fillsData = largestFills(fillsData)
+	maxFills := min(topN, len(fillsData))
+
+	for i, fill := range fillsData[:maxFills] {
+		fmt.Fprintf(w, "  • FILL %d: Symbol: %s, Side: %s, Price: %s, Size: %s, Hash: %s\n", i+1,
+			fill.Symbol, colorSide(fill.Side), decimal.FormatString(fill.Price, precision),
+			decimal.FormatString(fill.Size, precision), util.TruncateString(fill.Hash, 12))
+	}
+
+	if len(fillsData) > maxFills {
+		fmt.Fprintf(w, "  ... and %d more fills\n", len(fillsData)-maxFills)
+	}
+}
+
+// largestFills returns fills sorted by size, largest first. Fills whose size
+// can't be parsed keep their order after all others.
+func largestFills(fills []model.Fill) []model.Fill {
+	sizes := make([]*big.Rat, len(fills))
+	for i, fill := range fills {
+		sizes[i], _ = decimal.Parse(fill.Size)
+	}
+
+	order := make([]int, len(fills))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		sizeA, sizeB := sizes[order[a]], sizes[order[b]]
+		if sizeA == nil || sizeB == nil {
+			return sizeB == nil && sizeA != nil
+		}
+		return sizeA.Cmp(sizeB) > 0
+	})
+
+	sorted := make([]model.Fill, len(fills))
+	for i, j := range order {
+		sorted[i] = fills[j]
+	}
+	return sorted
+}
+
+// colorSide colors the feed's B (bid, a buy) green and A (ask, a sell) red
+func colorSide(side string) string {
+	switch side {
+	case "B":
+		return color.Green(side)
+	case "A":
+		return color.Red(side)
+	default:
+		return side
 	}
-	return b
 }
@@ -1,283 +1,714 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/metadata"
 
 	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
-	"github.com/joho/godotenv"
+	"github.com/dwellir/grpc-code-examples/go/internal/buildinfo"
+	"github.com/dwellir/grpc-code-examples/go/internal/capture"
+	"github.com/dwellir/grpc-code-examples/go/internal/client"
+	"github.com/dwellir/grpc-code-examples/go/internal/color"
+	"github.com/dwellir/grpc-code-examples/go/internal/config"
+	"github.com/dwellir/grpc-code-examples/go/internal/display"
+	"github.com/dwellir/grpc-code-examples/go/internal/inspect"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+	"github.com/dwellir/grpc-code-examples/go/internal/metrics"
+	"github.com/dwellir/grpc-code-examples/go/internal/model"
+	"github.com/dwellir/grpc-code-examples/go/internal/output"
+	"github.com/dwellir/grpc-code-examples/go/internal/parseerr"
+	"github.com/dwellir/grpc-code-examples/go/internal/schema"
+	"github.com/dwellir/grpc-code-examples/go/internal/shutdown"
+	"github.com/dwellir/grpc-code-examples/go/internal/sink"
+	"github.com/dwellir/grpc-code-examples/go/internal/stats"
+	"github.com/dwellir/grpc-code-examples/go/internal/util"
 )
 
-// Block represents the structure of a block
-type Block struct {
-	ABCIBlock struct {
-		Proposer             string          `json:"proposer"`
-		SignedActionBundles  [][]interface{} `json:"signed_action_bundles"`
-	} `json:"abci_block"`
-	Resps struct {
-		Full [][]interface{} `json:"Full"`
-	} `json:"resps"`
-}
-
-// ActionTypeCounts tracks different action types
-type ActionTypeCounts map[string]int
+// dedupSize is how many recent blocks are remembered to skip re-deliveries
+const dedupSize = 64
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not found")
+	cfg := config.Register(flag.CommandLine)
+	outFile := flag.String("out-file", "", "write the output to this file instead of stdout")
+	outBuffer := config.ByteSize(output.DefaultBufferSize)
+	flag.Var(&outBuffer, "out-buffer", "buffer up to this much output before writing it to stdout or -out-file, e.g. 64KB or 1MB")
+	outFlush := flag.Duration("out-flush-interval", output.DefaultFlushInterval, "write buffered output at least this often, 0 disables buffering and writes every line as it's printed")
+	outputFormat := flag.String("output", "pretty", "output format: pretty (human-readable summary), json (one summary object per block) or jsonl (one compact JSON block per line)")
+	keepaliveTime := flag.Duration("keepalive-time", 30*time.Second, "interval between keepalive pings on an idle connection, 0 disables keepalive")
+	keepaliveTimeout := flag.Duration("keepalive-timeout", 10*time.Second, "how long to wait for a keepalive ping to be acknowledged")
+	limit := flag.Int("limit", 0, "stop after receiving this many blocks and print the summary, 0 streams until stopped")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 8*time.Second, "after Ctrl+C or SIGTERM, force exit if the summary isn't printed within this long, 0 waits indefinitely")
+	idleTimeout := flag.Duration("idle-timeout", 60*time.Second, "restart the stream when no message arrives for this long, 0 disables")
+	breakerThreshold := flag.Int("breaker-threshold", 5, "open the circuit breaker after this many consecutive reconnect failures within -breaker-window, 0 disables it")
+	breakerWindow := flag.Duration("breaker-window", 5*time.Minute, "time window in which -breaker-threshold failures open the circuit breaker")
+	breakerCooldown := flag.Duration("breaker-cooldown", 5*time.Minute, "how long an open circuit breaker waits before trying again; with a single endpoint the example exits instead")
+	maxMsgSize := config.ByteSize(client.DefaultMaxMessageSize)
+	flag.Var(&maxMsgSize, "max-msg-size", "maximum message size to receive, e.g. 256MB or 1GB")
+	watchConn := flag.Bool("watch-conn", false, "log every connection state transition (IDLE, CONNECTING, READY, TRANSIENT_FAILURE, ...)")
+	metricsAddr := flag.String("metrics-addr", "", "serve Prometheus metrics on this address (e.g. :9090), disabled when empty")
+	statsdAddr := flag.String("statsd-addr", "", "send metrics to the StatsD server at this UDP address (e.g. localhost:8125), disabled when empty")
+	inspectAddr := flag.String("inspect-addr", "", "serve the most recent blocks as JSON at /recent on this address (e.g. :9091), disabled when empty")
+	inspectSize := flag.Int("inspect-size", 10, "number of recent blocks kept for -inspect-addr")
+	workers := flag.Int("workers", 1, "number of goroutines decoding blocks in parallel; output stays in receive order")
+	rawDir := flag.String("raw-dir", "", "write every received block, as received, to its own numbered file in this directory")
+	rawGzip := flag.String("raw-gzip", "", "write every received block, as received, into this single gzip-compressed file of length-prefixed frames (replay with replay_blocks.go -raw-gzip)")
+	schemaPath := flag.String("schema", "", "validate every block against this JSON schema file and count the violations, disabled when empty")
+	sinkSpec := flag.String("sink", "", "also write every block to a sink: "+strings.Join(sink.Names(), ", ")+", optionally followed by :path (stdout when omitted), disabled when empty")
+	schemaDumpDir := flag.String("schema-dump-dir", "", "write block payloads violating -schema to this directory, disabled when empty")
+	dumpRaw := flag.Bool("dump-raw", false, "also print each block's full JSON, indented (pretty output only)")
+	dumpMaxBytes := config.ByteSize(64 << 10)
+	flag.Var(&dumpMaxBytes, "dump-max-bytes", "truncate blocks printed by -dump-raw after this many bytes, e.g. 64KB or 1MB")
+	skewThreshold := flag.Duration("clock-skew-threshold", 2*time.Second, "warn when the local clock seems off against block times by more than this, 0 disables the check")
+	skewWindow := flag.Duration("clock-skew-window", time.Minute, "how long the skew must persist before -clock-skew-threshold warns")
+	printEvery := flag.Int("print-every", 1, "print the full summary of every Nth block only; all blocks are still counted (pretty output only)")
+	progress := flag.Bool("progress", true, "with -print-every, show a one-line progress indicator for the blocks in between")
+	quiet := flag.Bool("quiet", false, "don't print a summary per block, only the final summary (pretty output only)")
+	statsInterval := flag.Duration("stats-interval", 5*time.Second, "how often to print throughput (blocks/s, MB/s), 0 disables")
+	parseErrors := parseerr.Handler{Policy: parseerr.Skip, Kind: "block"}
+	flag.Var(&parseErrors.Policy, "on-parse-error", "what to do with a block that can't be parsed: skip, dump (write its bytes to -dump-dir) or fatal (exit)")
+	flag.StringVar(&parseErrors.Dir, "dump-dir", "parse-errors", "directory for blocks dumped by -on-parse-error dump")
+	noColor := flag.Bool("no-color", false, "don't color the output; colors are also off when NO_COLOR is set or the output isn't a terminal")
+	showVersion := flag.Bool("version", false, "print the version, commit and build date and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(buildinfo.String())
+		return
 	}
 
-	endpoint := os.Getenv("HYPERLIQUID_ENDPOINT")
-	apiKey := os.Getenv("API_KEY")
-
-	if endpoint == "" {
-		log.Fatal("Error: HYPERLIQUID_ENDPOINT environment variable is required.\n" +
-			"Please create a .env file from .env.example and set your endpoint.")
+	if err := cfg.LoadFile(); err != nil {
+		logging.Fatal("invalid -config file", "err", err)
 	}
-
-	// API key is optional - some endpoints are public and don't require authentication
-	if apiKey == "" {
-		fmt.Println("ℹ️  No API key provided - connecting to public endpoint")
+	if err := cfg.SetupLogging(); err != nil {
+		logging.Fatal("invalid logging configuration", "err", err)
 	}
-
-	fmt.Println("🚀 Hyperliquid Go gRPC Client - Stream Blocks")
-	fmt.Println("===============================================")
-	fmt.Printf("📡 Endpoint: %s\n\n", endpoint)
-
-	// Create TLS credentials
-	creds := credentials.NewTLS(nil)
-
-	// Set up connection options
-	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(creds),
-		grpc.WithDefaultCallOptions(
-			grpc.MaxCallRecvMsgSize(150 * 1024 * 1024), // 150MB
-		),
+	if err := cfg.Validate(); err != nil {
+		logging.Fatal("invalid configuration", "err", err)
 	}
-
-	fmt.Println("🔌 Connecting to gRPC server...")
-	conn, err := grpc.Dial(endpoint, opts...)
-	if err != nil {
-		log.Fatalf("Failed to connect: %v", err)
+	if warning := cfg.SecurityWarning(); warning != "" {
+		slog.Warn(warning)
 	}
-	defer conn.Close()
-
-	client := pb.NewHyperLiquidL1GatewayClient(conn)
-	fmt.Println("✅ Connected successfully!\n")
-
-	// Create context with metadata (API key) only if provided
-	ctx := context.Background()
-	if apiKey != "" {
-		ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", apiKey)
+	if *outFlush < 0 {
+		logging.Fatal("-out-flush-interval must not be negative", "out-flush-interval", *outFlush)
+	}
+	if *limit < 0 {
+		logging.Fatal("-limit must not be negative", "limit", *limit)
+	}
+	if *outputFormat != "pretty" && *outputFormat != "json" && *outputFormat != "jsonl" {
+		logging.Fatal("unknown -output (expected pretty, json or jsonl)", "output", *outputFormat)
+	}
+	if *inspectAddr != "" && *inspectSize < 1 {
+		logging.Fatal("-inspect-size must be at least 1", "inspect-size", *inspectSize)
+	}
+	if *skewThreshold < 0 || *skewWindow < 0 {
+		logging.Fatal("-clock-skew-threshold and -clock-skew-window must not be negative")
+	}
+	if *printEvery < 1 {
+		logging.Fatal("-print-every must be at least 1", "print-every", *printEvery)
+	}
+	if *workers < 1 {
+		logging.Fatal("-workers must be at least 1", "workers", *workers)
 	}
 
-	// Create cancellable context for graceful shutdown
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	// Set up signal handling for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
-	go func() {
-		<-sigChan
-		fmt.Println("\n🛑 Stopping stream...")
-		cancel()
-	}()
-
-	// Create request - 0 means latest/current blocks
-	request := &pb.Timestamp{Timestamp: 0}
-
-	fmt.Println("📥 Starting block stream...")
-	fmt.Println("Press Ctrl+C to stop streaming\n")
+	// -dry-run stops here, before any file is written or stream opened
+	if cfg.DryRun {
+		color.Setup(os.Stdout, *noColor)
+		os.Exit(cfg.RunDryRun(os.Stdout))
+	}
 
-	// Start streaming blocks
-	stream, err := client.StreamBlocks(ctx, request)
+	dest, err := output.Open(*outFile)
 	if err != nil {
-		log.Fatalf("Failed to start stream: %v", err)
+		logging.Fatal("failed to open output file", "path", *outFile, "err", err)
+	}
+	// Output is written in large chunks rather than line by line. os.Exit
+	// skips deferred calls, so exits also flush it explicitly.
+	out := output.NewBuffered(dest, int(outBuffer), *outFlush)
+	defer out.Close()
+	logging.OnExit(func() { out.Flush() })
+	color.Setup(out, *noColor)
+
+	var rawCapture *capture.Writer
+	if *rawDir != "" {
+		if rawCapture, err = capture.New(*rawDir, "block"); err != nil {
+			logging.Fatal("failed to create -raw-dir", "path", *rawDir, "err", err)
+		}
 	}
 
-	blockCount := 0
+	// Closed explicitly once the stream ends, since os.Exit skips deferred
+	// calls and an unclosed capture lacks the gzip trailer
+	var rawGzipCapture *capture.GzipWriter
+	if *rawGzip != "" {
+		if rawGzipCapture, err = capture.NewGzip(*rawGzip); err != nil {
+			logging.Fatal("failed to create -raw-gzip", "path", *rawGzip, "err", err)
+		}
+	}
 
-	for {
-		response, err := stream.Recv()
-		if err == io.EOF {
-			break
+	// A nil validator skips validation entirely
+	var validator *schema.Validator
+	if *schemaPath != "" {
+		if validator, err = schema.Load(*schemaPath, "block"); err != nil {
+			logging.Fatal("failed to load -schema", "path", *schemaPath, "err", err)
 		}
-		if err != nil {
-			if ctx.Err() == context.Canceled {
-				break
+		if *schemaDumpDir != "" {
+			if err := validator.DumpTo(*schemaDumpDir); err != nil {
+				logging.Fatal("failed to create -schema-dump-dir", "path", *schemaDumpDir, "err", err)
 			}
-			log.Printf("❌ Stream error: %v", err)
-			break
 		}
+	} else if *schemaDumpDir != "" {
+		logging.Fatal("-schema-dump-dir needs -schema")
+	}
 
-		blockCount++
-		fmt.Printf("\n===== BLOCK #%d =====\n", blockCount)
-		fmt.Printf("📦 Response size: %d bytes\n", len(response.Data))
+	// A nil sink means -sink is disabled. It is closed explicitly once the
+	// stream ends, since os.Exit skips deferred calls.
+	var blockSink sink.Sink
+	if *sinkSpec != "" {
+		if blockSink, err = sink.Open(*sinkSpec); err != nil {
+			logging.Fatal("failed to open -sink", "sink", *sinkSpec, "err", err)
+		}
+	}
 
-		// Process block
-		processBlock(response.Data, blockCount)
+	// In json and jsonl mode the output carries only data, so banners and summaries are dropped
+	var info io.Writer = out
+	if *outputFormat != "pretty" {
+		info = io.Discard
+	}
 
-		fmt.Println("\n" + "─────────────────────────────────────────────────")
+	// API key is optional - some endpoints are public and don't require authentication
+	if cfg.APIKey == "" {
+		slog.Info("no API key provided, connecting to a public endpoint")
 	}
 
-	fmt.Printf("\n📊 Total blocks received: %d\n", blockCount)
-}
+	fmt.Fprintln(info, "🚀 Hyperliquid Go gRPC Client - Stream Blocks")
+	fmt.Fprintln(info, "===============================================")
+	fmt.Fprintf(info, "📡 Endpoints: %s\n", strings.Join(cfg.Endpoints(), ", "))
+	fmt.Fprintf(info, "🔒 Transport: %s\n", cfg.TransportDescription())
+	fmt.Fprintf(info, "⏱️  Start: %s\n", cfg.StartDescription())
+	fmt.Fprintf(info, "🎬 Mode: %s\n", cfg.StreamDescription())
+	fmt.Fprintf(info, "⚙️  Config precedence: %s\n\n", config.Precedence)
+
+	slog.Info("connecting to gRPC server", "endpoints", cfg.Endpoints())
+	// Keepalive pings detect connections silently dropped by intermediaries
+	connectOpts := append(cfg.ConnectOptions(),
+		client.WithKeepalive(*keepaliveTime, *keepaliveTimeout),
+		client.WithMaxMessageSize(int(maxMsgSize)),
+	)
+	if *watchConn {
+		// Every connection, including ones made on reconnect, is watched until closed
+		watchCtx, stopWatching := context.WithCancel(context.Background())
+		defer stopWatching()
+		connectOpts = append(connectOpts, client.WithStateLogging(watchCtx))
+	}
 
-func processBlock(data []byte, blockNum int) {
-	var block Block
+	// Endpoints are tried in priority order, and each reconnect fails over to the next one
+	failover := client.NewFailover(cfg.Endpoints(), func(endpoint string) (*grpc.ClientConn, error) {
+		return client.Connect(endpoint, cfg.APIKey, connectOpts...)
+	})
+	ctx := context.Background()
 
-	if err := json.Unmarshal(data, &block); err != nil {
-		log.Printf("❌ Failed to parse JSON: %v", err)
-		log.Printf("Raw data (first 200 bytes): %s", data[:min(200, len(data))])
-		return
+	// The client connects lazily, so wait until an endpoint is actually ready
+	conn, err := failover.Connect(ctx, cfg.ConnectTimeout)
+	if err != nil {
+		logging.Exit(client.ExitConnectionFailure, "failed to connect", "err", err)
 	}
+	defer conn.Close()
 
-	fmt.Printf("🧱 BLOCK #%d DETAILS\n", blockNum)
-	fmt.Println("===================")
-
-	// Display proposer
-	if block.ABCIBlock.Proposer != "" {
-		fmt.Printf("👤 Proposer: %s\n", block.ABCIBlock.Proposer)
+	slog.Info("connected", "endpoint", failover.Active())
+
+	// Without another endpoint to fail over to, an open breaker ends the run
+	breaker := client.WithCircuitBreaker(client.Breaker{
+		Threshold:    *breakerThreshold,
+		Window:       *breakerWindow,
+		Cooldown:     *breakerCooldown,
+		ExitWhenOpen: len(cfg.Endpoints()) == 1,
+	})
+
+	// First Ctrl+C (or SIGTERM) drains the stream, a second one or an overrun
+	// of -shutdown-timeout forces an immediate exit
+	ctx, stop := shutdown.Listen(ctx, *shutdownTimeout)
+	defer stop()
+
+	// Reaching -limit stops the stream the same way Ctrl+C does
+	ctx, stopAtLimit := context.WithCancel(ctx)
+	defer stopAtLimit()
+
+	// Create request - 0 means latest, otherwise replay from the start time (see -from)
+	request := &pb.Timestamp{Timestamp: cfg.RequestTimestamp()}
+
+	fmt.Fprintln(info, "📥 Starting block stream...")
+	fmt.Fprint(info, "Press Ctrl+C to stop streaming (twice to force quit)\n\n")
+
+	// Metrics are only collected when an address to serve or send them to
+	// is given
+	var metricsOpts []metrics.Option
+	if *metricsAddr != "" {
+		metricsOpts = append(metricsOpts, metrics.WithPrometheus())
+		metrics.Serve(*metricsAddr)
+		fmt.Fprintf(info, "📈 Metrics: http://%s/metrics\n\n", *metricsAddr)
+	}
+	if *statsdAddr != "" {
+		statsd, err := metrics.DialStatsD(*statsdAddr)
+		if err != nil {
+			logging.Fatal("invalid -statsd-addr", "addr", *statsdAddr, "err", err)
+		}
+		defer statsd.Close()
+		metricsOpts = append(metricsOpts, metrics.WithStatsD(statsd))
+		fmt.Fprintf(info, "📈 StatsD: %s (udp)\n\n", *statsdAddr)
+	}
+	streamMetrics := metrics.NewStream("blocks", metricsOpts...)
+
+	// Recent blocks are only kept when an address to serve them on is given
+	var recent *inspect.Ring[recentBlock]
+	if *inspectAddr != "" {
+		recent = inspect.NewRing[recentBlock](*inspectSize)
+		inspect.Serve(*inspectAddr, recent)
+		fmt.Fprintf(info, "🔎 Recent blocks: http://%s/recent\n\n", *inspectAddr)
 	}
 
-	// Count action types
-	actionTypeCounts := make(ActionTypeCounts)
+	// Throughput is printed from its own goroutine, which stops once streaming ends
+	var rates rateTracker
+	ratesCtx, stopRates := context.WithCancel(ctx)
+	var ratesDone sync.WaitGroup
+	if *statsInterval > 0 {
+		ratesDone.Add(1)
+		go func() {
+			defer ratesDone.Done()
+			rates.Run(ratesCtx, info, *statsInterval)
+		}()
+	}
 
-	for _, actionBundle := range block.ABCIBlock.SignedActionBundles {
-		if len(actionBundle) < 2 {
+	blockCount := 0
+	emptyMessages := 0
+	var heights stats.HeightTracker
+	var blockTimes stats.BlockTimeTracker
+	// Remembers recent blocks so ones re-delivered after a reconnect are skipped
+	dedup := stats.NewDedup[stats.BlockKey](dedupSize)
+	var messageSizes stats.SizeStats
+	var reconciliation stats.Reconciliation
+	var proposers stats.Proposers
+	var actionTypes stats.ActionTypes
+
+	// Arrival times characterise the feed's cadence in the final summary, and
+	// compared with block times show how far behind real time the feed is
+	var cadence stats.Cadence
+	var feedLag stats.FeedLag
+	// Historical replays lag legitimately until they catch up, so only a
+	// clock that is behind is detected for them
+	clockSkew := stats.ClockSkew{
+		Threshold:   *skewThreshold,
+		Window:      *skewWindow,
+		IgnoreAhead: cfg.StartMode() == config.FromTimestamp,
+	}
+	streamStart := time.Now()
+
+	// Blocks drained after Ctrl+C still go to the sink
+	sinkCtx := context.WithoutCancel(ctx)
+
+	// Blocks arrive decoded on a channel; the error channel reports why the stream ended.
+	// With several workers blocks are decoded in parallel but still arrive in order.
+	// Re-delivered blocks are dropped before they arrive, so nothing below
+	// (counters, captures, validation, the sink) sees them twice.
+	blocks, streamErrs := client.StreamBlocksWithReconnect(ctx, conn, failover.Redial, request,
+		client.WithIdleTimeout(*idleTimeout), breaker, client.WithDecodeWorkers(*workers), client.WithDedup(dedup))
+	for block := range blocks {
+		// Blocks already in flight when -limit was reached are drained unprocessed
+		if *limit > 0 && blockCount >= *limit {
 			continue
 		}
-
-		bundleData, ok := actionBundle[1].(map[string]interface{})
-		if !ok {
+		// Some endpoints send empty heartbeat-like frames, which aren't blocks
+		if len(block.Data) == 0 {
+			emptyMessages++
+			slog.Debug("skipping empty message")
 			continue
 		}
+		blockCount++
+		if blockCount == *limit {
+			stopAtLimit()
+		}
+		receivedAt := time.Now()
+		cadence.Observe(receivedAt)
+		if block.Decoded != nil {
+			if produced, ok := block.Decoded.ABCIBlock.Timestamp(); ok {
+				feedLag.Observe(produced, receivedAt)
+				streamMetrics.Lag(receivedAt.Sub(produced))
+				rates.SetLag(feedLag.Average())
+				if warning := clockSkew.Observe(produced, receivedAt); warning != "" {
+					slog.Warn(warning, "height", block.Decoded.ABCIBlock.Height)
+				}
+			}
+		}
+		streamMetrics.Received(len(block.Data))
+		rates.Add(len(block.Data))
+		messageSizes.Add(len(block.Data))
 
-		signedActions, ok := bundleData["signed_actions"].([]interface{})
-		if !ok {
-			continue
+		var height int64
+		if block.Decoded != nil {
+			height = block.Decoded.ABCIBlock.Height
+		}
+		if rawCapture != nil {
+			if _, err := rawCapture.Write(blockCount, height, block.Data); err != nil {
+				slog.Error("failed to capture block", "block", blockCount, "err", err)
+			}
+		}
+		if rawGzipCapture != nil {
+			if err := rawGzipCapture.Write(block.Data); err != nil {
+				slog.Error("failed to capture block", "path", *rawGzip, "block", blockCount, "err", err)
+			}
+		}
+		validator.Check(blockCount, height, block.Data)
+
+		if blockSink != nil {
+			meta := sink.Meta{Kind: "block", Num: blockCount, Height: height, ReceivedAt: receivedAt}
+			if block.Decoded != nil {
+				if produced, ok := block.Decoded.ABCIBlock.Timestamp(); ok {
+					meta.Time = produced.UnixMilli()
+				}
+			}
+			if err := blockSink.Write(sinkCtx, block.Data, meta); err != nil {
+				slog.Error("failed to write block to sink", "block", blockCount, "err", err)
+			}
 		}
 
-		for _, signedAction := range signedActions {
-			signedActionMap, ok := signedAction.(map[string]interface{})
-			if !ok {
-				continue
+		// A panic on an unexpected payload is logged and counted instead of
+		// ending the stream
+		parseErrors.Guard(blockCount, block.Data, func() {
+			if recent != nil {
+				recent.Add(newRecentBlock(blockCount, block))
 			}
 
-			action, ok := signedActionMap["action"].(map[string]interface{})
-			if !ok {
-				continue
+			if *outputFormat == "jsonl" {
+				if err := writeJSONLine(out, block.Data); err != nil {
+					parseErrors.Handle(blockCount, block.Data, err)
+					streamMetrics.ParseError()
+				}
+				return
 			}
 
-			actionType, ok := action["type"].(string)
-			if !ok {
-				continue
+			// With -print-every, the blocks in between are processed the same
+			// way but their summary is discarded
+			summaryOut := info
+			if *quiet || blockCount%*printEvery != 0 {
+				summaryOut = io.Discard
 			}
 
-			// For order type, count the number of orders
-			if actionType == "order" {
-				if orders, ok := action["orders"].([]interface{}); ok {
-					actionTypeCounts[actionType] += len(orders)
-				} else {
-					actionTypeCounts[actionType]++
+			var summary *model.BlockSummary
+			var err error
+			if *outputFormat == "json" {
+				summary, err = writeSummaryLine(out, block, blockCount)
+			} else {
+				fmt.Fprintf(summaryOut, "\n===== BLOCK #%d =====\n", blockCount)
+				fmt.Fprintf(summaryOut, "📦 Response size: %d bytes\n", len(block.Data))
+				summary, err = processBlock(summaryOut, block, blockCount)
+				if *dumpRaw {
+					dumpRawBlock(summaryOut, block.Data, int(dumpMaxBytes))
+				}
+				if summaryOut == io.Discard && *progress && !*quiet {
+					// Overwritten in place until the next full summary
+					next := blockCount + *printEvery - blockCount%*printEvery
+					fmt.Fprintf(info, "\r⏩ Block #%d (height %d), next summary at #%d", blockCount, height, next)
 				}
+			}
+
+			if err != nil {
+				parseErrors.Handle(blockCount, block.Data, err)
+				streamMetrics.ParseError()
 			} else {
-				actionTypeCounts[actionType]++
+				// Keep running totals of actions against statuses across the whole run
+				if !reconciliation.Observe(blockCount, summary) {
+					fmt.Fprintln(summaryOut, color.Yellow(fmt.Sprintf("🚩 Block #%d diverges: %d actions vs %d statuses (%s)", blockCount, summary.TotalActions, summary.TotalStatuses(), display.TypeMismatches(summary.TypeMismatches()))))
+				}
+				actions, statuses := reconciliation.Totals()
+				fmt.Fprintf(summaryOut, "🧮 Cumulative: %d actions, %d statuses, %.1f%% of blocks matched\n", actions, statuses, reconciliation.MatchRate())
+				proposers.Observe(summary.Proposer)
+				actionTypes.Observe(summary.ActionCounts)
+
+				// Check that heights follow on from each other
+				if summary.Height != 0 {
+					if warning := heights.Observe(summary.Height); warning != "" {
+						slog.Warn(warning, "height", summary.Height)
+					}
+				}
+				// Check that block times never go backwards
+				if block.Decoded != nil {
+					if produced, ok := block.Decoded.ABCIBlock.Timestamp(); ok {
+						if warning := blockTimes.Observe(produced); warning != "" {
+							slog.Warn(warning, "height", summary.Height)
+						}
+					}
+				}
 			}
+
+			fmt.Fprintln(summaryOut, "\n"+"─────────────────────────────────────────────────")
+		})
+	}
+	err = <-streamErrs
+	stopRates()
+	ratesDone.Wait()
+	exitCode := client.ExitOK
+	if err != nil {
+		message, auth := client.ClassifyError(err)
+		if auth {
+			logging.Exit(client.ExitAuthFailure, message, "err", err)
+		}
+		slog.Error("stream ended with an error", "err", err)
+		exitCode = client.ExitStreamError
+	}
+	if rawGzipCapture != nil {
+		if err := rawGzipCapture.Close(); err != nil {
+			slog.Error("failed to close -raw-gzip", "path", *rawGzip, "err", err)
+		}
+	}
+	if blockSink != nil {
+		if err := blockSink.Close(); err != nil {
+			slog.Error("failed to close -sink", "sink", *sinkSpec, "err", err)
 		}
 	}
 
-	totalActions := 0
-	for _, count := range actionTypeCounts {
-		totalActions += count
+	fmt.Fprintf(info, "\n📊 Total blocks received: %d\n", blockCount)
+	fmt.Fprintf(info, "⏲️  Run duration: %v\n", time.Since(streamStart).Round(time.Millisecond))
+	if blockCount > 1 {
+		fmt.Fprintf(info, "📶 Mean interarrival: %v, longest gap: %v\n",
+			cadence.MeanInterarrival().Round(time.Millisecond), cadence.LongestGap().Round(time.Millisecond))
+	}
+	printFeedLag(info, &feedLag)
+	fmt.Fprintf(info, "🕳️  Total missed blocks: %s\n", color.If(heights.Missed() > 0, color.Yellow, heights.Missed()))
+	fmt.Fprintf(info, "⏪ Block time regressions: %s\n", color.If(blockTimes.Regressions() > 0, color.Yellow, blockTimes.Regressions()))
+	fmt.Fprintf(info, "🔁 Duplicate blocks skipped: %d\n", dedup.Duplicates())
+	fmt.Fprintf(info, "📭 Empty messages skipped: %d\n", emptyMessages)
+	fmt.Fprintf(info, "💥 Panics recovered: %d\n", parseErrors.Panics())
+	if validator != nil {
+		fmt.Fprintf(info, "🧩 Schema violations: %d\n", validator.Violations())
+	}
+	if rawGzipCapture != nil {
+		fmt.Fprintf(info, "🗜️  Messages captured to %s: %d\n", *rawGzip, rawGzipCapture.Frames())
 	}
+	if sizes := messageSizes.Summary(); sizes.Count > 0 {
+		fmt.Fprintf(info, "📐 Message sizes (bytes): min %d, max %d, mean %.0f, median %d, p95 %d\n",
+			sizes.Min, sizes.Max, sizes.Mean, sizes.Median, sizes.P95)
+	}
+	printReconciliation(info, &reconciliation)
+	printProposers(info, &proposers)
+	printActionTypes(info, &actionTypes)
+
+	// The summary is printed and all output flushed, so skipping deferred
+	// cleanup is safe
+	if exitCode != client.ExitOK {
+		out.Flush()
+		os.Exit(exitCode)
+	}
+}
 
-	fmt.Println("📋 Action types:")
-	for actionType, count := range actionTypeCounts {
-		fmt.Printf("  • %s: %d\n", actionType, count)
+// printFeedLag prints the rolling average and the largest delay between
+// block time and receive time
+func printFeedLag(w io.Writer, l *stats.FeedLag) {
+	if l.Count() == 0 {
+		return
 	}
-	fmt.Printf("  Total actions: %d\n", totalActions)
+	fmt.Fprintf(w, "🐢 Feed lag: %v (average of the last %d blocks), max %v\n",
+		l.Average().Round(time.Millisecond), l.Window(), l.Max().Round(time.Millisecond))
+}
 
-	// Count order statuses (success vs error)
-	successCount := 0
-	errorCount := 0
+// printProposers prints how many blocks each proposer produced during the run
+func printProposers(w io.Writer, p *stats.Proposers) {
+	if p.Total() == 0 {
+		return
+	}
+	fmt.Fprintln(w, "👥 Blocks per proposer:")
+	for _, c := range p.Distribution() {
+		fmt.Fprintf(w, "  • %s: %d (%.1f%%)\n", c.Proposer, c.Blocks, float64(c.Blocks)/float64(p.Total())*100)
+	}
+}
 
-	fullData := block.Resps.Full
-	if fullData != nil {
-		for _, item := range fullData {
-			if len(item) < 2 {
-				continue
-			}
+// actionBarWidth is the length of the bar of the most frequent action type
+const actionBarWidth = 20
 
-			entries, ok := item[1].([]interface{})
-			if !ok {
-				continue
-			}
+// printActionTypes prints a histogram of the action types of every block of
+// the run, most frequent first
+func printActionTypes(w io.Writer, a *stats.ActionTypes) {
+	if a.Total() == 0 {
+		return
+	}
+	counts := a.Distribution()
+	// Bars line up after the longest type
+	width := 0
+	for _, c := range counts {
+		width = max(width, len(c.Type)+1)
+	}
 
-			for _, entry := range entries {
-				entryMap, ok := entry.(map[string]interface{})
-				if !ok {
-					continue
-				}
+	fmt.Fprintf(w, "📋 Action types across the run (%d actions):\n", a.Total())
+	for _, c := range counts {
+		bar := strings.Repeat("█", max(1, c.Actions*actionBarWidth/counts[0].Actions))
+		fmt.Fprintf(w, "  • %-*s %s %d (%.1f%%)\n", width, c.Type+":", bar, c.Actions, float64(c.Actions)/float64(a.Total())*100)
+	}
+}
 
-				res, ok := entryMap["res"].(map[string]interface{})
-				if !ok {
-					continue
-				}
+// recentBlock is a block as served at /recent by -inspect-addr
+type recentBlock struct {
+	Block      int                 `json:"block"`
+	ReceivedAt time.Time           `json:"received_at"`
+	Size       int                 `json:"size"`
+	Summary    *model.BlockSummary `json:"summary,omitempty"`
+	Error      string              `json:"error,omitempty"`
+	Data       json.RawMessage     `json:"data,omitempty"`
+}
 
-				response, ok := res["response"].(map[string]interface{})
-				if !ok {
-					continue
-				}
+// newRecentBlock captures a received block for the inspection endpoint. The
+// raw data is only kept for blocks that decoded, since invalid JSON can't be
+// embedded in the response.
+func newRecentBlock(blockNum int, block *client.Block) recentBlock {
+	entry := recentBlock{Block: blockNum, ReceivedAt: time.Now(), Size: len(block.Data)}
+	if block.DecodeErr != nil {
+		entry.Error = block.DecodeErr.Error()
+		return entry
+	}
+	summary := block.Decoded.Summary()
+	entry.Summary = &summary
+	entry.Data = block.Data
+	return entry
+}
 
-				if responseType, ok := response["type"].(string); ok && responseType == "order" {
-					if data, ok := response["data"].(map[string]interface{}); ok {
-						if statuses, ok := data["statuses"].([]interface{}); ok {
-							for _, status := range statuses {
-								statusMap, ok := status.(map[string]interface{})
-								if !ok {
-									continue
-								}
-
-								if _, hasError := statusMap["error"]; hasError {
-									errorCount++
-								} else {
-									successCount++
-								}
-							}
-						}
-					}
-				}
+// printReconciliation prints the run's action/status totals and the blocks
+// whose counts diverged
+func printReconciliation(w io.Writer, r *stats.Reconciliation) {
+	actions, statuses := r.Totals()
+	fmt.Fprintf(w, "🧮 Reconciliation: %d actions, %d statuses, %.1f%% of blocks matched\n", actions, statuses, r.MatchRate())
+
+	mismatches, omitted := r.Mismatches()
+	if len(mismatches) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "🚩 Mismatching blocks:")
+	for _, m := range mismatches {
+		fmt.Fprintf(w, "  • #%d (height %d): %d actions vs %d statuses (%s)\n", m.Block, m.Height, m.Actions, m.Statuses, display.TypeMismatches(m.Types))
+	}
+	if omitted > 0 {
+		fmt.Fprintf(w, "  ... and %d more\n", omitted)
+	}
+}
+
+// rateTracker counts blocks and bytes from the receive loop and keeps the
+// latest feed lag. The fields are atomic so that Run can read them from
+// another goroutine.
+type rateTracker struct {
+	blocks atomic.Int64
+	bytes  atomic.Int64
+	// lag is the rolling feed lag in nanoseconds, valid once hasLag is set
+	lag    atomic.Int64
+	hasLag atomic.Bool
+}
+
+// Add records one received block of size bytes.
+func (r *rateTracker) Add(size int) {
+	r.blocks.Add(1)
+	r.bytes.Add(int64(size))
+}
+
+// SetLag records the current rolling feed lag.
+func (r *rateTracker) SetLag(lag time.Duration) {
+	r.lag.Store(int64(lag))
+	r.hasLag.Store(true)
+}
+
+// Run prints the rate over the last interval and the average since start
+// every interval until ctx is cancelled.
+func (r *rateTracker) Run(ctx context.Context, w io.Writer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	last := start
+	var lastBlocks, lastBytes int64
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			blocks, bytes := r.blocks.Load(), r.bytes.Load()
+			window := now.Sub(last).Seconds()
+			total := now.Sub(start).Seconds()
+
+			fmt.Fprintf(w, "\n⚡ Rate: %.2f blocks/s, %.2f MB/s (average %.2f blocks/s, %.2f MB/s)\n",
+				float64(blocks-lastBlocks)/window, float64(bytes-lastBytes)/window/(1024*1024),
+				float64(blocks)/total, float64(bytes)/total/(1024*1024))
+			if r.hasLag.Load() {
+				fmt.Fprintf(w, "🐢 Feed lag: %v\n", time.Duration(r.lag.Load()).Round(time.Millisecond))
 			}
+
+			last, lastBlocks, lastBytes = now, blocks, bytes
 		}
 	}
+}
+
+// writeJSONLine writes data as a single compact JSON line. The line is
+// written with one call so that with -out-flush-interval 0 each block reaches
+// the consumer in one piece as soon as it arrives.
+func writeJSONLine(w io.Writer, data []byte) error {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeSummaryLine writes the summary of a streamed block as one JSON object
+// per line and returns it. Decode errors are returned for the caller to handle.
+func writeSummaryLine(w io.Writer, block *client.Block, blockNum int) (*model.BlockSummary, error) {
+	if block.DecodeErr != nil {
+		return nil, block.DecodeErr
+	}
+	summary := block.Decoded.Summary()
+
+	line, err := json.Marshal(summary)
+	if err != nil {
+		return nil, err
+	}
+	line = append(line, '\n')
+	if _, err := w.Write(line); err != nil {
+		slog.Error("failed to write block summary", "block", blockNum, "err", err)
+	}
+	return &summary, nil
+}
 
-	totalStatuses := successCount + errorCount
-	fmt.Println("\n📊 Order Statuses:")
-	fmt.Printf("  ✅ Success: %d\n", successCount)
-	fmt.Printf("  ❌ Error: %d\n", errorCount)
-	fmt.Printf("  Total statuses: %d\n", totalStatuses)
+// dumpRawBlock writes data as indented JSON, cut off after maxBytes with a note
+// of how much was left out
+func dumpRawBlock(w io.Writer, data []byte, maxBytes int) {
+	indented, err := json.MarshalIndent(json.RawMessage(data), "", "  ")
+	if err != nil {
+		// Invalid JSON is already reported by the parse error handling
+		return
+	}
+
+	fmt.Fprintln(w, "🧾 Raw block:")
+	if len(indented) <= maxBytes {
+		fmt.Fprintf(w, "%s\n", indented)
+		return
+	}
 
-	match := totalActions == totalStatuses
-	fmt.Printf("\n🔍 Match check: Actions=%d, Statuses=%d, Match=%v\n", totalActions, totalStatuses, match)
+	shown := util.ClampBytes(indented, maxBytes)
+	fmt.Fprintf(w, "%s\n... (%d bytes omitted)\n", shown, len(indented)-len(shown))
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
+// processBlock writes the summary of a streamed block to w and returns it. Decode
+// errors are returned for the caller to handle.
+func processBlock(w io.Writer, block *client.Block, blockNum int) (*model.BlockSummary, error) {
+	if block.DecodeErr != nil {
+		return nil, block.DecodeErr
 	}
-	return b
+	summary := block.Decoded.Summary()
+	display.BlockSummary(w, &summary, blockNum)
+	return &summary, nil
 }
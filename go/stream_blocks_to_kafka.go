@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/grpc"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/buildinfo"
+	"github.com/dwellir/grpc-code-examples/go/internal/checkpoint"
+	"github.com/dwellir/grpc-code-examples/go/internal/client"
+	"github.com/dwellir/grpc-code-examples/go/internal/color"
+	"github.com/dwellir/grpc-code-examples/go/internal/config"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+	"github.com/dwellir/grpc-code-examples/go/internal/model"
+	"github.com/dwellir/grpc-code-examples/go/internal/shutdown"
+)
+
+func main() {
+	cfg := config.Register(flag.CommandLine)
+	brokers := flag.String("brokers", "localhost:9092", "comma-separated Kafka broker addresses")
+	topic := flag.String("topic", "hyperliquid-blocks", "Kafka topic to publish blocks to")
+	batchSize := flag.Int("batch-size", 100, "maximum number of blocks sent to Kafka in one request")
+	batchTimeout := flag.Duration("batch-timeout", 100*time.Millisecond, "flush an incomplete batch after this long")
+	checkpointPath := flag.String("checkpoint", "", "file recording the last block delivered to Kafka; on startup the stream resumes after it instead of at the latest block")
+	checkpointInterval := flag.Duration("checkpoint-interval", 5*time.Second, "write -checkpoint at most this often, 0 writes after every delivery")
+	keepaliveTime := flag.Duration("keepalive-time", 30*time.Second, "interval between keepalive pings on an idle connection, 0 disables keepalive")
+	keepaliveTimeout := flag.Duration("keepalive-timeout", 10*time.Second, "how long to wait for a keepalive ping to be acknowledged")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 8*time.Second, "after Ctrl+C or SIGTERM, force exit if the summary isn't printed within this long, 0 waits indefinitely")
+	idleTimeout := flag.Duration("idle-timeout", 60*time.Second, "restart the stream when no message arrives for this long, 0 disables")
+	breakerThreshold := flag.Int("breaker-threshold", 5, "open the circuit breaker after this many consecutive reconnect failures within -breaker-window, 0 disables it")
+	breakerWindow := flag.Duration("breaker-window", 5*time.Minute, "time window in which -breaker-threshold failures open the circuit breaker")
+	breakerCooldown := flag.Duration("breaker-cooldown", 5*time.Minute, "how long an open circuit breaker waits before trying again; with a single endpoint the example exits instead")
+	maxMsgSize := config.ByteSize(client.DefaultMaxMessageSize)
+	flag.Var(&maxMsgSize, "max-msg-size", "maximum message size to receive, e.g. 256MB or 1GB")
+	showVersion := flag.Bool("version", false, "print the version, commit and build date and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(buildinfo.String())
+		return
+	}
+
+	if err := cfg.LoadFile(); err != nil {
+		logging.Fatal("invalid -config file", "err", err)
+	}
+	if err := cfg.SetupLogging(); err != nil {
+		logging.Fatal("invalid logging configuration", "err", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	if warning := cfg.SecurityWarning(); warning != "" {
+		slog.Warn(warning)
+	}
+
+	brokerList := splitList(*brokers)
+	if len(brokerList) == 0 || *topic == "" {
+		logging.Fatal("-brokers and -topic are required")
+	}
+	if *checkpointInterval < 0 {
+		logging.Fatal("-checkpoint-interval must not be negative", "interval", *checkpointInterval)
+	}
+
+	// A saved checkpoint replaces the latest block as the start, while an
+	// explicit -from now or timestamp still wins
+	var resume checkpoint.Checkpoint
+	var checkpoints *checkpoint.Writer
+	if *checkpointPath != "" {
+		saved, ok, err := checkpoint.Load(*checkpointPath)
+		if err != nil {
+			logging.Fatal("failed to read checkpoint", "path", *checkpointPath, "err", err)
+		}
+		switch {
+		case ok && cfg.StartMode() == config.FromLatest:
+			resume = saved
+			cfg.ResumeFrom(saved.Time)
+			slog.Info("resuming from checkpoint", "path", *checkpointPath, "height", saved.Height, "time", saved.Time)
+		case ok:
+			slog.Info("ignoring checkpoint, -from sets the start", "path", *checkpointPath, "height", saved.Height)
+		}
+		checkpoints = checkpoint.NewWriter(*checkpointPath, *checkpointInterval)
+	}
+
+	// -dry-run stops here, before any file is written or stream opened
+	if cfg.DryRun {
+		color.Setup(os.Stdout, false)
+		os.Exit(cfg.RunDryRun(os.Stdout))
+	}
+
+	// The checkpoint only moves over blocks whose delivery succeeded, in
+	// publish order
+	var deliveries *checkpoint.Ordered
+	if checkpoints != nil {
+		deliveries = checkpoint.NewOrdered(checkpoints)
+	}
+
+	// Delivery results arrive asynchronously, so they are counted atomically
+	var delivered, failed atomic.Int64
+
+	// Blocks are keyed by height, and the hash balancer keeps equal keys on
+	// the same partition
+	producer := &kafka.Writer{
+		Addr:         kafka.TCP(brokerList...),
+		Topic:        *topic,
+		Balancer:     &kafka.Hash{},
+		BatchSize:    *batchSize,
+		BatchTimeout: *batchTimeout,
+		BatchBytes:   int64(maxMsgSize),
+		RequiredAcks: kafka.RequireAll,
+		Async:        true,
+		// Completion is the delivery confirmation: it runs once the brokers
+		// acknowledged a batch, or with the error that made it fail
+		Completion: func(messages []kafka.Message, err error) {
+			markDelivered(deliveries, messages, err)
+			if err != nil {
+				failed.Add(int64(len(messages)))
+				slog.Error("failed to deliver blocks", "count", len(messages), "err", err)
+				return
+			}
+			delivered.Add(int64(len(messages)))
+		},
+	}
+
+	// API key is optional - some endpoints are public and don't require authentication
+	if cfg.APIKey == "" {
+		slog.Info("no API key provided, connecting to a public endpoint")
+	}
+
+	fmt.Println("🚀 Hyperliquid Go gRPC Client - Stream Blocks to Kafka")
+	fmt.Println("======================================================")
+	fmt.Printf("📡 Endpoints: %s\n", strings.Join(cfg.Endpoints(), ", "))
+	fmt.Printf("🔒 Transport: %s\n", cfg.TransportDescription())
+	fmt.Printf("⏱️  Start: %s\n", cfg.StartDescription())
+	fmt.Printf("🎬 Mode: %s\n", cfg.StreamDescription())
+	fmt.Printf("📨 Kafka: %s -> topic %s (batches of %d, flushed after %v)\n", strings.Join(brokerList, ","), *topic, *batchSize, *batchTimeout)
+	if *checkpointPath != "" {
+		fmt.Printf("💾 Checkpoint: %s (%s)\n", *checkpointPath, checkpointDescription(resume))
+	}
+	fmt.Printf("⚙️  Config precedence: %s\n\n", config.Precedence)
+
+	slog.Info("connecting to gRPC server", "endpoints", cfg.Endpoints())
+	// Keepalive pings detect connections silently dropped by intermediaries
+	connectOpts := append(cfg.ConnectOptions(),
+		client.WithKeepalive(*keepaliveTime, *keepaliveTimeout),
+		client.WithMaxMessageSize(int(maxMsgSize)),
+	)
+
+	// Endpoints are tried in priority order, and each reconnect fails over to the next one
+	failover := client.NewFailover(cfg.Endpoints(), func(endpoint string) (*grpc.ClientConn, error) {
+		return client.Connect(endpoint, cfg.APIKey, connectOpts...)
+	})
+	ctx := context.Background()
+
+	// The client connects lazily, so wait until an endpoint is actually ready
+	conn, err := failover.Connect(ctx, cfg.ConnectTimeout)
+	if err != nil {
+		logging.Exit(client.ExitConnectionFailure, "failed to connect", "err", err)
+	}
+	defer conn.Close()
+
+	slog.Info("connected", "endpoint", failover.Active())
+
+	// Without another endpoint to fail over to, an open breaker ends the run
+	breaker := client.WithCircuitBreaker(client.Breaker{
+		Threshold:    *breakerThreshold,
+		Window:       *breakerWindow,
+		Cooldown:     *breakerCooldown,
+		ExitWhenOpen: len(cfg.Endpoints()) == 1,
+	})
+
+	// First Ctrl+C (or SIGTERM) drains the stream, a second one or an overrun
+	// of -shutdown-timeout forces an immediate exit
+	ctx, stop := shutdown.Listen(ctx, *shutdownTimeout)
+	defer stop()
+
+	// Create request - 0 means latest, otherwise replay from the start time (see -from)
+	request := &pb.Timestamp{Timestamp: cfg.RequestTimestamp()}
+
+	fmt.Println("📥 Publishing blocks to Kafka...")
+	fmt.Print("Press Ctrl+C to stop streaming (twice to force quit)\n\n")
+
+	// Enqueueing must keep working while the stream drains after Ctrl+C
+	produceCtx := context.WithoutCancel(ctx)
+
+	blockCount, skipped := 0, 0
+	err = client.StreamWithReconnect(ctx, conn, failover.Redial, pb.HyperLiquidL1GatewayClient.StreamBlocks, request, func(response *pb.Block) {
+		blockCount++
+
+		position := decodePosition(response.Data)
+		// Resuming from the checkpoint's time delivers its block again
+		if resume.Covers(position.height) {
+			skipped++
+			return
+		}
+
+		position.seq = deliveries.Start()
+		message := kafka.Message{Key: position.key(), Value: response.Data, WriterData: position}
+		if err := producer.WriteMessages(produceCtx, message); err != nil {
+			// Only enqueue errors end up here, delivery errors go to Completion
+			markDelivered(deliveries, []kafka.Message{message}, err)
+			failed.Add(1)
+			slog.Error("failed to publish block", "block", blockCount, "err", err)
+			return
+		}
+
+		if blockCount%100 == 0 {
+			fmt.Printf("📦 Blocks published: %d (delivered %d, failed %d)\n", blockCount, delivered.Load(), failed.Load())
+		}
+	}, client.WithIdleTimeout(*idleTimeout), breaker)
+	exitCode := client.ExitOK
+	if err != nil {
+		message, auth := client.ClassifyError(err)
+		if auth {
+			logging.Exit(client.ExitAuthFailure, message, "err", err)
+		}
+		slog.Error("stream ended with an error", "err", err)
+		exitCode = client.ExitStreamError
+	}
+
+	// Close flushes pending batches and waits for their delivery results
+	if err := producer.Close(); err != nil {
+		slog.Error("failed to close Kafka producer", "err", err)
+	}
+
+	fmt.Printf("\n📊 Total blocks received: %d\n", blockCount)
+	fmt.Printf("✅ Delivered: %d\n", delivered.Load())
+	fmt.Printf("❌ Failed: %d\n", failed.Load())
+	if checkpoints != nil {
+		if err := deliveries.Flush(); err != nil {
+			slog.Error("failed to write checkpoint", "err", err)
+		}
+		if skipped > 0 {
+			fmt.Printf("⏭️  Blocks skipped as already delivered: %d\n", skipped)
+		}
+		if latest := deliveries.Latest(); latest.Time != 0 {
+			fmt.Printf("📍 Checkpoint %s: height %d\n", *checkpointPath, latest.Height)
+		}
+		if deliveries.Held() {
+			fmt.Println("⚠️  The checkpoint stops before the first failed block, so the next run publishes it and the blocks after it again")
+		}
+	}
+
+	// The summary is printed and all output written, so skipping deferred
+	// cleanup is safe
+	if exitCode != client.ExitOK {
+		os.Exit(exitCode)
+	}
+}
+
+// blockPosition identifies a published block. seq numbers the blocks in
+// publish order; height and time (Unix milliseconds) are zero when they
+// can't be decoded.
+type blockPosition struct {
+	seq    int64
+	height int64
+	time   int64
+}
+
+// decodePosition decodes the height and time of a block payload
+func decodePosition(data []byte) blockPosition {
+	block, err := model.DecodeBlock(data)
+	if err != nil {
+		return blockPosition{}
+	}
+	position := blockPosition{height: block.ABCIBlock.Height}
+	if t, ok := block.ABCIBlock.Timestamp(); ok {
+		position.time = t.UnixMilli()
+	}
+	return position
+}
+
+// key returns the block height as the message key, or no key when the
+// height can't be decoded
+func (p blockPosition) key() []byte {
+	if p.height == 0 {
+		slog.Warn("publishing block without a key: height not found")
+		return nil
+	}
+	return []byte(strconv.FormatInt(p.height, 10))
+}
+
+// markDelivered records the delivery result of messages in deliveries
+func markDelivered(deliveries *checkpoint.Ordered, messages []kafka.Message, err error) {
+	for _, message := range messages {
+		position, ok := message.WriterData.(blockPosition)
+		if !ok {
+			continue
+		}
+		if saveErr := deliveries.Done(position.seq, position.height, position.time, err); saveErr != nil {
+			slog.Error("failed to write checkpoint", "err", saveErr)
+		}
+	}
+}
+
+// checkpointDescription describes where a checkpointed run starts
+func checkpointDescription(resume checkpoint.Checkpoint) string {
+	if resume.Time == 0 {
+		return "not resuming"
+	}
+	return fmt.Sprintf("resuming after height %d", resume.Height)
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"google.golang.org/grpc"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/buildinfo"
+	"github.com/dwellir/grpc-code-examples/go/internal/client"
+	"github.com/dwellir/grpc-code-examples/go/internal/color"
+	"github.com/dwellir/grpc-code-examples/go/internal/config"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+	"github.com/dwellir/grpc-code-examples/go/internal/model"
+	"github.com/dwellir/grpc-code-examples/go/internal/shutdown"
+	"github.com/dwellir/grpc-code-examples/go/internal/stats"
+)
+
+// dedupSize is how many recent blocks are remembered to skip re-deliveries
+const dedupSize = 64
+
+// Row groups of a partition file are flushed after this many blocks or this
+// long, whichever comes first
+const (
+	partitionRowGroupBlocks = 10_000
+	partitionFlushInterval  = time.Minute
+)
+
+// partitionDay is the layout of the day a partition holds, in UTC
+const partitionDay = "2006-01-02"
+
+// blockRow is one row of a partition file: the summary of a block
+type blockRow struct {
+	Height       int64            `parquet:"height"`
+	Time         int64            `parquet:"time"` // Unix milliseconds
+	Proposer     string           `parquet:"proposer,dict"`
+	ActionCounts map[string]int64 `parquet:"action_counts"`
+	TotalActions int64            `parquet:"total_actions"`
+}
+
+func main() {
+	cfg := config.Register(flag.CommandLine)
+	dir := flag.String("dir", "blocks", "directory to write the date=YYYY-MM-DD partitions to")
+	keepaliveTime := flag.Duration("keepalive-time", 30*time.Second, "interval between keepalive pings on an idle connection, 0 disables keepalive")
+	keepaliveTimeout := flag.Duration("keepalive-timeout", 10*time.Second, "how long to wait for a keepalive ping to be acknowledged")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 8*time.Second, "after Ctrl+C or SIGTERM, force exit if the summary isn't printed within this long, 0 waits indefinitely")
+	idleTimeout := flag.Duration("idle-timeout", 60*time.Second, "restart the stream when no message arrives for this long, 0 disables")
+	breakerThreshold := flag.Int("breaker-threshold", 5, "open the circuit breaker after this many consecutive reconnect failures within -breaker-window, 0 disables it")
+	breakerWindow := flag.Duration("breaker-window", 5*time.Minute, "time window in which -breaker-threshold failures open the circuit breaker")
+	breakerCooldown := flag.Duration("breaker-cooldown", 5*time.Minute, "how long an open circuit breaker waits before trying again; with a single endpoint the example exits instead")
+	maxMsgSize := config.ByteSize(client.DefaultMaxMessageSize)
+	flag.Var(&maxMsgSize, "max-msg-size", "maximum message size to receive, e.g. 256MB or 1GB")
+	showVersion := flag.Bool("version", false, "print the version, commit and build date and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(buildinfo.String())
+		return
+	}
+
+	if err := cfg.LoadFile(); err != nil {
+		logging.Fatal("invalid -config file", "err", err)
+	}
+	if err := cfg.SetupLogging(); err != nil {
+		logging.Fatal("invalid logging configuration", "err", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	if warning := cfg.SecurityWarning(); warning != "" {
+		slog.Warn(warning)
+	}
+	if *dir == "" {
+		logging.Fatal("-dir is required")
+	}
+
+	// -dry-run stops here, before any file is written or stream opened
+	if cfg.DryRun {
+		color.Setup(os.Stdout, false)
+		os.Exit(cfg.RunDryRun(os.Stdout))
+	}
+
+	// API key is optional - some endpoints are public and don't require authentication
+	if cfg.APIKey == "" {
+		slog.Info("no API key provided, connecting to a public endpoint")
+	}
+
+	fmt.Println("🚀 Hyperliquid Go gRPC Client - Stream Blocks to Parquet")
+	fmt.Println("=========================================================")
+	fmt.Printf("📡 Endpoints: %s\n", strings.Join(cfg.Endpoints(), ", "))
+	fmt.Printf("🔒 Transport: %s\n", cfg.TransportDescription())
+	fmt.Printf("⏱️  Start: %s\n", cfg.StartDescription())
+	fmt.Printf("🎬 Mode: %s\n", cfg.StreamDescription())
+	fmt.Printf("🗂️  Partitions: %s/date=YYYY-MM-DD (row groups every %d blocks or %v)\n", *dir, partitionRowGroupBlocks, partitionFlushInterval)
+	fmt.Printf("⚙️  Config precedence: %s\n\n", config.Precedence)
+
+	slog.Info("connecting to gRPC server", "endpoints", cfg.Endpoints())
+	// Keepalive pings detect connections silently dropped by intermediaries
+	connectOpts := append(cfg.ConnectOptions(),
+		client.WithKeepalive(*keepaliveTime, *keepaliveTimeout),
+		client.WithMaxMessageSize(int(maxMsgSize)),
+	)
+
+	// Endpoints are tried in priority order, and each reconnect fails over to the next one
+	failover := client.NewFailover(cfg.Endpoints(), func(endpoint string) (*grpc.ClientConn, error) {
+		return client.Connect(endpoint, cfg.APIKey, connectOpts...)
+	})
+	ctx := context.Background()
+
+	// The client connects lazily, so wait until an endpoint is actually ready
+	conn, err := failover.Connect(ctx, cfg.ConnectTimeout)
+	if err != nil {
+		logging.Exit(client.ExitConnectionFailure, "failed to connect", "err", err)
+	}
+	defer conn.Close()
+
+	slog.Info("connected", "endpoint", failover.Active())
+
+	// Without another endpoint to fail over to, an open breaker ends the run
+	breaker := client.WithCircuitBreaker(client.Breaker{
+		Threshold:    *breakerThreshold,
+		Window:       *breakerWindow,
+		Cooldown:     *breakerCooldown,
+		ExitWhenOpen: len(cfg.Endpoints()) == 1,
+	})
+
+	// First Ctrl+C (or SIGTERM) drains the stream, a second one or an overrun
+	// of -shutdown-timeout forces an immediate exit
+	ctx, stop := shutdown.Listen(ctx, *shutdownTimeout)
+	defer stop()
+
+	// Create request - 0 means latest, otherwise replay from the start time (see -from)
+	request := &pb.Timestamp{Timestamp: cfg.RequestTimestamp()}
+
+	fmt.Println("📥 Writing blocks to Parquet...")
+	fmt.Print("Press Ctrl+C to stop streaming (twice to force quit)\n\n")
+
+	partitions := &partitionWriter{dir: *dir}
+	// Remembers recent blocks so ones re-delivered after a reconnect are skipped
+	dedup := stats.NewDedup[stats.BlockKey](dedupSize)
+	blockCount, undecodable, untimed := 0, 0, 0
+
+	err = client.StreamWithReconnect(ctx, conn, failover.Redial, pb.HyperLiquidL1GatewayClient.StreamBlocks, request, func(response *pb.Block) {
+		// Some endpoints send empty heartbeat-like frames, which aren't blocks
+		if len(response.Data) == 0 {
+			return
+		}
+		blockCount++
+
+		block, err := model.DecodeBlock(response.Data)
+		if err != nil {
+			undecodable++
+			slog.Error("failed to decode block", "block", blockCount, "err", err)
+			return
+		}
+		// The block time picks the partition, so blocks without one are left out
+		produced, ok := block.ABCIBlock.Timestamp()
+		if !ok {
+			untimed++
+			slog.Warn("skipping block without a time", "height", block.ABCIBlock.Height)
+			return
+		}
+		if dedup.Seen(stats.BlockKey{Height: block.ABCIBlock.Height, Time: block.ABCIBlock.BlockTime}) {
+			slog.Debug("skipping duplicate block", "height", block.ABCIBlock.Height)
+			return
+		}
+
+		if err := partitions.Write(block.Summary(), produced); err != nil {
+			slog.Error("failed to write block to Parquet", "height", block.ABCIBlock.Height, "err", err)
+		}
+	}, client.WithIdleTimeout(*idleTimeout), breaker)
+	exitCode := client.ExitOK
+	if err != nil {
+		message, auth := client.ClassifyError(err)
+		if auth {
+			logging.Exit(client.ExitAuthFailure, message, "err", err)
+		}
+		slog.Error("stream ended with an error", "err", err)
+		exitCode = client.ExitStreamError
+	}
+
+	// The open partition only becomes readable once its footer is written
+	if err := partitions.Close(); err != nil {
+		slog.Error("failed to finalize Parquet file", "err", err)
+	}
+
+	fmt.Printf("\n📊 Total blocks received: %d\n", blockCount)
+	fmt.Printf("🧱 Blocks written: %d\n", partitions.Rows())
+	if late := partitions.Late(); late > 0 {
+		fmt.Printf("⏪ Blocks from an earlier day, written to files of their own: %d\n", late)
+	}
+	fmt.Printf("🔁 Duplicate blocks skipped: %d\n", dedup.Duplicates())
+	if undecodable > 0 || untimed > 0 {
+		fmt.Printf("⚠️  Blocks skipped: %d undecodable, %d without a time\n", undecodable, untimed)
+	}
+	fmt.Printf("🗂️  Files written: %d\n", len(partitions.Files()))
+	for _, path := range partitions.Files() {
+		fmt.Printf("  • %s\n", path)
+	}
+
+	// The summary is printed and all output written, so skipping deferred
+	// cleanup is safe
+	if exitCode != client.ExitOK {
+		os.Exit(exitCode)
+	}
+}
+
+// partitionWriter writes block summaries to one Parquet file per UTC day of
+// the block time, under dir/date=YYYY-MM-DD, the Hive layout that DuckDB,
+// Spark and most query engines read as a partition column. The file is
+// rotated when a block of a later day arrives. A block whose time went back
+// to an earlier day is written to a file of its own in that day's directory,
+// so the partition column never lies about a row and a finished file is never
+// reopened.
+type partitionWriter struct {
+	dir  string
+	open *partitionFile
+
+	rows  int
+	late  int
+	files []string
+}
+
+// Write adds the summary of a block produced at the given time, rotating to
+// a new partition file when the block is from a later day than the open one.
+func (p *partitionWriter) Write(summary model.BlockSummary, produced time.Time) error {
+	day := produced.UTC().Format(partitionDay)
+
+	counts := make(map[string]int64, len(summary.ActionCounts))
+	for actionType, n := range summary.ActionCounts {
+		counts[actionType] = int64(n)
+	}
+	row := blockRow{
+		Height:       summary.Height,
+		Time:         produced.UnixMilli(),
+		Proposer:     summary.Proposer,
+		ActionCounts: counts,
+		TotalActions: int64(summary.TotalActions),
+	}
+
+	if p.open != nil && day < p.open.day {
+		return p.writeLate(day, row)
+	}
+	if p.open == nil || day > p.open.day {
+		if err := p.Close(); err != nil {
+			return err
+		}
+		file, err := createPartitionFile(p.dir, day, summary.Height)
+		if err != nil {
+			return err
+		}
+		p.open = file
+		slog.Info("started partition", "day", day, "path", file.path)
+	}
+	if err := p.open.write(row); err != nil {
+		return err
+	}
+	p.rows++
+	return nil
+}
+
+// writeLate writes row, from a day before the open file's, to a file of its
+// own in the partition of its day
+func (p *partitionWriter) writeLate(day string, row blockRow) error {
+	file, err := createPartitionFile(p.dir, day, row.Height)
+	if err != nil {
+		return err
+	}
+	if err := file.write(row); err != nil {
+		file.file.Close()
+		return err
+	}
+	if err := file.close(); err != nil {
+		return err
+	}
+	p.rows++
+	p.late++
+	p.files = append(p.files, file.path)
+	slog.Warn("block time went back to an earlier day, wrote the block to a file of its own", "height", row.Height, "day", day, "path", file.path)
+	return nil
+}
+
+// Close finalizes the open file, if any.
+func (p *partitionWriter) Close() error {
+	if p.open == nil {
+		return nil
+	}
+	file := p.open
+	p.open = nil
+	if err := file.close(); err != nil {
+		return err
+	}
+	p.files = append(p.files, file.path)
+	slog.Info("finalized partition", "day", file.day, "path", file.path)
+	return nil
+}
+
+// Rows returns the number of blocks written.
+func (p *partitionWriter) Rows() int {
+	return p.rows
+}
+
+// Late returns the number of blocks written to a file of their own because
+// their time went back to an earlier day.
+func (p *partitionWriter) Late() int {
+	return p.late
+}
+
+// Files returns the finalized files, in the order they were written.
+func (p *partitionWriter) Files() []string {
+	return p.files
+}
+
+// partitionFile is one Parquet file of a partition. It is written as
+// name.partial and renamed once its footer is written, so readers only ever
+// see complete files.
+type partitionFile struct {
+	day       string
+	path      string
+	file      *os.File
+	w         *parquet.GenericWriter[blockRow]
+	pending   int
+	lastFlush time.Time
+}
+
+// createPartitionFile starts a file in the partition of day under dir, named
+// after the height of its first block so a later run never overwrites it
+func createPartitionFile(dir, day string, height int64) (*partitionFile, error) {
+	partition := filepath.Join(dir, "date="+day)
+	if err := os.MkdirAll(partition, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(partition, fmt.Sprintf("blocks-%d.parquet", height))
+	file, err := os.Create(path + ".partial")
+	if err != nil {
+		return nil, err
+	}
+	return &partitionFile{
+		day:       day,
+		path:      path,
+		file:      file,
+		w:         parquet.NewGenericWriter[blockRow](file, parquet.Compression(&parquet.Zstd)),
+		lastFlush: time.Now(),
+	}, nil
+}
+
+// write adds row, flushing a row group after partitionRowGroupBlocks rows or
+// partitionFlushInterval
+func (f *partitionFile) write(row blockRow) error {
+	if _, err := f.w.Write([]blockRow{row}); err != nil {
+		return err
+	}
+	f.pending++
+	if f.pending >= partitionRowGroupBlocks || time.Since(f.lastFlush) >= partitionFlushInterval {
+		return f.flush()
+	}
+	return nil
+}
+
+// flush writes the pending rows as a row group
+func (f *partitionFile) flush() error {
+	f.lastFlush = time.Now()
+	if f.pending == 0 {
+		return nil
+	}
+	if err := f.w.Flush(); err != nil {
+		return err
+	}
+	f.pending = 0
+	return nil
+}
+
+// close flushes the pending rows, writes the footer, which makes the file
+// readable, and renames it into place
+func (f *partitionFile) close() error {
+	if err := f.flush(); err != nil {
+		f.file.Close()
+		return err
+	}
+	if err := f.w.Close(); err != nil {
+		f.file.Close()
+		return err
+	}
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.path+".partial", f.path)
+}
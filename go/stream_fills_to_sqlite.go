@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	_ "modernc.org/sqlite" // pure Go SQLite driver, registers "sqlite"
+
+	pb "github.com/dwellir/grpc-code-examples/go/internal/api"
+	"github.com/dwellir/grpc-code-examples/go/internal/buildinfo"
+	"github.com/dwellir/grpc-code-examples/go/internal/checkpoint"
+	"github.com/dwellir/grpc-code-examples/go/internal/client"
+	"github.com/dwellir/grpc-code-examples/go/internal/color"
+	"github.com/dwellir/grpc-code-examples/go/internal/config"
+	"github.com/dwellir/grpc-code-examples/go/internal/logging"
+	"github.com/dwellir/grpc-code-examples/go/internal/model"
+	"github.com/dwellir/grpc-code-examples/go/internal/shutdown"
+)
+
+// Prices and sizes are stored as TEXT to keep the exact decimals
+const createFillsTable = `
+CREATE TABLE IF NOT EXISTS fills (
+	height INTEGER NOT NULL,
+	time   INTEGER NOT NULL,
+	symbol TEXT NOT NULL,
+	side   TEXT NOT NULL,
+	price  TEXT NOT NULL,
+	size   TEXT NOT NULL,
+	hash   TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS fills_symbol_time ON fills (symbol, time);
+`
+
+const insertFill = `INSERT INTO fills (height, time, symbol, side, price, size, hash) VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+func main() {
+	cfg := config.Register(flag.CommandLine)
+	dbPath := flag.String("db", "fills.db", "SQLite database file to insert fills into")
+	batchSize := flag.Int("batch-size", 500, "commit after this many fills")
+	flushInterval := flag.Duration("flush-interval", time.Second, "commit pending fills at least this often")
+	checkpointPath := flag.String("checkpoint", "", "file recording the last committed block; on startup the stream resumes after it instead of at the latest block")
+	checkpointInterval := flag.Duration("checkpoint-interval", 5*time.Second, "write -checkpoint at most this often, 0 writes after every commit")
+	keepaliveTime := flag.Duration("keepalive-time", 30*time.Second, "interval between keepalive pings on an idle connection, 0 disables keepalive")
+	keepaliveTimeout := flag.Duration("keepalive-timeout", 10*time.Second, "how long to wait for a keepalive ping to be acknowledged")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 8*time.Second, "after Ctrl+C or SIGTERM, force exit if the summary isn't printed within this long, 0 waits indefinitely")
+	idleTimeout := flag.Duration("idle-timeout", 60*time.Second, "restart the stream when no message arrives for this long, 0 disables")
+	breakerThreshold := flag.Int("breaker-threshold", 5, "open the circuit breaker after this many consecutive reconnect failures within -breaker-window, 0 disables it")
+	breakerWindow := flag.Duration("breaker-window", 5*time.Minute, "time window in which -breaker-threshold failures open the circuit breaker")
+	breakerCooldown := flag.Duration("breaker-cooldown", 5*time.Minute, "how long an open circuit breaker waits before trying again; with a single endpoint the example exits instead")
+	maxMsgSize := config.ByteSize(client.DefaultMaxMessageSize)
+	flag.Var(&maxMsgSize, "max-msg-size", "maximum message size to receive, e.g. 256MB or 1GB")
+	showVersion := flag.Bool("version", false, "print the version, commit and build date and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(buildinfo.String())
+		return
+	}
+
+	if err := cfg.LoadFile(); err != nil {
+		logging.Fatal("invalid -config file", "err", err)
+	}
+	if err := cfg.SetupLogging(); err != nil {
+		logging.Fatal("invalid logging configuration", "err", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		logging.Fatal("invalid configuration", "err", err)
+	}
+	if warning := cfg.SecurityWarning(); warning != "" {
+		slog.Warn(warning)
+	}
+	if *batchSize < 1 || *flushInterval <= 0 {
+		logging.Fatal("-batch-size and -flush-interval must be positive")
+	}
+	if *checkpointInterval < 0 {
+		logging.Fatal("-checkpoint-interval must not be negative", "interval", *checkpointInterval)
+	}
+
+	// A saved checkpoint replaces the latest block as the start, while an
+	// explicit -from now or timestamp still wins
+	var resume checkpoint.Checkpoint
+	var checkpoints *checkpoint.Batch
+	if *checkpointPath != "" {
+		saved, ok, err := checkpoint.Load(*checkpointPath)
+		if err != nil {
+			logging.Fatal("failed to read checkpoint", "path", *checkpointPath, "err", err)
+		}
+		switch {
+		case ok && cfg.StartMode() == config.FromLatest:
+			resume = saved
+			cfg.ResumeFrom(saved.Time)
+			slog.Info("resuming from checkpoint", "path", *checkpointPath, "height", saved.Height, "time", saved.Time)
+		case ok:
+			slog.Info("ignoring checkpoint, -from sets the start", "path", *checkpointPath, "height", saved.Height)
+		}
+		checkpoints = checkpoint.NewBatch(checkpoint.NewWriter(*checkpointPath, *checkpointInterval))
+	}
+
+	// -dry-run stops here, before any file is written or stream opened
+	if cfg.DryRun {
+		color.Setup(os.Stdout, false)
+		os.Exit(cfg.RunDryRun(os.Stdout))
+	}
+
+	store, err := openFillStore(*dbPath)
+	if err != nil {
+		logging.Fatal("failed to open database", "path", *dbPath, "err", err)
+	}
+	defer store.Close()
+
+	// API key is optional - some endpoints are public and don't require authentication
+	if cfg.APIKey == "" {
+		slog.Info("no API key provided, connecting to a public endpoint")
+	}
+
+	fmt.Println("🚀 Hyperliquid Go gRPC Client - Stream Fills to SQLite")
+	fmt.Println("=======================================================")
+	fmt.Printf("📡 Endpoints: %s\n", strings.Join(cfg.Endpoints(), ", "))
+	fmt.Printf("🔒 Transport: %s\n", cfg.TransportDescription())
+	fmt.Printf("⏱️  Start: %s\n", cfg.StartDescription())
+	fmt.Printf("🎬 Mode: %s\n", cfg.StreamDescription())
+	fmt.Printf("🗄️  Database: %s (commit every %d fills or %v)\n", *dbPath, *batchSize, *flushInterval)
+	if *checkpointPath != "" {
+		fmt.Printf("💾 Checkpoint: %s (%s)\n", *checkpointPath, checkpointDescription(resume))
+	}
+	fmt.Printf("⚙️  Config precedence: %s\n\n", config.Precedence)
+
+	slog.Info("connecting to gRPC server", "endpoints", cfg.Endpoints())
+	// Keepalive pings detect connections silently dropped by intermediaries
+	connectOpts := append(cfg.ConnectOptions(),
+		client.WithKeepalive(*keepaliveTime, *keepaliveTimeout),
+		client.WithMaxMessageSize(int(maxMsgSize)),
+	)
+
+	// Endpoints are tried in priority order, and each reconnect fails over to the next one
+	failover := client.NewFailover(cfg.Endpoints(), func(endpoint string) (*grpc.ClientConn, error) {
+		return client.Connect(endpoint, cfg.APIKey, connectOpts...)
+	})
+	ctx := context.Background()
+
+	// The client connects lazily, so wait until an endpoint is actually ready
+	conn, err := failover.Connect(ctx, cfg.ConnectTimeout)
+	if err != nil {
+		logging.Exit(client.ExitConnectionFailure, "failed to connect", "err", err)
+	}
+	defer conn.Close()
+
+	slog.Info("connected", "endpoint", failover.Active())
+
+	// Without another endpoint to fail over to, an open breaker ends the run
+	breaker := client.WithCircuitBreaker(client.Breaker{
+		Threshold:    *breakerThreshold,
+		Window:       *breakerWindow,
+		Cooldown:     *breakerCooldown,
+		ExitWhenOpen: len(cfg.Endpoints()) == 1,
+	})
+
+	// First Ctrl+C (or SIGTERM) drains the stream, a second one or an overrun
+	// of -shutdown-timeout forces an immediate exit
+	ctx, stop := shutdown.Listen(ctx, *shutdownTimeout)
+	defer stop()
+
+	// Create request - 0 means latest, otherwise replay from the start time (see -from)
+	request := &pb.Timestamp{Timestamp: cfg.RequestTimestamp()}
+
+	// The receive loop hands blocks to a single writer goroutine that owns the
+	// transaction, so slow disk writes apply backpressure to the stream
+	blocks := make(chan *model.BlockFills, 100)
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		writeFills(store, blocks, *batchSize, *flushInterval, checkpoints)
+	}()
+
+	fmt.Println("📥 Streaming block fills into SQLite...")
+	fmt.Print("Press Ctrl+C to stop streaming (twice to force quit)\n\n")
+
+	blockFillsCount, skipped := 0, 0
+	err = client.StreamWithReconnect(ctx, conn, failover.Redial, pb.HyperLiquidL1GatewayClient.StreamBlockFills, request, func(response *pb.BlockFills) {
+		blockFillsCount++
+
+		blockFills, err := model.DecodeBlockFills(response.Data)
+		if err != nil {
+			slog.Error("failed to decode block fills", "block", blockFillsCount, "err", err)
+			return
+		}
+		// Resuming from the checkpoint's time delivers its block again
+		if resume.Covers(blockFills.Height) {
+			skipped++
+			return
+		}
+		blocks <- blockFills
+	}, client.WithIdleTimeout(*idleTimeout), breaker)
+	exitCode := client.ExitOK
+	if err != nil {
+		message, auth := client.ClassifyError(err)
+		if auth {
+			logging.Exit(client.ExitAuthFailure, message, "err", err)
+		}
+		slog.Error("stream ended with an error", "err", err)
+		exitCode = client.ExitStreamError
+	}
+
+	// Let the writer commit the final batch before exiting
+	close(blocks)
+	<-writerDone
+
+	fmt.Printf("\n📊 Total block fills received: %d\n", blockFillsCount)
+	fmt.Printf("💾 Fills inserted into %s: %d\n", *dbPath, store.Inserted())
+	if checkpoints != nil {
+		if skipped > 0 {
+			fmt.Printf("⏭️  Blocks skipped as already committed: %d\n", skipped)
+		}
+		if latest := checkpoints.Latest(); latest.Time != 0 {
+			fmt.Printf("📍 Checkpoint %s: height %d\n", *checkpointPath, latest.Height)
+		}
+		if checkpoints.Held() {
+			fmt.Println("⚠️  The checkpoint stops before the first block that failed to be stored, so the next run inserts it and the blocks after it again")
+		}
+	}
+
+	// The summary is printed and all output written, so skipping deferred
+	// cleanup is safe
+	if exitCode != client.ExitOK {
+		os.Exit(exitCode)
+	}
+}
+
+// checkpointDescription describes where a checkpointed run starts
+func checkpointDescription(resume checkpoint.Checkpoint) string {
+	if resume.Time == 0 {
+		return "not resuming"
+	}
+	return fmt.Sprintf("resuming after height %d", resume.Height)
+}
+
+// writeFills inserts the fills of every block received on blocks and commits
+// once batchSize fills are pending or flushInterval has passed. The final
+// batch is committed when blocks is closed. Each commit advances checkpoints,
+// if not nil, which is saved a last time on return. A block that fails to be
+// inserted or committed holds the checkpoint before it for the rest of the
+// run.
+func writeFills(store *fillStore, blocks <-chan *model.BlockFills, batchSize int, flushInterval time.Duration, checkpoints *checkpoint.Batch) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	defer func() {
+		if err := checkpoints.Flush(); err != nil {
+			slog.Error("failed to write checkpoint", "err", err)
+		}
+	}()
+
+	commit := func() {
+		err := store.Commit()
+		if err != nil {
+			slog.Error("failed to commit fills", "err", err)
+		}
+		if err := checkpoints.Commit(err); err != nil {
+			slog.Error("failed to write checkpoint", "err", err)
+		}
+	}
+
+	for {
+		select {
+		case blockFills, ok := <-blocks:
+			if !ok {
+				commit()
+				return
+			}
+			err := store.Add(blockFills)
+			if err != nil {
+				slog.Error("failed to insert fills", "height", blockFills.Height, "err", err)
+			}
+			checkpoints.Add(blockFills.Height, blockFills.Time, err)
+			if store.Pending() >= batchSize {
+				commit()
+			}
+		case <-ticker.C:
+			commit()
+		}
+	}
+}
+
+// fillStore inserts fills into SQLite in batched transactions
+type fillStore struct {
+	db       *sql.DB
+	insert   *sql.Stmt
+	tx       *sql.Tx
+	pending  int
+	inserted int
+}
+
+// openFillStore opens the database at path and creates the fills table if
+// it doesn't exist
+func openFillStore(path string) (*fillStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(createFillsTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create table: %w", err)
+	}
+
+	insert, err := db.Prepare(insertFill)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("prepare insert: %w", err)
+	}
+
+	return &fillStore{db: db, insert: insert}, nil
+}
+
+// Add inserts the fills of a block into the current transaction, starting
+// one if needed
+func (s *fillStore) Add(blockFills *model.BlockFills) error {
+	if s.tx == nil {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		s.tx = tx
+	}
+
+	stmt := s.tx.Stmt(s.insert)
+	for _, fill := range blockFills.Fills {
+		if _, err := stmt.Exec(blockFills.Height, blockFills.Time, fill.Symbol, fill.Side, fill.Price, fill.Size, fill.Hash); err != nil {
+			return err
+		}
+		s.pending++
+	}
+	return nil
+}
+
+// Pending returns the number of fills inserted but not yet committed
+func (s *fillStore) Pending() int {
+	return s.pending
+}
+
+// Inserted returns the number of committed fills
+func (s *fillStore) Inserted() int {
+	return s.inserted
+}
+
+// Commit commits the current transaction, if any
+func (s *fillStore) Commit() error {
+	if s.tx == nil {
+		return nil
+	}
+
+	err := s.tx.Commit()
+	s.tx = nil
+	if err != nil {
+		s.pending = 0
+		return err
+	}
+
+	s.inserted += s.pending
+	s.pending = 0
+	return nil
+}
+
+// Close commits pending fills and closes the database
+func (s *fillStore) Close() error {
+	commitErr := s.Commit()
+	s.insert.Close()
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	return commitErr
+}